@@ -0,0 +1,597 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/overlay"
+	"go-snip/internal/record"
+	"go-snip/internal/retention"
+	"go-snip/internal/utils"
+)
+
+var (
+	// errUsage is returned for invalid command lines; usage has already been printed.
+	errUsage = errors.New("usage error")
+	// errSelectionCancelled is returned when an interactive selection is cancelled, so scripts
+	// see a failure rather than an empty success.
+	errSelectionCancelled = errors.New("selection cancelled")
+)
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Usage:
+  go-snip [-out <dir>]                      run the hotkey daemon
+  go-snip [-out <dir>] capture full [--display N|primary|cursor|all] [--delay S] [-o <file>|-] [--format F] [--note TEXT]
+  go-snip [-out <dir>] capture area [--delay S] [-o <file>|-] [--format F] [--note TEXT]
+  go-snip [-out <dir>] capture window [--decorations] [--delay S] [-o <file>|-] [--format F] [--note TEXT]
+  go-snip [-out <dir>] capture last [--delay S] [-o <file>|-] [--format F] [--note TEXT]
+  go-snip [-out <dir>] capture region --rect x0,y0,x1,y1 [--display N] [--delay S] [-o <file>|-] [--format F] [--note TEXT]
+  go-snip [-out <dir>] capture region --name NAME [--delay S] [-o <file>|-] [--format F] [--note TEXT]
+  go-snip [-out <dir>] capture scroll [--rect x0,y0,x1,y1 [--display N] | --name NAME] [--every D] [--for D] [--idle D] [--delay S] [-o <file>|-] [--format F] [--note TEXT]
+  go-snip [-out <dir>] timelapse [--every D] [--for D] [--display N|primary|cursor|all | --name REGION] [--skip-identical] [--format F]
+  go-snip [-out <dir>] record [--rect x0,y0,x1,y1 [--display N] | --name REGION] [--fps N] [--for D] [--format gif|apng] [-o <file>]
+  go-snip [-out <dir>] cleanup [--dry-run] [--days N] [--max-mb N] [--max-files N]
+  go-snip [-out <dir>] history list [--limit N] [--mode M] [--since YYYY-MM-DD] [--until YYYY-MM-DD]
+  go-snip [-out <dir>] history search QUERY... [--limit N] [--mode M] [--since YYYY-MM-DD] [--until YYYY-MM-DD]
+  go-snip [-out <dir>] history show|open N|PATH
+  go-snip inspect FILE
+  go-snip displays
+
+Captures are saved into the output directory unless -o is given; "-o -" writes the image to stdout.
+The format (png, jpeg, gif, bmp, tiff) comes from --format, else the -o extension, else the config.
+Region rectangles are in pixels relative to the top-left corner of the display.
+--name captures a region saved in the config ("regions"), checked against the current displays.
+"capture last" repeats the last area selection (from "capture area" or the area hotkey).
+"capture scroll" grabs the region (selected interactively unless --rect or --name is given)
+every D while you scroll it and stitches the frames into one tall image; it stops after --for,
+after --idle without anything new scrolling into view, or when interrupted.
+--delay waits S seconds before capturing (before showing the selection for area captures).
+--note adds notes to the metadata embedded in PNG captures (time, go-snip version, display,
+selection, hostname and name; "stripMetadata" in the config turns it off).
+timelapse saves numbered frames into a new folder of the output directory every D (e.g. 5s)
+until --for has passed or it is interrupted, then prints the folder.
+record captures a region (selected interactively unless --rect or --name is given) at --fps
+until --for has passed or it is interrupted, then saves it as an animated GIF or APNG and
+prints the path; frames dropped because encoding fell behind are reported.
+cleanup deletes captures beyond the retention limits (default: from the config) and prints
+them, oldest first; --dry-run only prints what would be deleted. Only files named by go-snip
+are touched.
+history lists saved captures, newest first, as: number, time, mode, size, name and path.
+search matches every query word against names, paths, modes and tags; show prints an entry
+as JSON and open opens it, by number from list or by path. The index is rebuilt from the
+output directory if it is missing, and forgets files deleted outside go-snip.
+inspect prints the metadata embedded in a PNG capture as JSON.
+`)
+}
+
+// runCommand runs a one-shot subcommand. It prints the saved path (or the PNG for -o -) to out.
+func runCommand(ctx context.Context, env captureEnv, args []string, outDir string, cfg config.Config, out io.Writer) error {
+	switch args[0] {
+	case "capture":
+		if len(args) < 2 {
+			return usageError("capture: missing mode (full, area, window, region, last or scroll)")
+		}
+		return runCapture(ctx, env, args[1], args[2:], outDir, cfg, out)
+	case "timelapse":
+		return runTimelapseCommand(ctx, env, args[1:], outDir, cfg, out)
+	case "record":
+		return runRecordCommand(ctx, env, args[1:], outDir, cfg, out)
+	case "cleanup":
+		return runCleanupCommand(args[1:], outDir, cfg, env.now, out)
+	case "history":
+		return runHistoryCommand(env, args[1:], outDir, cfg, out)
+	case "inspect":
+		return runInspectCommand(args[1:], out)
+	case "displays":
+		return runDisplays(env.src, out)
+	case "help", "-h", "--help":
+		printUsage(out)
+		return nil
+	}
+	return usageError(fmt.Sprintf("unknown command %q", args[0]))
+}
+
+func usageError(msg string) error {
+	fmt.Fprintf(os.Stderr, "go-snip: %s\n\n", msg)
+	printUsage(os.Stderr)
+	return errUsage
+}
+
+func runCapture(ctx context.Context, env captureEnv, mode string, args []string, outDir string, cfg config.Config, out io.Writer) error {
+	fs := flag.NewFlagSet("capture "+mode, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dest := fs.String("o", "", `Output file ("-" for stdout); defaults to a new file in the output directory`)
+	display := fs.String("display", "", "Display index, or primary/cursor/all")
+	rectFlag := fs.String("rect", "", "Region x0,y0,x1,y1 relative to the display (region mode)")
+	nameFlag := fs.String("name", "", "Named region from the config (region mode)")
+	formatFlag := fs.String("format", "", "Image format: png, jpeg, gif, bmp or tiff (default: from -o extension, else config)")
+	delay := fs.Int("delay", 0, "Seconds to wait before capturing")
+	decorations := fs.Bool("decorations", false, "Include the window frame and title bar (window mode)")
+	note := fs.String("note", "", "Notes to embed in the PNG metadata")
+	scrollDefaults := scrollOptionsFor(cfg)
+	every := fs.Duration("every", scrollDefaults.interval, "Time between frames (scroll mode)")
+	length := fs.Duration("for", scrollDefaults.maxDuration, "Longest capture, 0 for no limit (scroll mode)")
+	idle := fs.Duration("idle", scrollDefaults.idle, "Stop once nothing new scrolled into view for this long, 0 to disable (scroll mode)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(fmt.Sprintf("capture %s: %v", mode, err))
+	}
+	if fs.NArg() > 0 {
+		return usageError(fmt.Sprintf("capture %s: unexpected argument %q", mode, fs.Arg(0)))
+	}
+
+	opts := captureOptionsFor(cfg)
+	save := opts.save
+	switch {
+	case *formatFlag != "":
+		f, err := utils.ParseFormat(*formatFlag)
+		if err != nil {
+			return usageError(fmt.Sprintf("--format: %v", err))
+		}
+		save.Format = f
+	case *dest != "" && *dest != "-":
+		if _, err := utils.ParseFormat(filepath.Ext(*dest)); err == nil {
+			save.Format = "" // infer from the -o extension
+		}
+	}
+
+	if *delay < 0 {
+		return usageError(fmt.Sprintf("--delay: negative delay %d", *delay))
+	}
+	wait := func(ctx context.Context) error {
+		if *delay == 0 {
+			return nil
+		}
+		return waitDelay(ctx, env, time.Duration(*delay)*time.Second)
+	}
+
+	opts.save, opts.mode, opts.notes = save, mode, *note
+	var img image.Image
+	switch mode {
+	case "full":
+		policy := displayPolicy(cfg)
+		if *display != "" {
+			p, err := parseDisplayFlag(*display)
+			if err != nil {
+				return usageError(err.Error())
+			}
+			if err := checkDisplayIndex(env.src, p); err != nil {
+				return err
+			}
+			policy = p
+		}
+		if err := wait(ctx); err != nil {
+			return err
+		}
+		full, bounds, err := capture.CapturePolicy(env.src, policy)
+		if err != nil {
+			return err
+		}
+		img = full
+		opts.rect, opts.display = bounds, policyDisplay(env.src, policy, bounds)
+	case "region":
+		if *nameFlag != "" {
+			if *rectFlag != "" || *display != "" {
+				return usageError("capture region: --name can't be combined with --rect or --display")
+			}
+			rect, err := namedRegionRect(env.src, *nameFlag, cfg.Regions)
+			if err != nil {
+				return err
+			}
+			if err := wait(ctx); err != nil {
+				return err
+			}
+			img, err = captureDesktopRect(env.src, rect)
+			if err != nil {
+				return err
+			}
+			opts.locate(env.src, rect)
+			opts.tags = append(opts.tags, *nameFlag)
+			break
+		}
+		if *rectFlag == "" {
+			return usageError("capture region: --rect or --name is required")
+		}
+		local, err := parseRect(*rectFlag)
+		if err != nil {
+			return usageError(err.Error())
+		}
+		index := 0
+		if *display != "" {
+			index, err = strconv.Atoi(*display)
+			if err != nil || index < 0 {
+				return usageError(fmt.Sprintf("capture region: --display must be an index, got %q", *display))
+			}
+		}
+		rect, err := displayRegionRect(env.src, local, index)
+		if err != nil {
+			return err
+		}
+		if err := wait(ctx); err != nil {
+			return err
+		}
+		img, err = env.src.CaptureRect(rect)
+		if err != nil {
+			return err
+		}
+		opts.rect, opts.display = rect, index
+	case "area":
+		err := runEntry(ctx, func(ctx context.Context) error {
+			if err := wait(ctx); err != nil {
+				return err
+			}
+			rect, cancelled, err := env.selectArea(env.src, overlay.AreaOptions{Constraint: opts.constraint})
+			if err != nil {
+				return err
+			}
+			if cancelled {
+				return errSelectionCancelled
+			}
+			rememberRegion(env, rect)
+			opts.locate(env.src, rect)
+			img, err = captureDesktopRect(env.src, rect)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	case "last":
+		if err := wait(ctx); err != nil {
+			return err
+		}
+		rect, err := lastRegionRect(env)
+		if err != nil {
+			return err
+		}
+		img, err = captureDesktopRect(env.src, rect)
+		if err != nil {
+			return err
+		}
+		opts.locate(env.src, rect)
+	case "scroll":
+		if *every <= 0 || *length < 0 || *idle < 0 {
+			return usageError("capture scroll: --every must be positive, --for and --idle not negative")
+		}
+		if err := wait(ctx); err != nil {
+			return err
+		}
+		rect, err := commandRegion(ctx, env, cfg, "capture scroll", *nameFlag, *rectFlag, *display)
+		if err != nil {
+			return err
+		}
+		st, err := runScroll(ctx, env, rect, scrollOptions{interval: *every, maxDuration: *length, idle: *idle})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "go-snip: scrolling capture: %s\n", scrollSummary(st))
+		img = st.Image()
+		opts.locate(env.src, rect)
+	case "window":
+		err := runEntry(ctx, func(ctx context.Context) error {
+			if err := wait(ctx); err != nil {
+				return err
+			}
+			rect, cancelled, err := selectWindow(env, *decorations || cfg.IncludeDecorations)
+			if err != nil {
+				return err
+			}
+			if cancelled {
+				return errSelectionCancelled
+			}
+			opts.locate(env.src, rect)
+			img, err = captureDesktopRect(env.src, rect)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		return usageError(fmt.Sprintf("capture: unknown mode %q", mode))
+	}
+
+	return writeCapture(env, img, *dest, outDir, opts, out)
+}
+
+func runTimelapseCommand(ctx context.Context, env captureEnv, args []string, outDir string, cfg config.Config, out io.Writer) error {
+	opts := timelapseOptionsFor(cfg)
+	fs := flag.NewFlagSet("timelapse", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	every := fs.Duration("every", opts.interval, "Time between frames")
+	length := fs.Duration("for", opts.duration, "How long to run (0: until interrupted)")
+	display := fs.String("display", "", "Display index, or primary/cursor/all")
+	name := fs.String("name", "", "Named region from the config (default: timelapseRegion)")
+	skip := fs.Bool("skip-identical", opts.skipIdentical, "Drop frames identical to the previous one")
+	formatFlag := fs.String("format", "", "Image format: png, jpeg, gif, bmp or tiff (default: config)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(fmt.Sprintf("timelapse: %v", err))
+	}
+	if fs.NArg() > 0 {
+		return usageError(fmt.Sprintf("timelapse: unexpected argument %q", fs.Arg(0)))
+	}
+	if *every <= 0 || *length < 0 {
+		return usageError("timelapse: --every must be positive and --for not negative")
+	}
+	opts.interval, opts.duration, opts.skipIdentical = *every, *length, *skip
+	switch {
+	case *display != "" && *name != "":
+		return usageError("timelapse: --display can't be combined with --name")
+	case *display != "":
+		p, err := parseDisplayFlag(*display)
+		if err != nil {
+			return usageError(err.Error())
+		}
+		if err := checkDisplayIndex(env.src, p); err != nil {
+			return err
+		}
+		opts.policy, opts.region = p, ""
+	case *name != "":
+		opts.region = *name
+	}
+	if *formatFlag != "" {
+		f, err := utils.ParseFormat(*formatFlag)
+		if err != nil {
+			return usageError(fmt.Sprintf("--format: %v", err))
+		}
+		opts.save.Format = f
+	}
+
+	stats, err := runTimelapse(ctx, env, cfg.Regions, outDir, opts)
+	if stats.Saved > 0 {
+		fmt.Fprintln(out, stats.Dir)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "go-snip: timelapse %s\n", stats)
+	return nil
+}
+
+func runRecordCommand(ctx context.Context, env captureEnv, args []string, outDir string, cfg config.Config, out io.Writer) error {
+	opts := recordOptionsFor(cfg)
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dest := fs.String("o", "", "Output file; defaults to a new file in the output directory")
+	rectFlag := fs.String("rect", "", "Region x0,y0,x1,y1 relative to the display")
+	display := fs.String("display", "", "Display index for --rect (default 0)")
+	name := fs.String("name", "", "Named region from the config")
+	fps := fs.Int("fps", int(time.Second/opts.Interval()), "Frames per second")
+	length := fs.Duration("for", opts.maxDuration, "How long to record (0: until interrupted)")
+	formatFlag := fs.String("format", "", "gif or apng (default: from -o extension, else config)")
+	if err := fs.Parse(args); err != nil {
+		return usageError(fmt.Sprintf("record: %v", err))
+	}
+	if fs.NArg() > 0 {
+		return usageError(fmt.Sprintf("record: unexpected argument %q", fs.Arg(0)))
+	}
+	if *fps < 1 || *length < 0 {
+		return usageError("record: --fps must be positive and --for not negative")
+	}
+	opts.FPS, opts.maxDuration = *fps, *length
+	switch {
+	case *formatFlag != "":
+		f, err := record.ParseFormat(*formatFlag)
+		if err != nil {
+			return usageError(fmt.Sprintf("--format: %v", err))
+		}
+		opts.Format = f
+	case *dest != "":
+		if f, err := record.ParseFormat(strings.TrimPrefix(filepath.Ext(*dest), ".")); err == nil {
+			opts.Format = f
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return usageError(err.Error())
+	}
+
+	rect, err := commandRegion(ctx, env, cfg, "record", *name, *rectFlag, *display)
+	if err != nil {
+		return err
+	}
+
+	path, stats, err := runRecording(ctx, env, rect, *dest, outDir, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "go-snip: recorded %s\n", stats)
+	_, err = fmt.Fprintln(out, path)
+	return err
+}
+
+func runCleanupCommand(args []string, outDir string, cfg config.Config, now func() time.Time, out io.Writer) error {
+	opts := retentionOptionsFor(cfg)
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dryRun := fs.Bool("dry-run", false, "Only print what would be deleted")
+	days := fs.Int("days", int(opts.policy.MaxAge/(24*time.Hour)), "Delete captures older than N days, 0 for no limit")
+	maxMB := fs.Int64("max-mb", opts.policy.MaxBytes>>20, "Keep the newest captures within N MiB, 0 for no limit")
+	maxFiles := fs.Int("max-files", opts.policy.MaxFiles, "Keep the newest N captures, 0 for no limit")
+	if err := fs.Parse(args); err != nil {
+		return usageError(fmt.Sprintf("cleanup: %v", err))
+	}
+	if fs.NArg() > 0 {
+		return usageError(fmt.Sprintf("cleanup: unexpected argument %q", fs.Arg(0)))
+	}
+	opts.policy = retention.Policy{MaxAge: time.Duration(*days) * 24 * time.Hour, MaxBytes: *maxMB << 20, MaxFiles: *maxFiles}
+	if err := opts.policy.Validate(); err != nil {
+		return usageError(fmt.Sprintf("cleanup: %v", err))
+	}
+	if opts.policy.IsZero() {
+		return usageError("cleanup: no retention limits (set retentionDays, retentionMaxMB or retentionMaxFiles in the config, or pass --days, --max-mb or --max-files)")
+	}
+
+	files, err := retention.Scan(outDir, opts.match)
+	if err != nil {
+		return err
+	}
+	expired := retention.Expired(files, opts.policy, now())
+	for _, f := range expired {
+		fmt.Fprintln(out, f.Path)
+	}
+	if *dryRun {
+		var res retention.Result
+		for _, f := range expired {
+			res.Files++
+			res.Bytes += f.Size
+		}
+		fmt.Fprintf(os.Stderr, "go-snip: would delete %s of %d captures\n", res, len(files))
+		return nil
+	}
+	res, err := retention.Remove(outDir, expired)
+	fmt.Fprintf(os.Stderr, "go-snip: deleted %s of %d captures\n", res, len(files))
+	return err
+}
+
+// commandRegion returns the virtual-desktop rectangle of a subcommand's --name region, or of
+// --rect relative to display --display (default 0). With neither it lets the user select an area.
+func commandRegion(ctx context.Context, env captureEnv, cfg config.Config, cmd, name, rectFlag, display string) (image.Rectangle, error) {
+	switch {
+	case name != "" && (rectFlag != "" || display != ""):
+		return image.Rectangle{}, usageError(cmd + ": --name can't be combined with --rect or --display")
+	case display != "" && rectFlag == "":
+		return image.Rectangle{}, usageError(cmd + ": --display needs --rect")
+	case name != "":
+		return namedRegionRect(env.src, name, cfg.Regions)
+	case rectFlag != "":
+		local, err := parseRect(rectFlag)
+		if err != nil {
+			return image.Rectangle{}, usageError(err.Error())
+		}
+		index := 0
+		if display != "" {
+			index, err = strconv.Atoi(display)
+			if err != nil || index < 0 {
+				return image.Rectangle{}, usageError(fmt.Sprintf("%s: --display must be an index, got %q", cmd, display))
+			}
+		}
+		return displayRegionRect(env.src, local, index)
+	}
+
+	var rect image.Rectangle
+	err := runEntry(ctx, func(ctx context.Context) error {
+		r, cancelled, err := env.selectArea(env.src, overlay.AreaOptions{Constraint: captureOptionsFor(cfg).constraint})
+		if err != nil {
+			return err
+		}
+		if cancelled {
+			return errSelectionCancelled
+		}
+		rect = r
+		return nil
+	})
+	return rect, err
+}
+
+// displayRegionRect converts local, relative to the top-left corner of display index, into
+// virtual-desktop coordinates, failing if it doesn't fit on the display.
+func displayRegionRect(src capture.Source, local image.Rectangle, index int) (image.Rectangle, error) {
+	displays := capture.DisplayBounds(src)
+	if index < 0 || index >= len(displays) {
+		return image.Rectangle{}, fmt.Errorf("display %d not found (%d displays)", index, len(displays))
+	}
+	b := displays[index]
+	rect := local.Add(b.Min)
+	if !rect.In(b) {
+		return image.Rectangle{}, fmt.Errorf("region %v is outside display %d (%dx%d)", local, index, b.Dx(), b.Dy())
+	}
+	return rect, nil
+}
+
+// checkDisplayIndex fails if p selects a display index src doesn't have. CapturePolicy clamps the
+// index, which suits a config that outlived a monitor but not a script asking for a display.
+func checkDisplayIndex(src capture.Source, p capture.DisplayPolicy) error {
+	if p.Mode != capture.DisplayIndex {
+		return nil
+	}
+	if n := src.NumDisplays(); p.Index >= n {
+		return fmt.Errorf("display %d not found (%d displays)", p.Index, n)
+	}
+	return nil
+}
+
+// writeCapture saves img to dest ("-" streams the encoded image to out) or, if dest is empty,
+// to a new file in outDir named by opts. The saved path is printed to out.
+func writeCapture(env captureEnv, img image.Image, dest string, outDir string, opts captureOptions, out io.Writer) error {
+	t := env.now()
+	opts.save.Metadata = captureMetadata(env.src, t, "", opts)
+	if dest == "-" {
+		return utils.EncodeImage(out, img, opts.save)
+	}
+	if dest == "" {
+		if err := utils.EnsureDir(outDir); err != nil {
+			return fmt.Errorf("create output dir %q: %w", outDir, err)
+		}
+		path, err := saveCapture(img, outDir, t, "", opts)
+		if err != nil {
+			return err
+		}
+		dest = path
+	} else if err := utils.SaveImage(img, dest, opts.save); err != nil {
+		return err
+	}
+	env.saved(captureEntry(dest, t, "", img.Bounds().Size(), opts))
+	_, err := fmt.Fprintln(out, dest)
+	return err
+}
+
+func runDisplays(src capture.Source, out io.Writer) error {
+	displays := capture.DisplayBounds(src)
+	if len(displays) == 0 {
+		return capture.ErrNoActiveDisplays
+	}
+	for i, b := range displays {
+		if _, err := fmt.Fprintf(out, "%d\t%d,%d,%d,%d\t%dx%d\n", i, b.Min.X, b.Min.Y, b.Max.X, b.Max.Y, b.Dx(), b.Dy()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDisplayFlag parses --display: a display index or a display mode name.
+func parseDisplayFlag(s string) (capture.DisplayPolicy, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 {
+			return capture.DisplayPolicy{}, fmt.Errorf("--display: negative index %d", n)
+		}
+		return capture.DisplayPolicy{Mode: capture.DisplayIndex, Index: n}, nil
+	}
+	p := capture.DisplayPolicy{Mode: strings.ToLower(s)}
+	if p.Mode == capture.DisplayIndex {
+		return capture.DisplayPolicy{}, errors.New("--display: pass the index itself, e.g. --display 1")
+	}
+	if err := p.Validate(); err != nil {
+		return capture.DisplayPolicy{}, fmt.Errorf("--display: %w", err)
+	}
+	return p, nil
+}
+
+// parseRect parses "x0,y0,x1,y1" into a non-empty rectangle.
+func parseRect(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("--rect: want x0,y0,x1,y1, got %q", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("--rect: %q is not a number", strings.TrimSpace(p))
+		}
+		v[i] = n
+	}
+	r := image.Rect(v[0], v[1], v[2], v[3])
+	if r.Empty() {
+		return image.Rectangle{}, fmt.Errorf("--rect: %q is empty", s)
+	}
+	return r, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/utils"
+)
+
+func TestParseRect(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseRect("100, 200,800,600")
+	if err != nil {
+		t.Fatalf("parseRect() error: %v", err)
+	}
+	if want := image.Rect(100, 200, 800, 600); got != want {
+		t.Fatalf("parseRect() got=%v want=%v", got, want)
+	}
+
+	for _, bad := range []string{"", "1,2,3", "1,2,3,x", "10,10,10,20"} {
+		if _, err := parseRect(bad); err == nil {
+			t.Fatalf("parseRect(%q): expected error", bad)
+		}
+	}
+}
+
+func TestParseDisplayFlag(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in   string
+		want capture.DisplayPolicy
+	}{
+		{in: "1", want: capture.DisplayPolicy{Mode: capture.DisplayIndex, Index: 1}},
+		{in: "all", want: capture.DisplayPolicy{Mode: capture.DisplayAll}},
+		{in: "Cursor", want: capture.DisplayPolicy{Mode: capture.DisplayCursor}},
+	}
+	for _, tc := range cases {
+		got, err := parseDisplayFlag(tc.in)
+		if err != nil {
+			t.Fatalf("parseDisplayFlag(%q) error: %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Fatalf("parseDisplayFlag(%q) got=%+v want=%+v", tc.in, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"-1", "index", "left"} {
+		if _, err := parseDisplayFlag(bad); err == nil {
+			t.Fatalf("parseDisplayFlag(%q): expected error", bad)
+		}
+	}
+}
+
+func TestRunCommand_UnknownCommandIsUsageError(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	err := runCommand(t.Context(), defaultCaptureEnv(), []string{"frobnicate"}, t.TempDir(), config.Config{}, &out)
+	if !errors.Is(err, errUsage) {
+		t.Fatalf("expected errUsage, got=%v", err)
+	}
+}
+
+func TestWriteCapture_StdoutAndOutDir(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+
+	var stdout bytes.Buffer
+	if err := writeCapture(captureEnv{now: time.Now}, img, "-", "", captureOptions{}, &stdout); err != nil {
+		t.Fatalf("writeCapture(-) error: %v", err)
+	}
+	if !bytes.HasPrefix(stdout.Bytes(), []byte("\x89PNG")) {
+		t.Fatalf("expected PNG bytes on stdout")
+	}
+
+	var printed bytes.Buffer
+	now := func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local) }
+	if err := writeCapture(captureEnv{now: now}, img, "", t.TempDir(), captureOptions{}, &printed); err != nil {
+		t.Fatalf("writeCapture(outDir) error: %v", err)
+	}
+	if !bytes.Contains(printed.Bytes(), []byte("20250102_030405_000.png")) {
+		t.Fatalf("expected saved path, got=%q", printed.String())
+	}
+}
+
+func TestRunCommand_CaptureRegionFromFakeSource(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	var out bytes.Buffer
+	args := []string{"capture", "region", "--display", "1", "--rect", "2,3,12,8", "-o", "-"}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, t.TempDir(), config.Config{}, &out); err != nil {
+		t.Fatalf("runCommand() error: %v", err)
+	}
+
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatalf("decode stdout: %v", err)
+	}
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 5 {
+		t.Fatalf("bounds=%v want 10x5", img.Bounds())
+	}
+	if got, want := img.At(0, 0), capture.FakePixel(1, 42, 3); got != want {
+		t.Fatalf("pixel=%v want=%v", got, want)
+	}
+}
+
+func TestRunCommand_MissingDisplayIndexFails(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	for _, args := range [][]string{
+		{"capture", "region", "--display", "7", "--rect", "2,3,12,8", "-o", "-"},
+		{"capture", "full", "--display", "2", "-o", "-"},
+	} {
+		var out bytes.Buffer
+		err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, t.TempDir(), config.Config{}, &out)
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("runCommand(%v) error=%v, want display not found", args, err)
+		}
+		if out.Len() != 0 {
+			t.Fatalf("runCommand(%v) wrote %d bytes", args, out.Len())
+		}
+	}
+	if got := src.Captures(); len(got) != 0 {
+		t.Fatalf("captured %v for a missing display", got)
+	}
+}
+
+func TestRunCommand_Displays(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 1920, 1080), image.Rect(-1280, 0, 0, 1024))
+	var out bytes.Buffer
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), []string{"displays"}, "", config.Config{}, &out); err != nil {
+		t.Fatalf("runCommand() error: %v", err)
+	}
+	want := "0\t0,0,1920,1080\t1920x1080\n1\t-1280,0,0,1024\t1280x1024\n"
+	if out.String() != want {
+		t.Fatalf("output=%q want=%q", out.String(), want)
+	}
+}
+
+func TestWriteCapture_FormatFromOptions(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	now := func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local) }
+
+	var printed bytes.Buffer
+	if err := writeCapture(captureEnv{now: now}, img, "", t.TempDir(), captureOptions{save: utils.SaveOptions{Format: utils.FormatJPEG}}, &printed); err != nil {
+		t.Fatalf("writeCapture() error: %v", err)
+	}
+	if !bytes.Contains(printed.Bytes(), []byte("20250102_030405_000.jpg")) {
+		t.Fatalf("expected .jpg path, got=%q", printed.String())
+	}
+}
+
+func TestRunCommand_DelayHonorsCancellation(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 10, 10))
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	args := []string{"capture", "full", "--delay", "30", "-o", "-"}
+	err := runCommand(ctx, fakeEnv(src, image.Rectangle{}), args, t.TempDir(), config.Config{}, &bytes.Buffer{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("runCommand() error=%v want=%v", err, context.Canceled)
+	}
+	if got := src.Captures(); len(got) != 0 {
+		t.Fatalf("captured %v after cancellation", got)
+	}
+}
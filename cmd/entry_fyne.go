@@ -5,18 +5,16 @@ package main
 
 import (
 	"context"
-	"io"
 	"sync"
-	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/widget"
-
-	"go-snip/internal/config"
 )
 
-func runEntry(ctx context.Context, outDir string, cfgPath string, cfg config.Config, now func() time.Time, out io.Writer) error {
+// runEntry starts the Fyne app and runs fn in the background once the driver is up,
+// so fn may open windows (overlay, prompt, settings). The app quits when fn returns.
+func runEntry(ctx context.Context, fn func(ctx context.Context) error) error {
 	a := app.NewWithID("go-snip")
 
 	errCh := make(chan error, 1)
@@ -45,9 +43,9 @@ func runEntry(ctx context.Context, outDir string, cfgPath string, cfg config.Con
 			})
 
 			go func() {
-				// Hotkeys run in the background; UI runs on the main thread via a.Run().
-				errCh <- runHotkeys(ctx, outDir, cfgPath, cfg, now, out)
-				// When the hotkey loop stops (Ctrl+C), exit the UI loop too.
+				// fn (e.g. the hotkey loop) runs in the background; UI runs on the main thread via a.Run().
+				errCh <- fn(ctx)
+				// When fn stops (Ctrl+C for the hotkey loop), exit the UI loop too.
 				a.Quit()
 			}()
 		})
@@ -2,14 +2,9 @@
 
 package main
 
-import (
-	"context"
-	"io"
-	"time"
+import "context"
 
-	"go-snip/internal/config"
-)
-
-func runEntry(ctx context.Context, outDir string, cfgPath string, cfg config.Config, now func() time.Time, out io.Writer) error {
-	return runHotkeys(ctx, outDir, cfgPath, cfg, now, out)
+// runEntry runs fn directly; without the `fyne` build tag there is no UI loop to host it.
+func runEntry(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
 }
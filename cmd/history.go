@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-snip/internal/config"
+	"go-snip/internal/history"
+	"go-snip/internal/retention"
+)
+
+// captureEntry returns the history entry for a capture of size saved to path at t.
+func captureEntry(path string, t time.Time, name string, size image.Point, opts captureOptions) history.Entry {
+	tags := append([]string(nil), opts.tags...)
+	if ext := strings.TrimPrefix(filepath.Ext(path), "."); ext != "" {
+		tags = append(tags, strings.ToLower(ext))
+	}
+	return history.Entry{
+		Path:    path,
+		Time:    t,
+		Mode:    opts.mode,
+		Display: opts.display,
+		Rect:    rectArray(opts.rect),
+		Width:   size.X,
+		Height:  size.Y,
+		Name:    name,
+		Tags:    tags,
+	}
+}
+
+func rectArray(r image.Rectangle) [4]int {
+	return [4]int{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y}
+}
+
+// historyRecorder returns an afterSave hook that appends each save to the index at indexPath
+// with its absolute path and, for files, content hash. Failures are logged: the capture itself
+// is already safe. An empty indexPath records nothing.
+func historyRecorder(indexPath string) func(history.Entry) {
+	if indexPath == "" {
+		return nil
+	}
+	return func(e history.Entry) {
+		if abs, err := filepath.Abs(e.Path); err == nil {
+			e.Path = abs
+		}
+		if info, err := os.Stat(e.Path); err == nil && info.Mode().IsRegular() {
+			if e.SHA256, err = history.HashFile(e.Path); err != nil {
+				log.Printf("history: hash %q: %v", e.Path, err)
+			}
+		}
+		if err := history.Append(indexPath, e); err != nil {
+			log.Printf("history: %v", err)
+		}
+	}
+}
+
+// loadHistory returns the entries of the index at indexPath, oldest first. A missing index is
+// rebuilt from the go-snip files in outDir; entries of files deleted outside go-snip are
+// dropped. Either way the index is rewritten to match, on top of what it holds by then, so
+// captures saved in the meantime (e.g. by the daemon) are kept.
+func loadHistory(indexPath, outDir string, match retention.Matcher) ([]history.Entry, error) {
+	entries, skipped, err := history.Load(indexPath)
+	missing := errors.Is(err, os.ErrNotExist)
+	var rebuilt []history.Entry
+	switch {
+	case missing:
+		rebuilt, err = rebuildHistory(outDir, match)
+		if err != nil {
+			return nil, fmt.Errorf("rebuild history from %q: %w", outDir, err)
+		}
+		log.Printf("history index %s missing: rebuilt %d entries from %s", indexPath, len(rebuilt), outDir)
+		entries = rebuilt
+	case err != nil:
+		return nil, err
+	}
+
+	exists := func(p string) bool {
+		_, err := os.Stat(p)
+		return err == nil
+	}
+	entries, removed := history.Prune(entries, exists)
+	if !missing && skipped == 0 && removed == 0 {
+		return entries, nil
+	}
+	err = history.Update(indexPath, func(current []history.Entry) []history.Entry {
+		saved := make(map[string]bool, len(current))
+		for _, e := range current {
+			saved[e.Path] = true
+		}
+		var merged []history.Entry
+		for _, e := range rebuilt {
+			if !saved[e.Path] {
+				merged = append(merged, e)
+			}
+		}
+		entries, _ = history.Prune(append(merged, current...), exists)
+		return entries
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+var (
+	// timestampName is the start of default file names, YYYYMMDD_HHMMSS_mmm, followed by an
+	// optional " - name" and " - 001" collision suffix.
+	timestampName = regexp.MustCompile(`^(\d{8}_\d{6})_(\d{3})(?:_\d{3,})?(?: - (.*?))?(?: - \d{3,})?$`)
+	// timelapseFramePath is a frame in a timelapse session folder.
+	timelapseFramePath = regexp.MustCompile(`(?:^|/)(timelapse_(\d{8}_\d{6})(?:_\d{3,})?)/frame-\d+\.[^/]+$`)
+)
+
+// rebuildHistory returns entries, oldest first, for the go-snip files in outDir. What only the
+// original save knew (mode, display, rect) is left unknown; times, names and sizes come from
+// the file names and contents. Timelapse sessions get one entry per folder.
+func rebuildHistory(outDir string, match retention.Matcher) ([]history.Entry, error) {
+	files, err := retention.Scan(outDir, match)
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []history.Entry
+	sessions := make(map[string]bool)
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i]
+		if m := timelapseFramePath.FindStringSubmatch(f.Rel); m != nil {
+			dir := filepath.Join(root, filepath.FromSlash(path.Dir(f.Rel)))
+			if sessions[dir] {
+				continue
+			}
+			sessions[dir] = true
+			t, err := time.ParseInLocation("20060102_150405", m[2], time.Local)
+			if err != nil {
+				t = f.ModTime
+			}
+			entries = append(entries, history.Entry{Path: dir, Time: t, Mode: "timelapse", Display: -1, Tags: []string{"timelapse"}})
+			continue
+		}
+
+		e := history.Entry{Path: filepath.Join(root, filepath.FromSlash(f.Rel)), Time: f.ModTime, Display: -1}
+		ext := path.Ext(f.Rel)
+		if m := timestampName.FindStringSubmatch(strings.TrimSuffix(path.Base(f.Rel), ext)); m != nil {
+			if t, err := time.ParseInLocation("20060102_150405", m[1], time.Local); err == nil {
+				ms, _ := strconv.Atoi(m[2])
+				e.Time = t.Add(time.Duration(ms) * time.Millisecond)
+			}
+			e.Name = m[3]
+		}
+		if fh, err := os.Open(e.Path); err == nil {
+			if cfg, _, err := image.DecodeConfig(fh); err == nil {
+				e.Width, e.Height = cfg.Width, cfg.Height
+			}
+			fh.Close()
+		}
+		if e.SHA256, err = history.HashFile(e.Path); err != nil {
+			return nil, err
+		}
+		e.Tags = []string{strings.ToLower(strings.TrimPrefix(ext, "."))}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+func runHistoryCommand(env captureEnv, args []string, outDir string, cfg config.Config, out io.Writer) error {
+	if len(args) == 0 {
+		return usageError("history: missing subcommand (list, search, show or open)")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("history "+sub, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	limit := fs.Int("limit", 20, "Most entries to print, 0 for all (list, search)")
+	mode := fs.String("mode", "", "Only captures of this mode (list, search)")
+	since := fs.String("since", "", "Only captures from this day (YYYY-MM-DD) on (list, search)")
+	until := fs.String("until", "", "Only captures before the end of this day (YYYY-MM-DD) (list, search)")
+	words, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return usageError(fmt.Sprintf("history %s: %v", sub, err))
+	}
+
+	var filter history.Filter
+	switch sub {
+	case "list", "search":
+		if sub == "list" && len(words) > 0 {
+			return usageError(fmt.Sprintf("history list: unexpected argument %q", words[0]))
+		}
+		if sub == "search" && len(words) == 0 {
+			return usageError("history search: missing query")
+		}
+		filter = history.Filter{Query: strings.Join(words, " "), Mode: *mode}
+		if filter.Since, err = parseDay(*since, 0); err != nil {
+			return usageError(fmt.Sprintf("history %s: --since: %v", sub, err))
+		}
+		if filter.Until, err = parseDay(*until, 1); err != nil {
+			return usageError(fmt.Sprintf("history %s: --until: %v", sub, err))
+		}
+	case "show", "open":
+		if len(words) != 1 {
+			return usageError(fmt.Sprintf("history %s: want one entry number (from history list) or path", sub))
+		}
+	default:
+		return usageError(fmt.Sprintf("history: unknown subcommand %q", sub))
+	}
+
+	if env.historyPath == "" {
+		return errors.New("history: index path unavailable")
+	}
+	entries, err := loadHistory(env.historyPath, outDir, retentionOptionsFor(cfg).match)
+	if err != nil {
+		return err
+	}
+	newest := history.Newest(entries)
+
+	if sub == "list" || sub == "search" {
+		printed := 0
+		for i, e := range newest {
+			if !filter.Match(e) {
+				continue
+			}
+			if *limit > 0 && printed == *limit {
+				break
+			}
+			printed++
+			if _, err := fmt.Fprintf(out, "%d\t%s\t%s\t%dx%d\t%s\t%s\n", i+1, e.Time.Local().Format("2006-01-02 15:04:05"), orDash(e.Mode), e.Width, e.Height, orDash(e.Name), e.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	e, err := historyEntry(newest, words[0])
+	if err != nil {
+		return err
+	}
+	if sub == "open" {
+		if env.open == nil {
+			return errors.New("history open: no opener")
+		}
+		return env.open(e.Path)
+	}
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", b)
+	return err
+}
+
+// historyEntry finds ref, an entry number as printed by history list (1 is the newest) or a
+// path, in newest.
+func historyEntry(newest []history.Entry, ref string) (history.Entry, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(newest) {
+			return history.Entry{}, fmt.Errorf("history: no entry %d (have %d)", n, len(newest))
+		}
+		return newest[n-1], nil
+	}
+	abs, err := filepath.Abs(ref)
+	if err != nil {
+		return history.Entry{}, err
+	}
+	for _, e := range newest {
+		if e.Path == abs || e.Path == ref {
+			return e, nil
+		}
+	}
+	return history.Entry{}, fmt.Errorf("history: %q is not in the history", ref)
+}
+
+// parseInterspersed parses fs from args, allowing flags after positional arguments, and
+// returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// parseDay parses a YYYY-MM-DD day in local time, plus addDays; empty is the zero time.
+func parseDay(s string, addDays int) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want YYYY-MM-DD, got %q", s)
+	}
+	return t.AddDate(0, 0, addDays), nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go-snip/internal/clipboard"
+	"go-snip/internal/config"
+	"go-snip/internal/history"
+	"go-snip/internal/retention"
+	"go-snip/internal/thumbs"
+	"go-snip/internal/ui"
+	"go-snip/internal/utils"
+)
+
+// thumbnailWorkers is the number of goroutines generating thumbnails for the history browser.
+const thumbnailWorkers = 4
+
+// openHistoryBrowser runs handleHistoryBrowser as a background job, so the hotkeys keep working
+// while the window is open, and returns the job. If browser is still running its window is
+// already open and it is returned instead of opening another one.
+func openHistoryBrowser(ctx context.Context, browser *backgroundJob, env captureEnv, outDir string, cfg config.Config) *backgroundJob {
+	if browser.running() {
+		log.Printf("history browser is already open")
+		return browser
+	}
+	return startJob(ctx, func(context.Context) {
+		err := handleHistoryBrowser(env, outDir, cfg)
+		switch {
+		case errors.Is(err, ui.ErrHistoryUnavailable):
+			log.Printf("history browser unavailable (build with -tags=fyne): %v", err)
+		case err != nil:
+			log.Printf("history browser failed: %v", err)
+		}
+	})
+}
+
+// handleHistoryBrowser opens the history browser on the index at env.historyPath and blocks
+// until it is closed. Thumbnails are generated on a worker pool into env.thumbsDir.
+func handleHistoryBrowser(env captureEnv, outDir string, cfg config.Config) error {
+	if env.historyPath == "" {
+		return errors.New("history: index path unavailable")
+	}
+	if env.showHistory == nil {
+		return ui.ErrHistoryUnavailable
+	}
+	entries, err := loadHistory(env.historyPath, outDir, retentionOptionsFor(cfg).match)
+	if err != nil {
+		return err
+	}
+
+	actions := historyActions(env, outDir)
+	if env.thumbsDir != "" {
+		pool := thumbs.NewPool(thumbs.Cache{Dir: env.thumbsDir}, thumbnailWorkers)
+		defer pool.Close()
+		actions.Thumbnail = pool.Request
+		actions.ResetThumbnails = pool.Reset
+	}
+	return env.showHistory(history.Newest(entries), actions)
+}
+
+// historyActions returns the browser's per-entry actions; they keep the index at env.historyPath
+// in step with the files they rename or delete.
+func historyActions(env captureEnv, outDir string) ui.HistoryActions {
+	return ui.HistoryActions{
+		CopyImage: func(e history.Entry) error {
+			img, err := decodeEntry(e)
+			if err != nil {
+				return err
+			}
+			if env.clipboard == nil {
+				return clipboard.ErrUnavailable
+			}
+			return clipboard.CopyImage(env.clipboard, img)
+		},
+		CopyPath: func(e history.Entry) error {
+			if env.clipboard == nil {
+				return clipboard.ErrUnavailable
+			}
+			return env.clipboard.WriteText(e.Path)
+		},
+		Rename: func(e history.Entry, name string) (history.Entry, error) {
+			return renameEntry(env.historyPath, e, name)
+		},
+		Delete: func(e history.Entry) error {
+			return deleteEntry(env.historyPath, outDir, e)
+		},
+		OpenFolder: func(e history.Entry) error {
+			if env.open == nil {
+				return errors.New("history: no opener")
+			}
+			return env.open(filepath.Dir(e.Path))
+		},
+	}
+}
+
+// decodeEntry reads the image of e (the first frame of a recording).
+func decodeEntry(e history.Entry) (image.Image, error) {
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("%s is a folder", e.Path)
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q: %w", e.Path, err)
+	}
+	return img, nil
+}
+
+// renameEntry moves the file of e to a timestamped name with name appended, in the same folder
+// and with the same extension, and updates the index. Folders (timelapse sessions) keep their
+// names, which retention and the history rebuild rely on.
+func renameEntry(indexPath string, e history.Entry, name string) (history.Entry, error) {
+	info, err := os.Stat(e.Path)
+	if err != nil {
+		return history.Entry{}, err
+	}
+	if info.IsDir() {
+		return history.Entry{}, fmt.Errorf("%s is a folder and can't be renamed", e.Path)
+	}
+
+	exists := func(p string) bool {
+		_, statErr := os.Stat(p)
+		return statErr == nil && p != e.Path
+	}
+	base := utils.BaseNameForTimeAndName(e.Time.Local(), name)
+	dest := utils.UniquePathWithBaseExt(filepath.Dir(e.Path), base, filepath.Ext(e.Path), exists)
+	if dest != e.Path {
+		if err := os.Rename(e.Path, dest); err != nil {
+			return history.Entry{}, err
+		}
+	}
+
+	renamed := e
+	renamed.Path = dest
+	renamed.Name = strings.TrimSpace(name)
+	if indexPath != "" {
+		if err := history.Replace(indexPath, e.Path, &renamed); err != nil {
+			return renamed, fmt.Errorf("renamed to %s, but updating the history failed: %w", dest, err)
+		}
+	}
+	return renamed, nil
+}
+
+// deleteEntry removes the file of e, or the frames and folder of a timelapse session in outDir,
+// and its index entry.
+func deleteEntry(indexPath, outDir string, e history.Entry) error {
+	info, err := os.Stat(e.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// Already gone; just drop the entry.
+	case err != nil:
+		return err
+	case info.IsDir():
+		if err := deleteSession(outDir, e.Path); err != nil {
+			return err
+		}
+	default:
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+	}
+	if indexPath == "" {
+		return nil
+	}
+	return history.Replace(indexPath, e.Path, nil)
+}
+
+var (
+	// timelapseSessionName and timelapseFrameName are the folder and frame names of a timelapse
+	// session (see timelapse.SessionDir).
+	timelapseSessionName = regexp.MustCompile(`^timelapse_\d{8}_\d{6}(?:_\d{3,})?$`)
+	timelapseFrameName   = regexp.MustCompile(`^frame-\d{5,}\.[0-9A-Za-z]+$`)
+)
+
+// deleteSession deletes the frames of the timelapse session folder dir, then the folder and any
+// date folders it leaves empty. Nothing is deleted unless dir is a session folder below outDir
+// holding only frames, so a stale index entry can't take other files with it.
+func deleteSession(outDir, dir string) error {
+	root, err := filepath.Abs(outDir)
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if !retention.IsBelow(root, abs) || !timelapseSessionName.MatchString(filepath.Base(abs)) {
+		return fmt.Errorf("%s is not a timelapse session folder in %s", dir, outDir)
+	}
+
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return err
+	}
+	frames := make([]retention.File, 0, len(entries))
+	for _, de := range entries {
+		if !de.Type().IsRegular() || !timelapseFrameName.MatchString(de.Name()) {
+			return fmt.Errorf("%s holds %s, which go-snip didn't create; delete the folder by hand", dir, de.Name())
+		}
+		frames = append(frames, retention.File{Path: filepath.Join(abs, de.Name())})
+	}
+	if _, err := retention.Remove(root, frames); err != nil {
+		return err
+	}
+	// An empty session has no frames for Remove to clear the folder by.
+	if err := os.Remove(abs); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
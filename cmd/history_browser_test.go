@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/clipboard"
+	"go-snip/internal/config"
+	"go-snip/internal/history"
+	"go-snip/internal/ui"
+)
+
+func TestHandleHistoryBrowser_Actions(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30))
+	outDir := t.TempDir()
+	env := historyEnv(t, src, image.Rect(2, 3, 12, 8))
+	env.promptSave = func(img image.Image) (image.Image, string, bool, error) { return img, "draft", true, nil }
+	fake := clipboard.NewFake()
+	env.clipboard = fake
+	var opened []string
+	env.open = func(path string) error {
+		opened = append(opened, path)
+		return nil
+	}
+	env.thumbsDir = filepath.Join(t.TempDir(), "thumbs")
+
+	area, _, err := handleArea(env, outDir, captureOptions{postCapturePrompt: true})
+	if err != nil {
+		t.Fatalf("handleArea() error: %v", err)
+	}
+	lapse := filepath.Join(outDir, "timelapse_20250102_030405")
+	if err := os.MkdirAll(lapse, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(lapse, "frame-00001.png"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env.saved(history.Entry{Path: lapse, Time: env.now(), Mode: "timelapse"})
+
+	var renamed history.Entry
+	env.showHistory = func(entries []history.Entry, actions ui.HistoryActions) error {
+		if len(entries) != 2 {
+			t.Fatalf("browser got %d entries want=2", len(entries))
+		}
+		e := entries[1]
+		if e.Path != area {
+			t.Fatalf("browser entry path=%q want=%q", e.Path, area)
+		}
+
+		thumbs := make(chan error, 1)
+		actions.Thumbnail(e.Path, func(thumb string, err error) {
+			if err == nil {
+				_, err = os.Stat(thumb)
+			}
+			thumbs <- err
+		})
+		if err := <-thumbs; err != nil {
+			t.Fatalf("Thumbnail() error: %v", err)
+		}
+
+		if err := actions.CopyImage(e); err != nil {
+			t.Fatalf("CopyImage() error: %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(fake.Image()))
+		if err != nil || img.Bounds().Dx() != 10 || img.Bounds().Dy() != 5 {
+			t.Fatalf("clipboard image err=%v bounds=%v want 10x5", err, img.Bounds())
+		}
+		if err := actions.CopyPath(e); err != nil || fake.Text() != area {
+			t.Fatalf("CopyPath() err=%v clipboard=%q want=%q", err, fake.Text(), area)
+		}
+		if err := actions.OpenFolder(e); err != nil || len(opened) != 1 || opened[0] != filepath.Dir(area) {
+			t.Fatalf("OpenFolder() err=%v opened=%v want=[%s]", err, opened, filepath.Dir(area))
+		}
+
+		if renamed, err = actions.Rename(e, "login bug"); err != nil {
+			t.Fatalf("Rename() error: %v", err)
+		}
+		if _, err := actions.Rename(entries[0], "session"); err == nil {
+			t.Fatalf("Rename(folder) error=nil, want an error")
+		}
+		if err := actions.Delete(entries[0]); err != nil {
+			t.Fatalf("Delete(folder) error: %v", err)
+		}
+		return nil
+	}
+	if err := handleHistoryBrowser(env, outDir, config.Config{}); err != nil {
+		t.Fatalf("handleHistoryBrowser() error: %v", err)
+	}
+
+	if want := filepath.Join(outDir, "20250102_030405_000 - login bug.png"); renamed.Path != want || renamed.Name != "login bug" {
+		t.Fatalf("renamed entry path=%q name=%q want=%q, login bug", renamed.Path, renamed.Name, want)
+	}
+	if !exists(renamed.Path) || exists(area) || exists(lapse) {
+		t.Fatalf("after rename and delete: renamed=%v old=%v timelapse=%v want=true,false,false", exists(renamed.Path), exists(area), exists(lapse))
+	}
+	entries, _, err := history.Load(env.historyPath)
+	if err != nil {
+		t.Fatalf("history.Load() error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != renamed.Path || entries[0].Name != "login bug" {
+		t.Fatalf("history after browsing got=%+v want only %s", entries, renamed.Path)
+	}
+}
+
+func TestDeleteEntry_OnlyDeletesSessionFrames(t *testing.T) {
+	t.Parallel()
+
+	outDir := t.TempDir()
+	mkdir := func(dir string, files ...string) string {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			if err := os.WriteFile(filepath.Join(dir, f), []byte("x"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir
+	}
+
+	// Folders that aren't a session below outDir, or hold other files, are left alone.
+	for _, dir := range []string{
+		mkdir(filepath.Join(t.TempDir(), "timelapse_20250102_030405"), "frame-00001.png"),
+		mkdir(filepath.Join(outDir, "notes"), "frame-00001.png"),
+		mkdir(filepath.Join(outDir, "timelapse_20250102_030406"), "frame-00001.png", "notes.txt"),
+		mkdir(filepath.Join(outDir, "timelapse_20250102_030407", "keep"), "frame-00001.png"),
+	} {
+		if err := deleteEntry("", outDir, history.Entry{Path: dir}); err == nil {
+			t.Fatalf("deleteEntry(%s) error=nil, want a refusal", dir)
+		}
+		if !exists(filepath.Join(dir, "frame-00001.png")) {
+			t.Fatalf("deleteEntry(%s) deleted a frame", dir)
+		}
+	}
+
+	// A session in a date folder goes with the date folders it leaves empty.
+	session := mkdir(filepath.Join(outDir, "2025", "01", "02", "timelapse_20250102_030408"), "frame-00001.png", "frame-00002.png")
+	if err := deleteEntry("", outDir, history.Entry{Path: session}); err != nil {
+		t.Fatalf("deleteEntry(session) error: %v", err)
+	}
+	if exists(filepath.Join(outDir, "2025")) {
+		t.Fatalf("deleteEntry(session) left %s", filepath.Join(outDir, "2025"))
+	}
+
+	// So does a second session started in the same second.
+	session = mkdir(filepath.Join(outDir, "timelapse_20250102_030409_001"), "frame-00001.png")
+	if err := deleteEntry("", outDir, history.Entry{Path: session}); err != nil || exists(session) {
+		t.Fatalf("deleteEntry(%s) error=%v, left=%v", session, err, exists(session))
+	}
+}
+
+func TestHandleHistoryBrowser_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	env := historyEnv(t, capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	if err := handleHistoryBrowser(env, t.TempDir(), config.Config{}); !errors.Is(err, ui.ErrHistoryUnavailable) {
+		t.Fatalf("handleHistoryBrowser() error=%v want=%v", err, ui.ErrHistoryUnavailable)
+	}
+}
+
+func TestOpenHistoryBrowser_DoesNotBlockAndOpensOnce(t *testing.T) {
+	t.Parallel()
+
+	env := historyEnv(t, capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	opened := make(chan struct{}, 2)
+	closeWindow := make(chan struct{})
+	env.showHistory = func([]history.Entry, ui.HistoryActions) error {
+		opened <- struct{}{}
+		<-closeWindow
+		return nil
+	}
+
+	// Returning while the window is open is what keeps the hotkey loop going.
+	browser := openHistoryBrowser(t.Context(), nil, env, t.TempDir(), config.Config{})
+	<-opened
+	if again := openHistoryBrowser(t.Context(), browser, env, t.TempDir(), config.Config{}); again != browser {
+		t.Fatalf("openHistoryBrowser() while open started another browser")
+	}
+	close(closeWindow)
+	browser.stop()
+	if len(opened) != 0 {
+		t.Fatalf("history window opened %d more times, want once", len(opened))
+	}
+
+	next := openHistoryBrowser(t.Context(), browser, env, t.TempDir(), config.Config{})
+	<-opened
+	next.stop()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/history"
+)
+
+// historyEnv returns fakeEnv recording saves in a history index inside a temp dir.
+func historyEnv(t *testing.T, src capture.Source, selection image.Rectangle) captureEnv {
+	t.Helper()
+	env := fakeEnv(src, selection)
+	env.historyPath = filepath.Join(t.TempDir(), "history.jsonl")
+	env.afterSave = historyRecorder(env.historyPath)
+	return env
+}
+
+func runHistory(t *testing.T, env captureEnv, outDir string, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := runCommand(t.Context(), env, append([]string{"history"}, args...), outDir, config.Config{}, &out); err != nil {
+		t.Fatalf("history %v error: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestHistory_RecordsSavesAndSearches(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	outDir := t.TempDir()
+	env := historyEnv(t, src, image.Rect(45, 5, 57, 13))
+	names := []string{"login bug", ""}
+	env.promptSave = func(img image.Image) (image.Image, string, bool, error) {
+		name := names[0]
+		names = names[1:]
+		return img, name, true, nil
+	}
+
+	area, _, err := handleArea(env, outDir, captureOptions{postCapturePrompt: true})
+	if err != nil {
+		t.Fatalf("handleArea() error: %v", err)
+	}
+	env.now = func() time.Time { return time.Date(2025, 1, 3, 0, 0, 0, 0, time.Local) }
+	full, _, err := handleFull(env, outDir, capture.DisplayPolicy{Mode: capture.DisplayAll}, captureOptions{postCapturePrompt: true})
+	if err != nil {
+		t.Fatalf("handleFull() error: %v", err)
+	}
+
+	got := runHistory(t, env, outDir, "list")
+	want := "1\t2025-01-03 00:00:00\tfull\t80x30\t-\t" + full + "\n" +
+		"2\t2025-01-02 03:04:05\tarea\t12x8\tlogin bug\t" + area + "\n"
+	if got != want {
+		t.Fatalf("history list got=%q want=%q", got, want)
+	}
+	if got := runHistory(t, env, outDir, "search", "LOGIN", "--mode", "area"); !strings.HasPrefix(got, "2\t") || strings.Count(got, "\n") != 1 {
+		t.Fatalf("history search got=%q", got)
+	}
+	if got := runHistory(t, env, outDir, "list", "--since", "2025-01-03"); !strings.HasPrefix(got, "1\t") || strings.Count(got, "\n") != 1 {
+		t.Fatalf("history list --since got=%q", got)
+	}
+	if got := runHistory(t, env, outDir, "list", "--limit", "1", "--until", "2025-01-02"); !strings.HasPrefix(got, "2\t") || strings.Count(got, "\n") != 1 {
+		t.Fatalf("history list --until got=%q", got)
+	}
+
+	var e history.Entry
+	if err := json.Unmarshal([]byte(runHistory(t, env, outDir, "show", "2")), &e); err != nil {
+		t.Fatalf("history show output: %v", err)
+	}
+	hash, err := history.HashFile(area)
+	if err != nil {
+		t.Fatalf("HashFile() error: %v", err)
+	}
+	wantEntry := history.Entry{Path: area, Time: e.Time, Mode: "area", Display: 1, Rect: [4]int{45, 5, 57, 13}, Width: 12, Height: 8, Name: "login bug", SHA256: hash, Tags: []string{"png"}}
+	if !reflect.DeepEqual(e, wantEntry) || !e.Time.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)) {
+		t.Fatalf("history show got=%+v want=%+v", e, wantEntry)
+	}
+
+	var opened []string
+	env.open = func(path string) error {
+		opened = append(opened, path)
+		return nil
+	}
+	runHistory(t, env, outDir, "open", "1")
+	runHistory(t, env, outDir, "open", area)
+	if want := []string{full, area}; !reflect.DeepEqual(opened, want) {
+		t.Fatalf("opened got=%v want=%v", opened, want)
+	}
+}
+
+func TestHistory_ForgetsExternallyDeletedFiles(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 20, 10))
+	outDir := t.TempDir()
+	env := historyEnv(t, src, image.Rectangle{})
+	var paths []string
+	for i := 0; i < 2; i++ {
+		path, _, err := handleFull(env, outDir, capture.DisplayPolicy{}, captureOptions{})
+		if err != nil {
+			t.Fatalf("handleFull() error: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	if err := os.Remove(paths[0]); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if got := runHistory(t, env, outDir, "list"); strings.Contains(got, paths[0]) || !strings.Contains(got, paths[1]) {
+		t.Fatalf("history list after delete got=%q", got)
+	}
+	entries, _, err := history.Load(env.historyPath)
+	if err != nil || len(entries) != 1 || entries[0].Path != paths[1] {
+		t.Fatalf("index after delete got=%+v err=%v", entries, err)
+	}
+
+	var out bytes.Buffer
+	if err := runCommand(t.Context(), env, []string{"history", "show", "2"}, outDir, config.Config{}, &out); err == nil {
+		t.Fatalf("history show of a forgotten entry succeeded")
+	}
+}
+
+func TestHistory_RebuildsMissingIndex(t *testing.T) {
+	t.Parallel()
+
+	outDir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 6, 4))
+	img.Set(0, 0, color.White)
+	writePNG := func(rel string) string {
+		path := filepath.Join(outDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		return path
+	}
+	named := writePNG("2025/01/02/20250102_030405_250 - login bug - 001.png")
+	plain := writePNG("20250101_000000_000_001.png")
+	writePNG("timelapse_20250103_101010/frame-00001.png")
+	writePNG("timelapse_20250103_101010/frame-00002.png")
+	writePNG("holiday.png")
+
+	env := fakeEnv(nil, image.Rectangle{})
+	env.historyPath = filepath.Join(t.TempDir(), "history.jsonl")
+	got := runHistory(t, env, outDir, "list")
+	want := "1\t2025-01-03 10:10:10\ttimelapse\t0x0\t-\t" + filepath.Join(outDir, "timelapse_20250103_101010") + "\n" +
+		"2\t2025-01-02 03:04:05\t-\t6x4\tlogin bug\t" + named + "\n" +
+		"3\t2025-01-01 00:00:00\t-\t6x4\t-\t" + plain + "\n"
+	if got != want {
+		t.Fatalf("history list got=%q want=%q", got, want)
+	}
+	entries, _, err := history.Load(env.historyPath)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("rebuilt index got=%d entries err=%v", len(entries), err)
+	}
+	if e := entries[1]; e.SHA256 == "" || !e.Time.Equal(time.Date(2025, 1, 2, 3, 4, 5, 250*int(time.Millisecond), time.Local)) {
+		t.Fatalf("rebuilt entry got=%+v", e)
+	}
+}
+
+func TestCaptureEntry_Tags(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30))
+	env := historyEnv(t, src, image.Rectangle{})
+	env.promptSave = func(img image.Image) (image.Image, string, bool, error) {
+		return image.NewRGBA(img.Bounds()), "", true, nil
+	}
+	regions := []config.NamedRegion{{Name: "panel", Rect: [4]int{0, 0, 10, 10}}}
+	if _, _, err := handleNamedRegion(env, "panel", regions, t.TempDir(), captureOptions{postCapturePrompt: true, save: saveOptionsFor(config.Config{Format: "jpeg"})}); err != nil {
+		t.Fatalf("handleNamedRegion() error: %v", err)
+	}
+	entries, _, err := history.Load(env.historyPath)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("index got=%+v err=%v", entries, err)
+	}
+	if want := []string{"panel", "annotated", "jpg"}; !reflect.DeepEqual(entries[0].Tags, want) || entries[0].Mode != "region" {
+		t.Fatalf("entry got=%+v want tags=%v", entries[0], want)
+	}
+}
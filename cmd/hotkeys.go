@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"golang.design/x/hotkey"
+
+	"go-snip/internal/hotkeys"
+)
+
+var hotkeyKeys = map[string]hotkey.Key{
+	"0": hotkey.Key0, "1": hotkey.Key1, "2": hotkey.Key2, "3": hotkey.Key3, "4": hotkey.Key4,
+	"5": hotkey.Key5, "6": hotkey.Key6, "7": hotkey.Key7, "8": hotkey.Key8, "9": hotkey.Key9,
+
+	"A": hotkey.KeyA, "B": hotkey.KeyB, "C": hotkey.KeyC, "D": hotkey.KeyD, "E": hotkey.KeyE,
+	"F": hotkey.KeyF, "G": hotkey.KeyG, "H": hotkey.KeyH, "I": hotkey.KeyI, "J": hotkey.KeyJ,
+	"K": hotkey.KeyK, "L": hotkey.KeyL, "M": hotkey.KeyM, "N": hotkey.KeyN, "O": hotkey.KeyO,
+	"P": hotkey.KeyP, "Q": hotkey.KeyQ, "R": hotkey.KeyR, "S": hotkey.KeyS, "T": hotkey.KeyT,
+	"U": hotkey.KeyU, "V": hotkey.KeyV, "W": hotkey.KeyW, "X": hotkey.KeyX, "Y": hotkey.KeyY,
+	"Z": hotkey.KeyZ,
+
+	"F1": hotkey.KeyF1, "F2": hotkey.KeyF2, "F3": hotkey.KeyF3, "F4": hotkey.KeyF4,
+	"F5": hotkey.KeyF5, "F6": hotkey.KeyF6, "F7": hotkey.KeyF7, "F8": hotkey.KeyF8,
+	"F9": hotkey.KeyF9, "F10": hotkey.KeyF10, "F11": hotkey.KeyF11, "F12": hotkey.KeyF12,
+	"F13": hotkey.KeyF13, "F14": hotkey.KeyF14, "F15": hotkey.KeyF15, "F16": hotkey.KeyF16,
+	"F17": hotkey.KeyF17, "F18": hotkey.KeyF18, "F19": hotkey.KeyF19, "F20": hotkey.KeyF20,
+
+	"Space":  hotkey.KeySpace,
+	"Return": hotkey.KeyReturn,
+	"Escape": hotkey.KeyEscape,
+	"Delete": hotkey.KeyDelete,
+	"Tab":    hotkey.KeyTab,
+	"Left":   hotkey.KeyLeft,
+	"Right":  hotkey.KeyRight,
+	"Up":     hotkey.KeyUp,
+	"Down":   hotkey.KeyDown,
+}
+
+// toHotkey converts a parsed chord into golang.design/x/hotkey values for the current platform.
+func toHotkey(c hotkeys.Chord) ([]hotkey.Modifier, hotkey.Key, error) {
+	key, ok := hotkeyKeys[c.Key]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w %q", hotkeys.ErrUnknownKey, c.Key)
+	}
+	mods := make([]hotkey.Modifier, 0, len(c.Mods))
+	for _, m := range c.Mods {
+		mods = append(mods, platformModifier(m))
+	}
+	return mods, key, nil
+}
+
+// hotkeySet is a group of registered hotkeys whose keydown events are fanned into one channel
+// as action names.
+type hotkeySet struct {
+	// chords are the bindings that registered; skipped ones are left out.
+	chords map[string]hotkeys.Chord
+	keys   []*hotkey.Hotkey
+	stop   chan struct{}
+}
+
+// registerHotkeys registers every chord and forwards keydown events to events.
+// A chord that can't be registered (typically because another application holds it) is logged
+// and skipped, so one taken chord doesn't cost the other actions their hotkeys.
+func registerHotkeys(chords map[string]hotkeys.Chord, events chan<- string) *hotkeySet {
+	s := &hotkeySet{chords: make(map[string]hotkeys.Chord, len(chords)), stop: make(chan struct{})}
+
+	actions := make([]string, 0, len(chords))
+	for action := range chords {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	for _, action := range actions {
+		c := chords[action]
+		mods, key, err := toHotkey(c)
+		if err != nil {
+			log.Printf("%s hotkey %s (skipped): %v", action, c, err)
+			continue
+		}
+		hk := hotkey.New(mods, key)
+		if err := hk.Register(); err != nil {
+			log.Printf("register %s hotkey %s (skipped): %v", action, c, err)
+			continue
+		}
+		s.keys = append(s.keys, hk)
+		s.chords[action] = c
+
+		// Capture the channel now: Unregister swaps in a fresh one and closes this one,
+		// which ends the forwarder.
+		go forwardKeydown(hk.Keydown(), action, events, s.stop)
+	}
+	return s
+}
+
+func forwardKeydown(keydown <-chan hotkey.Event, action string, events chan<- string, stop <-chan struct{}) {
+	for range keydown {
+		select {
+		case events <- action:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *hotkeySet) unregister() {
+	if s == nil {
+		return
+	}
+	close(s.stop)
+	for _, hk := range s.keys {
+		_ = hk.Unregister()
+	}
+	s.keys = nil
+}
+
+// sameChords reports whether s already has exactly the given bindings registered. A chord that
+// was skipped makes it false, so the next rebind retries it.
+func (s *hotkeySet) sameChords(chords map[string]hotkeys.Chord) bool {
+	return s != nil && reflect.DeepEqual(s.chords, chords)
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"golang.design/x/hotkey"
+
+	"go-snip/internal/hotkeys"
+)
+
+// platformModifier maps a neutral modifier onto macOS modifiers (Alt is Option, Super is Cmd).
+func platformModifier(m hotkeys.Modifier) hotkey.Modifier {
+	switch m {
+	case hotkeys.ModAlt:
+		return hotkey.ModOption
+	case hotkeys.ModShift:
+		return hotkey.ModShift
+	case hotkeys.ModSuper:
+		return hotkey.ModCmd
+	}
+	return hotkey.ModCtrl
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"golang.design/x/hotkey"
+
+	"go-snip/internal/hotkeys"
+)
+
+// platformModifier maps a neutral modifier onto X11 modifier masks
+// (Mod1 is Alt and Mod4 is Super on common layouts).
+func platformModifier(m hotkeys.Modifier) hotkey.Modifier {
+	switch m {
+	case hotkeys.ModAlt:
+		return hotkey.Mod1
+	case hotkeys.ModShift:
+		return hotkey.ModShift
+	case hotkeys.ModSuper:
+		return hotkey.Mod4
+	}
+	return hotkey.ModCtrl
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"go-snip/internal/hotkeys"
+)
+
+func TestHotkeyKeys_CoverAllKeyNames(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range hotkeys.KeyNames() {
+		if _, ok := hotkeyKeys[name]; !ok {
+			t.Fatalf("hotkeyKeys missing %q", name)
+		}
+	}
+	if len(hotkeyKeys) != len(hotkeys.KeyNames()) {
+		t.Fatalf("hotkeyKeys has %d entries, want %d", len(hotkeyKeys), len(hotkeys.KeyNames()))
+	}
+}
+
+func TestToHotkey_MapsModifiers(t *testing.T) {
+	t.Parallel()
+
+	c, err := hotkeys.Parse("Ctrl+Shift+S")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	mods, _, err := toHotkey(c)
+	if err != nil {
+		t.Fatalf("toHotkey() error: %v", err)
+	}
+	if len(mods) != 2 || mods[0] != platformModifier(hotkeys.ModCtrl) || mods[1] != platformModifier(hotkeys.ModShift) {
+		t.Fatalf("toHotkey() mods=%v", mods)
+	}
+}
+
+func TestRegisterHotkeys_SkippedChordIsRetried(t *testing.T) {
+	t.Parallel()
+
+	chords := map[string]hotkeys.Chord{hotkeys.ActionFullscreen: {Key: "Bogus"}}
+	s := registerHotkeys(chords, make(chan string))
+	defer s.unregister()
+
+	if len(s.chords) != 0 {
+		t.Fatalf("registered chords=%v, want none", s.chords)
+	}
+	if s.sameChords(chords) {
+		t.Fatalf("sameChords() = true with a skipped chord, want a rebind to retry it")
+	}
+	if !s.sameChords(map[string]hotkeys.Chord{}) {
+		t.Fatalf("sameChords() = false for the registered bindings")
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"golang.design/x/hotkey"
+
+	"go-snip/internal/hotkeys"
+)
+
+// platformModifier maps a neutral modifier onto Windows modifiers.
+func platformModifier(m hotkeys.Modifier) hotkey.Modifier {
+	switch m {
+	case hotkeys.ModAlt:
+		return hotkey.ModAlt
+	case hotkeys.ModShift:
+		return hotkey.ModShift
+	case hotkeys.ModSuper:
+		return hotkey.ModWin
+	}
+	return hotkey.ModCtrl
+}
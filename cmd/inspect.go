@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/utils"
+)
+
+// appVersion returns version, else the main module version from the build info (set by
+// go install), else "dev".
+func appVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// captureMetadata returns the PNG metadata of a capture saved at t, or nothing with
+// opts.stripMetadata. The display bounds come from src (the union of all displays for -1).
+func captureMetadata(src capture.Source, t time.Time, name string, opts captureOptions) utils.CaptureMetadata {
+	if opts.stripMetadata {
+		return utils.CaptureMetadata{}
+	}
+	m := utils.CaptureMetadata{
+		Time:      t,
+		Software:  "go-snip " + appVersion(),
+		Selection: opts.rect,
+		Host:      hostname(),
+		Name:      strings.TrimSpace(name),
+		Notes:     strings.TrimSpace(opts.notes),
+	}
+	if src != nil {
+		m.Display = opts.display
+		if opts.display < 0 {
+			m.DisplayBounds = capture.UnionBounds(capture.DisplayBounds(src))
+		} else {
+			m.DisplayBounds = src.DisplayBounds(opts.display)
+		}
+	}
+	return m
+}
+
+// inspectOutput is the JSON printed by inspect. Rectangles are [x0, y0, x1, y1] in
+// virtual-desktop coordinates, as in the history.
+type inspectOutput struct {
+	Time          time.Time `json:"time,omitzero"`
+	Software      string    `json:"software,omitempty"`
+	Display       *int      `json:"display,omitempty"`
+	DisplayBounds [4]int    `json:"displayBounds,omitzero"`
+	Selection     [4]int    `json:"selection,omitzero"`
+	Host          string    `json:"host,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Notes         string    `json:"notes,omitempty"`
+	// Text holds the text chunks go-snip didn't write, by keyword.
+	Text map[string]string `json:"text,omitempty"`
+}
+
+// runInspectCommand prints the metadata embedded in a PNG capture as JSON.
+func runInspectCommand(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return usageError("inspect: want one PNG file")
+	}
+	m, err := utils.ReadPNGMetadataFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	o := inspectOutput{
+		Time:     m.Time,
+		Software: m.Software,
+		Host:     m.Host,
+		Name:     m.Name,
+		Notes:    m.Notes,
+		Text:     m.Other,
+	}
+	if !m.DisplayBounds.Empty() {
+		o.Display, o.DisplayBounds = &m.Display, rectArray(m.DisplayBounds)
+	}
+	if !m.Selection.Empty() {
+		o.Selection = rectArray(m.Selection)
+	}
+	b, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", b)
+	return err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/utils"
+)
+
+func TestInspect_PrintsCaptureMetadata(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	env := fakeEnv(src, image.Rectangle{})
+	outDir := t.TempDir()
+
+	var out bytes.Buffer
+	args := []string{"capture", "region", "--rect", "2,3,12,8", "--display", "1", "--note", "flaky after deploy"}
+	if err := runCommand(t.Context(), env, args, outDir, config.Config{}, &out); err != nil {
+		t.Fatalf("capture region error: %v", err)
+	}
+	path := strings.TrimSpace(out.String())
+
+	out.Reset()
+	if err := runCommand(t.Context(), env, []string{"inspect", path}, outDir, config.Config{}, &out); err != nil {
+		t.Fatalf("inspect error: %v", err)
+	}
+	var got inspectOutput
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("inspect output %q: %v", out.String(), err)
+	}
+	one := 1
+	want := inspectOutput{
+		Time:          env.now(),
+		Software:      "go-snip " + appVersion(),
+		Display:       &one,
+		DisplayBounds: [4]int{40, 0, 80, 30},
+		Selection:     [4]int{42, 3, 52, 8},
+		Host:          hostname(),
+		Notes:         "flaky after deploy",
+	}
+	if !got.Time.Equal(want.Time) || got.Display == nil || *got.Display != 1 {
+		t.Fatalf("inspect time=%v display=%v want=%v, 1", got.Time, got.Display, want.Time)
+	}
+	got.Time, got.Display = want.Time, want.Display
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("inspect got=%+v want=%+v", got, want)
+	}
+}
+
+func TestHandleArea_EmbedsNameUnlessStripped(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	env := fakeEnv(src, image.Rect(30, 5, 70, 10))
+	env.promptSave = func(img image.Image) (image.Image, string, bool, error) { return img, " login bug ", true, nil }
+	outDir := t.TempDir()
+
+	path, _, err := handleArea(env, outDir, captureOptions{postCapturePrompt: true})
+	if err != nil {
+		t.Fatalf("handleArea() error: %v", err)
+	}
+	m, err := utils.ReadPNGMetadataFile(path)
+	if err != nil {
+		t.Fatalf("ReadPNGMetadataFile() error: %v", err)
+	}
+	if m.Name != "login bug" || m.Selection != image.Rect(30, 5, 70, 10) || m.Display != 1 || m.DisplayBounds != image.Rect(40, 0, 80, 30) {
+		t.Fatalf("metadata name=%q selection=%v display=%d bounds=%v", m.Name, m.Selection, m.Display, m.DisplayBounds)
+	}
+
+	env.now = func() time.Time { return time.Date(2025, 1, 3, 0, 0, 0, 0, time.Local) }
+	path, _, err = handleFull(env, outDir, capture.DisplayPolicy{Mode: capture.DisplayAll}, captureOptions{stripMetadata: true})
+	if err != nil {
+		t.Fatalf("handleFull() error: %v", err)
+	}
+	if m, err := utils.ReadPNGMetadataFile(path); err != nil || !m.IsZero() {
+		t.Fatalf("stripped metadata got=%+v err=%v want none", m, err)
+	}
+}
+
+func TestInspect_Errors(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	notPNG := filepath.Join(t.TempDir(), "a.gif")
+	if err := os.WriteFile(notPNG, []byte("GIF89a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCommand(t.Context(), env, []string{"inspect", notPNG}, t.TempDir(), config.Config{}, &bytes.Buffer{}); !errors.Is(err, utils.ErrNotPNG) {
+		t.Fatalf("inspect(gif) error=%v want=%v", err, utils.ErrNotPNG)
+	}
+	if err := runCommand(t.Context(), env, []string{"inspect"}, t.TempDir(), config.Config{}, &bytes.Buffer{}); !errors.Is(err, errUsage) {
+		t.Fatalf("inspect without a file error=%v want=%v", err, errUsage)
+	}
+}
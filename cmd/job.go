@@ -0,0 +1,44 @@
+package main
+
+import "context"
+
+// backgroundJob is a long-running capture (timelapse, recording) started and stopped by a
+// hotkey while the daemon keeps handling other hotkeys.
+type backgroundJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startJob runs fn on its own goroutine with a context derived from ctx, cancelled by stop.
+func startJob(ctx context.Context, fn func(ctx context.Context)) *backgroundJob {
+	ctx, cancel := context.WithCancel(ctx)
+	j := &backgroundJob{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(j.done)
+		defer cancel()
+		fn(ctx)
+	}()
+	return j
+}
+
+// running reports whether the job is still going.
+func (j *backgroundJob) running() bool {
+	if j == nil {
+		return false
+	}
+	select {
+	case <-j.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// stop cancels the job and waits for it to finish its work, e.g. save the last frame.
+func (j *backgroundJob) stop() {
+	if j == nil {
+		return
+	}
+	j.cancel()
+	<-j.done
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+)
+
+var (
+	// errNoLastRegion is returned by a repeat capture before any area has been selected.
+	errNoLastRegion = errors.New("no area selected yet; select one with the area capture first")
+	// errRegionOffscreen is returned for a remembered or named region that doesn't fit the
+	// current display layout.
+	errRegionOffscreen = errors.New("region does not fit the displays")
+)
+
+// handleRepeatArea captures the last confirmed area selection again, without the overlay.
+func handleRepeatArea(env captureEnv, outDir string, opts captureOptions) (savedPath string, cancelled bool, err error) {
+	rect, err := lastRegionRect(env)
+	if err != nil {
+		return "", false, err
+	}
+	opts.mode = "last"
+	return finishSelection(env, rect, outDir, opts)
+}
+
+// rememberRegion stores rect (virtual-desktop coordinates) as the last area selection,
+// relative to the display holding most of it. Failures are logged: the capture itself matters more.
+func rememberRegion(env captureEnv, rect image.Rectangle) {
+	if env.statePath == "" {
+		return
+	}
+	displays := capture.DisplayBounds(env.src)
+	index := regionDisplay(rect, displays)
+	if index < 0 {
+		return
+	}
+
+	st, err := config.LoadState(env.statePath)
+	if err != nil {
+		log.Printf("failed to load state %q (overwriting): %v", env.statePath, err)
+	}
+	region := config.NewRegion(index, rect.Sub(displays[index].Min))
+	st.LastRegion = &region
+	if err := config.SaveState(env.statePath, st); err != nil {
+		log.Printf("failed to save last area to %q: %v", env.statePath, err)
+	}
+}
+
+// lastRegionRect returns the remembered area in virtual-desktop coordinates for the current
+// display layout (see regionRect).
+func lastRegionRect(env captureEnv) (image.Rectangle, error) {
+	if env.statePath == "" {
+		return image.Rectangle{}, errNoLastRegion
+	}
+	st, err := config.LoadState(env.statePath)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	if st.LastRegion == nil {
+		return image.Rectangle{}, errNoLastRegion
+	}
+
+	rect, err := regionRect(*st.LastRegion, capture.DisplayBounds(env.src))
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("last area: %w", err)
+	}
+	return rect, nil
+}
+
+// regionRect returns region in virtual-desktop coordinates for displays. It fails with
+// errRegionOffscreen rather than crop something else if the region's display is gone or the
+// region doesn't lie entirely on screen.
+func regionRect(region config.Region, displays []image.Rectangle) (image.Rectangle, error) {
+	if region.Display < 0 || region.Display >= len(displays) {
+		return image.Rectangle{}, fmt.Errorf("%w: display %d doesn't exist (%d displays)", errRegionOffscreen, region.Display, len(displays))
+	}
+	local := region.Rect()
+	b := displays[region.Display]
+	if local.Empty() {
+		return image.Rectangle{}, fmt.Errorf("%w: %v is empty", errRegionOffscreen, local)
+	}
+	rect := local.Add(b.Min)
+	if !coveredByDisplays(rect, displays) {
+		return image.Rectangle{}, fmt.Errorf("%w: %v is outside display %d (%dx%d)", errRegionOffscreen, local, region.Display, b.Dx(), b.Dy())
+	}
+	return rect, nil
+}
+
+// regionDisplay returns the index of the display holding most of rect, or -1 if none does.
+func regionDisplay(rect image.Rectangle, displays []image.Rectangle) int {
+	best, bestArea := -1, 0
+	for i, d := range displays {
+		in := rect.Intersect(d)
+		if area := in.Dx() * in.Dy(); area > bestArea {
+			best, bestArea = i, area
+		}
+	}
+	return best
+}
+
+// coveredByDisplays reports whether every pixel of rect lies on some display. Displays are
+// assumed not to overlap, as in an extended desktop.
+func coveredByDisplays(rect image.Rectangle, displays []image.Rectangle) bool {
+	covered := 0
+	for _, d := range displays {
+		in := rect.Intersect(d)
+		covered += in.Dx() * in.Dy()
+	}
+	return covered >= rect.Dx()*rect.Dy()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+)
+
+func TestHandleRepeatArea_RecapturesLastSelection(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	selection := image.Rect(45, 5, 60, 20)
+	env := fakeEnv(src, selection)
+	env.statePath = filepath.Join(t.TempDir(), "state.json")
+
+	if _, _, err := handleRepeatArea(env, t.TempDir(), captureOptions{}); !errors.Is(err, errNoLastRegion) {
+		t.Fatalf("handleRepeatArea() before any selection error=%v want=%v", err, errNoLastRegion)
+	}
+
+	if _, _, err := handleArea(env, t.TempDir(), captureOptions{}); err != nil {
+		t.Fatalf("handleArea() error: %v", err)
+	}
+	st, err := config.LoadState(env.statePath)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if want := config.NewRegion(1, image.Rect(5, 5, 20, 20)); st.LastRegion == nil || *st.LastRegion != want {
+		t.Fatalf("remembered region got=%+v want=%+v", st.LastRegion, want)
+	}
+
+	env.selectArea = nil // the overlay must not open
+	path, _, err := handleRepeatArea(env, t.TempDir(), captureOptions{})
+	if err != nil {
+		t.Fatalf("handleRepeatArea() error: %v", err)
+	}
+	img := decodePNG(t, path)
+	if img.Bounds().Dx() != 15 || img.Bounds().Dy() != 15 {
+		t.Fatalf("bounds=%v want 15x15", img.Bounds())
+	}
+	if got, want := img.At(0, 0), capture.FakePixel(1, 45, 5); got != want {
+		t.Fatalf("pixel=%v want=%v", got, want)
+	}
+}
+
+func TestLastRegionRect_FollowsMovedDisplay(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	region := config.NewRegion(1, image.Rect(5, 5, 20, 20))
+	if err := config.SaveState(statePath, config.State{LastRegion: &region}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Display 1 now sits left of the primary display.
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(-40, 0, 0, 30)), image.Rectangle{})
+	env.statePath = statePath
+	got, err := lastRegionRect(env)
+	if err != nil {
+		t.Fatalf("lastRegionRect() error: %v", err)
+	}
+	if want := image.Rect(-35, 5, -20, 20); got != want {
+		t.Fatalf("lastRegionRect() got=%v want=%v", got, want)
+	}
+}
+
+func TestLastRegionRect_LayoutChangedFails(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	region := config.NewRegion(1, image.Rect(5, 5, 35, 25))
+	if err := config.SaveState(statePath, config.State{LastRegion: &region}); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]*capture.FakeSource{
+		"display removed": capture.NewFakeSource(image.Rect(0, 0, 40, 30)),
+		"display smaller": capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 60, 20)),
+	}
+	for name, src := range cases {
+		env := fakeEnv(src, image.Rectangle{})
+		env.statePath = statePath
+		if _, err := lastRegionRect(env); !errors.Is(err, errRegionOffscreen) {
+			t.Fatalf("%s: lastRegionRect() error=%v want=%v", name, err, errRegionOffscreen)
+		}
+		if n := len(src.Captures()); n != 0 {
+			t.Fatalf("%s: captured %d times, want 0", name, n)
+		}
+	}
+}
+
+func TestRegionDisplay_MostOfTheRect(t *testing.T) {
+	t.Parallel()
+
+	displays := []image.Rectangle{image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30)}
+	cases := []struct {
+		rect image.Rectangle
+		want int
+	}{
+		{rect: image.Rect(5, 5, 10, 10), want: 0},
+		{rect: image.Rect(30, 5, 60, 10), want: 1},
+		{rect: image.Rect(100, 100, 110, 110), want: -1},
+	}
+	for _, tc := range cases {
+		if got := regionDisplay(tc.rect, displays); got != tc.want {
+			t.Fatalf("regionDisplay(%v) got=%d want=%d", tc.rect, got, tc.want)
+		}
+	}
+}
+
+func TestRunCommand_CaptureLast(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30))
+	env := fakeEnv(src, image.Rect(2, 3, 12, 8))
+	env.statePath = filepath.Join(t.TempDir(), "state.json")
+
+	var out bytes.Buffer
+	if err := runCommand(t.Context(), env, []string{"capture", "last", "-o", "-"}, t.TempDir(), config.Config{}, &out); !errors.Is(err, errNoLastRegion) {
+		t.Fatalf("capture last before area error=%v want=%v", err, errNoLastRegion)
+	}
+	if err := runCommand(t.Context(), env, []string{"capture", "area", "-o", "-"}, t.TempDir(), config.Config{}, &out); err != nil {
+		t.Fatalf("capture area error: %v", err)
+	}
+
+	out.Reset()
+	if err := runCommand(t.Context(), env, []string{"capture", "last", "-o", "-"}, t.TempDir(), config.Config{}, &out); err != nil {
+		t.Fatalf("capture last error: %v", err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatalf("decode stdout: %v", err)
+	}
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 5 {
+		t.Fatalf("bounds=%v want 10x5", img.Bounds())
+	}
+}
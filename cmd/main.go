@@ -14,33 +14,55 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/kbinani/screenshot"
-	"golang.design/x/hotkey"
-
 	"go-snip/internal/capture"
+	"go-snip/internal/clipboard"
 	"go-snip/internal/config"
+	"go-snip/internal/history"
+	"go-snip/internal/hotkeys"
 	"go-snip/internal/overlay"
+	"go-snip/internal/thumbs"
 	"go-snip/internal/ui"
 	"go-snip/internal/utils"
 )
 
 const outputDirEnv = "GO_SNIP_OUT"
 
+// version is the go-snip release, set at build time with -ldflags "-X main.version=v1.2.3".
+// Without it, the module version from the build info is used (see appVersion).
+var version = ""
+
 func main() {
 	var outFlag string
 	flag.StringVar(&outFlag, "out", "", "Output directory for screenshots (overrides GO_SNIP_OUT)")
+	flag.Usage = func() { printUsage(flag.CommandLine.Output()) }
 	flag.Parse()
 
 	cfgPath, cfg := loadConfig()
 	outDir := resolveOutputDir(outFlag, os.LookupEnv, cfg.OutputDir)
-	if err := utils.EnsureDir(outDir); err != nil {
-		log.Fatalf("failed to create output dir %q: %v", outDir, err)
-	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
-	if err := runEntry(ctx, outDir, cfgPath, cfg, time.Now, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+	// One-shot subcommands (e.g. `go-snip capture full`) run and exit instead of starting the daemon.
+	if args := flag.Args(); len(args) > 0 {
+		err := runCommand(ctx, defaultCaptureEnv(), args, outDir, cfg, os.Stdout)
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Fatalf("go-snip: %v", err)
+		}
+		return
+	}
+
+	if err := utils.EnsureDir(outDir); err != nil {
+		log.Fatalf("failed to create output dir %q: %v", outDir, err)
+	}
+
+	err := runEntry(ctx, func(ctx context.Context) error {
+		return runHotkeys(ctx, defaultCaptureEnv(), outDir, cfgPath, cfg, os.Stdout)
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Fatalf("go-snip: %v", err)
 	}
 }
@@ -72,39 +94,125 @@ func loadConfig() (path string, cfg config.Config) {
 	return p, c
 }
 
-func runHotkeys(ctx context.Context, initialOutDir string, cfgPath string, cfg config.Config, now func() time.Time, out io.Writer) error {
+// captureEnv holds the dependencies of the capture handlers. Tests swap in a capture.FakeSource
+// and stub UI functions so the capture -> crop -> save pipeline runs headless.
+type captureEnv struct {
+	src          capture.Source
+	windows      capture.WindowLister
+	selectArea   func(src capture.Source, opts overlay.AreaOptions) (rect image.Rectangle, cancelled bool, err error)
+	selectWindow func(src capture.Source, windows []capture.Window, includeDecorations bool) (rect image.Rectangle, cancelled bool, err error)
+	promptSave   func(img image.Image) (edited image.Image, name string, save bool, err error)
+	clipboard    clipboard.Writer
+	countdown    func(ctx context.Context, d time.Duration) error
+	now          func() time.Time
+	// statePath is the state file remembering the last area; empty disables repeat captures.
+	statePath string
+	// afterSave, if set, is called with each saved capture, recording or timelapse folder.
+	afterSave func(e history.Entry)
+	// historyPath is the capture history index; empty disables the history command.
+	historyPath string
+	// open opens a file or folder in the desktop's default application.
+	open func(path string) error
+	// showHistory shows the history browser; thumbsDir caches its thumbnails (empty disables them).
+	showHistory func(entries []history.Entry, actions ui.HistoryActions) error
+	thumbsDir   string
+}
+
+// saved reports e to env.afterSave, if set.
+func (env captureEnv) saved(e history.Entry) {
+	if env.afterSave != nil {
+		env.afterSave(e)
+	}
+}
+
+func defaultCaptureEnv() captureEnv {
+	statePath, err := config.DefaultStatePath()
+	if err != nil {
+		log.Printf("state path unavailable: %v", err)
+	}
+	historyPath, err := history.DefaultPath()
+	if err != nil {
+		log.Printf("history path unavailable: %v", err)
+	}
+	thumbsDir, err := thumbs.DefaultDir()
+	if err != nil {
+		log.Printf("thumbnail cache unavailable: %v", err)
+	}
+	return captureEnv{
+		src:          capture.Screen(),
+		windows:      capture.SystemWindows(),
+		selectArea:   overlay.SelectArea,
+		selectWindow: overlay.SelectWindow,
+		promptSave:   ui.PromptSave,
+		clipboard:    clipboard.System(),
+		countdown:    ui.Countdown,
+		now:          time.Now,
+		statePath:    statePath,
+		afterSave:    historyRecorder(historyPath),
+		historyPath:  historyPath,
+		open:         utils.OpenPath,
+		showHistory:  ui.ShowHistory,
+		thumbsDir:    thumbsDir,
+	}
+}
+
+func runHotkeys(ctx context.Context, env captureEnv, initialOutDir string, cfgPath string, cfg config.Config, out io.Writer) error {
 	if out == nil {
 		out = io.Discard
 	}
 
-	fullHK := hotkey.New([]hotkey.Modifier{hotkey.ModCtrl, hotkey.ModShift}, hotkey.Key1)
-	areaHK := hotkey.New([]hotkey.Modifier{hotkey.ModCtrl, hotkey.ModShift}, hotkey.Key2)
-	settingsHK := hotkey.New([]hotkey.Modifier{hotkey.ModCtrl, hotkey.ModShift}, hotkey.KeyS)
+	events := make(chan string)
+	keys := registerHotkeys(resolveHotkeys(cfg.Hotkeys), events)
+	defer func() { keys.unregister() }()
 
-	if err := fullHK.Register(); err != nil {
-		return fmt.Errorf("register fullscreen hotkey: %w", err)
-	}
-	defer fullHK.Unregister()
+	var outDir atomic.Value
+	outDir.Store(initialOutDir)
 
-	if err := areaHK.Register(); err != nil {
-		return fmt.Errorf("register area hotkey: %w", err)
+	if err := checkRegions(env.src, cfg); err != nil {
+		log.Printf("invalid region config: %v", err)
 	}
-	defer areaHK.Unregister()
 
-	if err := settingsHK.Register(); err != nil {
-		return fmt.Errorf("register settings hotkey: %w", err)
+	// The janitor cleans up on startup and after every save; it outlives the jobs below. Saves
+	// come from their goroutines and settings can replace it, hence the atomic pointer.
+	janitorDir := func() string { return outDir.Load().(string) }
+	var jan atomic.Pointer[janitor]
+	jan.Store(startJanitor(ctx, janitorDir, retentionOptionsFor(cfg), env.now))
+	defer func() { jan.Load().stop() }()
+	record := env.afterSave
+	env.afterSave = func(e history.Entry) {
+		if record != nil {
+			record(e)
+		}
+		jan.Load().kick()
 	}
-	defer settingsHK.Unregister()
 
-	var outDir atomic.Value
-	outDir.Store(initialOutDir)
+	var lapse, rec, scroll *backgroundJob
+	// The history browser runs until its window is closed, so it isn't waited for on exit.
+	var browser *backgroundJob
+	defer func() {
+		lapse.stop()
+		rec.stop()
+		scroll.stop()
+	}()
 
 	for {
+		var action string
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-fullHK.Keydown():
-			path, cancelled, err := handleFull(outDir.Load().(string), cfg.PostCapturePrompt, now)
+		case action = <-events:
+		}
+
+		switch action {
+		case hotkeys.ActionDelayedFullscreen, hotkeys.ActionDelayedArea:
+			if err := waitDelay(ctx, env, captureDelay(cfg)); err != nil {
+				return err
+			}
+		}
+
+		switch action {
+		case hotkeys.ActionFullscreen, hotkeys.ActionDelayedFullscreen:
+			path, cancelled, err := handleFull(env, outDir.Load().(string), displayPolicy(cfg), captureOptionsFor(cfg))
 			if cancelled {
 				continue
 			}
@@ -115,8 +223,13 @@ func runHotkeys(ctx context.Context, initialOutDir string, cfgPath string, cfg c
 			if path != "" {
 				fmt.Fprintln(out, path)
 			}
-		case <-areaHK.Keydown():
-			path, cancelled, err := handleArea(outDir.Load().(string), cfg.PostCapturePrompt, now)
+		case hotkeys.ActionArea, hotkeys.ActionDelayedArea:
+			opts := captureOptionsFor(cfg)
+			// The hotkey loop is blocked in handleArea while the overlay saves, so cfg is ours.
+			opts.saveRegion = func(name string, rect image.Rectangle) error {
+				return saveNamedRegion(env.src, &cfg, cfgPath, name, rect)
+			}
+			path, cancelled, err := handleArea(env, outDir.Load().(string), opts)
 			if cancelled {
 				continue
 			}
@@ -131,9 +244,86 @@ func runHotkeys(ctx context.Context, initialOutDir string, cfgPath string, cfg c
 			if path != "" {
 				fmt.Fprintln(out, path)
 			}
-		case <-settingsHK.Keydown():
-			current := outDir.Load().(string)
-			newCfg, saved, err := ui.ShowSettings(current, cfg.PostCapturePrompt)
+		case hotkeys.ActionRepeatArea:
+			path, cancelled, err := handleRepeatArea(env, outDir.Load().(string), captureOptionsFor(cfg))
+			if cancelled {
+				continue
+			}
+			if err != nil {
+				log.Printf("repeat area capture failed: %v", err)
+				continue
+			}
+			if path != "" {
+				fmt.Fprintln(out, path)
+			}
+		case hotkeys.ActionWindow:
+			path, cancelled, err := handleWindow(env, outDir.Load().(string), cfg.IncludeDecorations, captureOptionsFor(cfg))
+			if cancelled {
+				continue
+			}
+			if err != nil {
+				if errors.Is(err, overlay.ErrSelectionUnavailable) {
+					log.Printf("window selection unavailable (build with -tags=fyne): %v", err)
+				} else {
+					log.Printf("window capture failed: %v", err)
+				}
+				continue
+			}
+			if path != "" {
+				fmt.Fprintln(out, path)
+			}
+		case hotkeys.ActionTimelapse:
+			if lapse.running() {
+				lapse.stop()
+				lapse = nil
+				continue
+			}
+			lapse = startTimelapse(ctx, env, cfg.Regions, outDir.Load().(string), timelapseOptionsFor(cfg))
+		case hotkeys.ActionRecord:
+			if rec.running() {
+				rec.stop()
+				rec = nil
+				continue
+			}
+			rect, cancelled, err := env.selectArea(env.src, overlay.AreaOptions{Constraint: captureOptionsFor(cfg).constraint})
+			if cancelled {
+				continue
+			}
+			if err != nil {
+				if errors.Is(err, overlay.ErrSelectionUnavailable) {
+					log.Printf("area selection unavailable (build with -tags=fyne): %v", err)
+				} else {
+					log.Printf("recording failed: %v", err)
+				}
+				continue
+			}
+			rec = startRecording(ctx, env, rect, outDir.Load().(string), recordOptionsFor(cfg))
+		case hotkeys.ActionScroll:
+			if scroll.running() {
+				scroll.stop()
+				scroll = nil
+				continue
+			}
+			opts := captureOptionsFor(cfg)
+			rect, cancelled, err := env.selectArea(env.src, overlay.AreaOptions{Constraint: opts.constraint})
+			if cancelled {
+				continue
+			}
+			if err != nil {
+				if errors.Is(err, overlay.ErrSelectionUnavailable) {
+					log.Printf("area selection unavailable (build with -tags=fyne): %v", err)
+				} else {
+					log.Printf("scrolling capture failed: %v", err)
+				}
+				continue
+			}
+			scroll = startScroll(ctx, env, rect, outDir.Load().(string), scrollOptionsFor(cfg), opts)
+		case hotkeys.ActionHistory:
+			browser = openHistoryBrowser(ctx, browser, env, outDir.Load().(string), cfg)
+		case hotkeys.ActionSettings:
+			initial := cfg
+			initial.OutputDir = outDir.Load().(string)
+			newCfg, saved, err := ui.ShowSettings(initial)
 			if err != nil {
 				if errors.Is(err, ui.ErrSettingsUnavailable) {
 					log.Printf("settings unavailable (build with -tags=fyne): %v", err)
@@ -157,6 +347,11 @@ func runHotkeys(ctx context.Context, initialOutDir string, cfgPath string, cfg c
 			}
 
 			outDir.Store(effective)
+			keys = rebindHotkeys(keys, newCfg.Hotkeys, events)
+			jan.Store(rebindJanitor(ctx, jan.Load(), cfg, newCfg, janitorDir, env.now))
+			if err := checkRegions(env.src, newCfg); err != nil {
+				log.Printf("invalid region config: %v", err)
+			}
 
 			// Persist (best-effort).
 			cfg = newCfg
@@ -166,121 +361,341 @@ func runHotkeys(ctx context.Context, initialOutDir string, cfgPath string, cfg c
 					log.Printf("failed to save config %q: %v", cfgPath, err)
 				}
 			}
+		default:
+			name, ok := hotkeys.RegionName(action)
+			if !ok {
+				continue
+			}
+			path, cancelled, err := handleNamedRegion(env, name, cfg.Regions, outDir.Load().(string), captureOptionsFor(cfg))
+			if cancelled {
+				continue
+			}
+			if err != nil {
+				log.Printf("region capture failed: %v", err)
+				continue
+			}
+			if path != "" {
+				fmt.Fprintln(out, path)
+			}
 		}
 	}
 }
 
-func handleFull(outDir string, postCapturePrompt bool, now func() time.Time) (savedPath string, cancelled bool, err error) {
-	img, err := capture.CaptureDisplay(0)
+// resolveHotkeys returns the effective bindings for the configured overrides.
+// Invalid overrides are logged and dropped one by one (see hotkeys.ResolveLenient), so a
+// bad entry neither costs the valid ones nor leaves the app without hotkeys.
+func resolveHotkeys(overrides map[string]string) map[string]hotkeys.Chord {
+	chords, err := hotkeys.ResolveLenient(overrides)
 	if err != nil {
-		return "", false, err
+		log.Printf("hotkey config: %v", err)
 	}
+	return chords
+}
 
-	t := now()
-	if postCapturePrompt {
-		name, save, err := ui.PromptSave(img)
-		if err != nil {
-			// Don't lose the capture just because the prompt UI failed.
-			log.Printf("post-capture prompt failed (saving anyway): %v", err)
-		} else if !save {
-			return "", true, nil
-		} else {
-			if utils.SanitizeFilenameComponent(name) != "" {
-				base := utils.BaseNameForTimeAndName(t, name)
-				dest := utils.UniquePathWithBase(outDir, base, func(p string) bool {
-					_, statErr := os.Stat(p)
-					return statErr == nil
-				})
-				if err := utils.SavePNG(img, dest); err != nil {
-					return "", false, err
-				}
-				return dest, false, nil
-			}
-			// Empty (or fully-sanitized-to-empty) name: keep the existing timestamp-only scheme.
-			dest := utils.UniquePath(outDir, t, func(p string) bool {
-				_, statErr := os.Stat(p)
-				return statErr == nil
-			})
-			if err := utils.SavePNG(img, dest); err != nil {
-				return "", false, err
-			}
-			return dest, false, nil
+// rebindHotkeys swaps the registered hotkeys for the given overrides. Chords that can't be
+// registered are logged and skipped (see registerHotkeys).
+func rebindHotkeys(current *hotkeySet, overrides map[string]string, events chan<- string) *hotkeySet {
+	chords := resolveHotkeys(overrides)
+	if current.sameChords(chords) {
+		return current
+	}
+	current.unregister()
+	return registerHotkeys(chords, events)
+}
+
+// displayPolicy returns the capture display policy configured in cfg.
+// An unknown mode is logged and treated as the primary display.
+func displayPolicy(cfg config.Config) capture.DisplayPolicy {
+	p := capture.DisplayPolicy{Mode: cfg.Display, Index: cfg.DisplayIndex}
+	if err := p.Validate(); err != nil {
+		log.Printf("invalid display config (using primary): %v", err)
+		return capture.DisplayPolicy{Mode: capture.DisplayPrimary}
+	}
+	return p
+}
+
+// defaultDelay is the wait of the delayed captures when the config doesn't set one.
+const defaultDelay = 3 * time.Second
+
+// captureDelay returns the configured capture delay. Values outside 1..60 seconds are logged
+// and replaced by defaultDelay.
+func captureDelay(cfg config.Config) time.Duration {
+	switch {
+	case cfg.DelaySeconds == 0:
+		return defaultDelay
+	case cfg.DelaySeconds < 0 || cfg.DelaySeconds > 60:
+		log.Printf("invalid delaySeconds %d (using %v): want 1..60", cfg.DelaySeconds, defaultDelay)
+		return defaultDelay
+	}
+	return time.Duration(cfg.DelaySeconds) * time.Second
+}
+
+// waitDelay waits d, showing the countdown indicator if the build has one. It only fails
+// when ctx is cancelled, returning ctx.Err().
+func waitDelay(ctx context.Context, env captureEnv, d time.Duration) error {
+	if env.countdown != nil {
+		err := env.countdown(ctx, d)
+		if err == nil || ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !errors.Is(err, ui.ErrCountdownUnavailable) {
+			log.Printf("countdown indicator failed (waiting without it): %v", err)
 		}
 	}
 
-	dest := utils.UniquePath(outDir, t, func(p string) bool {
-		_, statErr := os.Stat(p)
-		return statErr == nil
-	})
-	if err := utils.SavePNG(img, dest); err != nil {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// captureOptions are the per-capture settings taken from config.Config.
+type captureOptions struct {
+	postCapturePrompt bool
+	save              utils.SaveOptions
+	destination       clipboard.Destination
+	constraint        overlay.Constraint
+	// saveRegion, if set, lets the area overlay save its selection as a named region.
+	saveRegion func(name string, rect image.Rectangle) error
+	// filename names saved files; the zero template keeps the timestamp names.
+	filename utils.FilenameTemplate
+	// dateFolders saves into outDir/YYYY/MM/DD.
+	dateFolders bool
+	// mode, display and rect (virtual desktop) describe the capture for the filename template
+	// and the history; the handlers set them. tags are added to its history entry.
+	mode    string
+	display int
+	rect    image.Rectangle
+	tags    []string
+	// notes go into the PNG metadata, which stripMetadata leaves out altogether.
+	notes         string
+	stripMetadata bool
+}
+
+// locate records that the capture is of the virtual-desktop rect.
+func (o *captureOptions) locate(src capture.Source, rect image.Rectangle) {
+	o.rect, o.display = rect, displayOf(src, rect)
+}
+
+// captureOptionsFor returns the capture settings in cfg. Invalid image format options are
+// logged and replaced by plain PNG, an invalid destination by saving to file, and invalid
+// selection constraints by free selection, so a bad config never loses a capture.
+func captureOptionsFor(cfg config.Config) captureOptions {
+	save := saveOptionsFor(cfg)
+	if err := save.Validate(); err != nil {
+		log.Printf("invalid image format config (saving PNG): %v", err)
+		save = utils.SaveOptions{Format: utils.FormatPNG}
+	}
+	dest := clipboard.Destination{Mode: cfg.Destination, Content: cfg.ClipboardContent}
+	if err := dest.Validate(); err != nil {
+		log.Printf("invalid destination config (saving to file): %v", err)
+		dest = clipboard.Destination{Mode: clipboard.DestinationFile}
+	}
+	constraint, err := overlay.ParseConstraint(cfg.SelectionAspect, cfg.SelectionSize, cfg.SelectionMinSize)
+	if err != nil {
+		log.Printf("invalid selection constraint config (selecting freely): %v", err)
+	}
+	filename, err := utils.ParseFilenameTemplate(cfg.FilenameTemplate)
+	if err != nil {
+		log.Printf("invalid filename template (using timestamp names): %v", err)
+	}
+	return captureOptions{postCapturePrompt: cfg.PostCapturePrompt, save: save, destination: dest, constraint: constraint, filename: filename, dateFolders: cfg.DateFolders, stripMetadata: cfg.StripMetadata}
+}
+
+func saveOptionsFor(cfg config.Config) utils.SaveOptions {
+	return utils.SaveOptions{
+		Format:          cfg.Format,
+		PNGCompression:  cfg.PNGCompression,
+		JPEGQuality:     cfg.JPEGQuality,
+		GIFColors:       cfg.GIFColors,
+		TIFFCompression: cfg.TIFFCompression,
+	}
+}
+
+func handleFull(env captureEnv, outDir string, policy capture.DisplayPolicy, opts captureOptions) (savedPath string, cancelled bool, err error) {
+	img, bounds, err := capture.CapturePolicy(env.src, policy)
+	if err != nil {
 		return "", false, err
 	}
-	return dest, false, nil
+	opts.mode, opts.rect, opts.display = "full", bounds, policyDisplay(env.src, policy, bounds)
+	return finishCapture(env, img, outDir, opts)
 }
 
-func handleArea(outDir string, postCapturePrompt bool, now func() time.Time) (savedPath string, cancelled bool, err error) {
-	rect, cancelled, err := overlay.SelectArea()
+// handleArea lets the user select an area across all displays. The display policy doesn't
+// apply here: the selection is a virtual-desktop rectangle cropped from a capture of every display.
+// The confirmed selection is remembered for handleRepeatArea.
+func handleArea(env captureEnv, outDir string, opts captureOptions) (savedPath string, cancelled bool, err error) {
+	rect, cancelled, err := env.selectArea(env.src, overlay.AreaOptions{Constraint: opts.constraint, SaveRegion: opts.saveRegion})
 	if err != nil {
 		return "", false, err
 	}
 	if cancelled {
 		return "", true, nil
 	}
+	rememberRegion(env, rect)
+	opts.mode = "area"
+	return finishSelection(env, rect, outDir, opts)
+}
+
+// handleWindow lets the user click a window and captures its frame or client area.
+// The window list is taken before the overlay opens, so the overlay itself is never listed.
+func handleWindow(env captureEnv, outDir string, includeDecorations bool, opts captureOptions) (savedPath string, cancelled bool, err error) {
+	rect, cancelled, err := selectWindow(env, includeDecorations)
+	if err != nil || cancelled {
+		return "", cancelled, err
+	}
+	opts.mode = "window"
+	return finishSelection(env, rect, outDir, opts)
+}
 
-	img, err := capture.CaptureDisplay(0)
+func selectWindow(env captureEnv, includeDecorations bool) (rect image.Rectangle, cancelled bool, err error) {
+	if env.windows == nil {
+		return image.Rectangle{}, false, capture.ErrWindowsUnavailable
+	}
+	windows, err := env.windows.Windows()
 	if err != nil {
-		return "", false, err
+		return image.Rectangle{}, false, err
 	}
+	return env.selectWindow(env.src, windows, includeDecorations)
+}
 
-	displayBounds := screenshot.GetDisplayBounds(0)
-	cropRect := cropRectFor(img.Bounds(), displayBounds, rect)
-	cropped, err := capture.Crop(img, cropRect)
+// finishSelection crops a virtual-desktop rectangle out of a capture of every display and
+// finishes the capture.
+func finishSelection(env captureEnv, rect image.Rectangle, outDir string, opts captureOptions) (savedPath string, cancelled bool, err error) {
+	img, err := captureDesktopRect(env.src, rect)
 	if err != nil {
 		return "", false, err
 	}
+	opts.locate(env.src, rect)
+	return finishCapture(env, img, outDir, opts)
+}
+
+// displayOf returns the index of the display holding most of the virtual-desktop rect, or -1.
+func displayOf(src capture.Source, rect image.Rectangle) int {
+	return regionDisplay(rect, capture.DisplayBounds(src))
+}
+
+// policyDisplay returns the display index a capture under policy came from (bounds is what
+// CapturePolicy returned), or -1 for a composite of all displays.
+func policyDisplay(src capture.Source, policy capture.DisplayPolicy, bounds image.Rectangle) int {
+	if policy.Mode == capture.DisplayAll {
+		return -1
+	}
+	return displayOf(src, bounds)
+}
+
+// captureDesktopRect captures every display of src and crops rect (virtual-desktop coordinates).
+func captureDesktopRect(src capture.Source, rect image.Rectangle) (image.Image, error) {
+	full, desktop, err := capture.CapturePolicy(src, capture.DisplayPolicy{Mode: capture.DisplayAll})
+	if err != nil {
+		return nil, err
+	}
+	return capture.Crop(full, cropRectFor(full.Bounds(), desktop, rect))
+}
 
-	t := now()
-	if postCapturePrompt {
-		name, save, err := ui.PromptSave(cropped)
+// finishCapture runs the optional post-capture prompt (which may annotate img), then saves img
+// into outDir and/or copies it to the clipboard according to opts.destination. savedPath is
+// empty if nothing was saved.
+func finishCapture(env captureEnv, img image.Image, outDir string, opts captureOptions) (savedPath string, cancelled bool, err error) {
+	t := env.now()
+	name := ""
+	if opts.postCapturePrompt {
+		edited, n, save, err := env.promptSave(img)
 		if err != nil {
+			// Don't lose the capture just because the prompt UI failed.
 			log.Printf("post-capture prompt failed (saving anyway): %v", err)
 		} else if !save {
 			return "", true, nil
 		} else {
-			if utils.SanitizeFilenameComponent(name) != "" {
-				base := utils.BaseNameForTimeAndName(t, name)
-				dest := utils.UniquePathWithBase(outDir, base, func(p string) bool {
-					_, statErr := os.Stat(p)
-					return statErr == nil
-				})
-				if err := utils.SavePNG(cropped, dest); err != nil {
-					return "", false, err
-				}
-				return dest, false, nil
+			name = n
+			if edited != nil && edited != img {
+				opts.tags = append(opts.tags, "annotated")
+			}
+			if edited != nil {
+				img = edited
 			}
-			dest := utils.UniquePath(outDir, t, func(p string) bool {
-				_, statErr := os.Stat(p)
-				return statErr == nil
-			})
-			if err := utils.SavePNG(cropped, dest); err != nil {
+		}
+	}
+
+	dest := ""
+	if opts.destination.ToFile() {
+		opts.save.Metadata = captureMetadata(env.src, t, name, opts)
+		dest, err = saveCapture(img, outDir, t, name, opts)
+		if err != nil {
+			return "", false, err
+		}
+		env.saved(captureEntry(dest, t, name, img.Bounds().Size(), opts))
+	}
+	if opts.destination.ToClipboard() {
+		if err := copyCapture(env.clipboard, img, dest, opts.destination); err != nil {
+			if dest == "" {
 				return "", false, err
 			}
-			return dest, false, nil
+			// The file is safe on disk; a clipboard failure shouldn't turn that into an error.
+			log.Printf("copy to clipboard failed (saved to %s): %v", dest, err)
 		}
 	}
+	return dest, false, nil
+}
+
+// copyCapture puts img, or savedPath if the destination copies paths, on the clipboard.
+func copyCapture(w clipboard.Writer, img image.Image, savedPath string, d clipboard.Destination) error {
+	if w == nil {
+		return clipboard.ErrUnavailable
+	}
+	if d.CopiesPath() && savedPath != "" {
+		return w.WriteText(savedPath)
+	}
+	return clipboard.CopyImage(w, img)
+}
 
-	dest := utils.UniquePath(outDir, t, func(p string) bool {
+// saveCapture saves img into outDir (its date folder with opts.dateFolders) under a unique name
+// from opts.filename or, without a template, a timestamped name with the user's name appended
+// if it survives sanitizing. The extension follows the save format.
+func saveCapture(img image.Image, outDir string, t time.Time, name string, opts captureOptions) (string, error) {
+	exists := func(p string) bool {
 		_, statErr := os.Stat(p)
 		return statErr == nil
-	})
-	if err := utils.SavePNG(cropped, dest); err != nil {
-		return "", false, err
 	}
-	return dest, false, nil
+	if opts.dateFolders {
+		outDir = utils.DateDir(outDir, t)
+	}
+
+	save := opts.save
+	var dest string
+	if !opts.filename.IsZero() {
+		b := img.Bounds()
+		fields := utils.FilenameFields{Time: t, Mode: opts.mode, Display: opts.display, Width: b.Dx(), Height: b.Dy(), Host: hostname(), Name: name}
+		dest = opts.filename.UniquePath(outDir, fields, save.Extension(), exists)
+	} else if utils.SanitizeFilenameComponent(name) != "" {
+		base := utils.BaseNameForTimeAndName(t, name)
+		dest = utils.UniquePathWithBaseExt(outDir, base, save.Extension(), exists)
+	} else {
+		// Empty (or fully-sanitized-to-empty) name: keep the existing timestamp-only scheme.
+		dest = utils.UniquePathExt(outDir, t, save.Extension(), exists)
+	}
+	if err := utils.SaveImage(img, dest, save); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// hostname returns the machine name for the {host} filename placeholder, or "" if unknown.
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return h
 }
 
 // cropRectFor maps a screen-space selection rectangle (relative to displayBounds) into the
-// coordinate space of the captured image bounds.
+// coordinate space of the captured image bounds. displayBounds may be the union of several
+// displays when img is a composited capture.
 func cropRectFor(imgBounds, displayBounds, selectionRect image.Rectangle) image.Rectangle {
 	// Screen coordinate (s) -> image coordinate (i):
 	// i = s - displayBounds.Min + imgBounds.Min
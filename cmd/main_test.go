@@ -1,8 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/clipboard"
+	"go-snip/internal/config"
+	"go-snip/internal/hotkeys"
+	"go-snip/internal/overlay"
+	"go-snip/internal/ui"
+	"go-snip/internal/utils"
 )
 
 func TestMainPackageBuilds(t *testing.T) {
@@ -92,3 +108,367 @@ func TestCropRectFor_ImageBoundsHaveOffset(t *testing.T) {
 		t.Fatalf("got=%v want=%v", got, want)
 	}
 }
+
+func TestCropRectFor_CompositedNegativeOriginDesktop(t *testing.T) {
+	t.Parallel()
+
+	// Secondary display at x=-1280 left of a 1920x1080 primary; the composite starts at (0,0).
+	desktop := image.Rect(-1280, 0, 1920, 1080)
+	imgBounds := image.Rect(0, 0, 3200, 1080)
+	selection := image.Rect(-100, 50, 200, 150) // spans both displays
+
+	got := cropRectFor(imgBounds, desktop, selection)
+	want := image.Rect(1180, 50, 1480, 150)
+	if got != want {
+		t.Fatalf("got=%v want=%v", got, want)
+	}
+}
+
+// fakeEnv returns a captureEnv backed by src with the UI stubbed out: the area selection
+// returns selection, the window selection picks the topmost window and the post-capture
+// prompt saves without a name.
+func fakeEnv(src capture.Source, selection image.Rectangle) captureEnv {
+	return captureEnv{
+		src: src,
+		selectArea: func(capture.Source, overlay.AreaOptions) (image.Rectangle, bool, error) {
+			return selection, false, nil
+		},
+		selectWindow: func(_ capture.Source, windows []capture.Window, includeDecorations bool) (image.Rectangle, bool, error) {
+			return windows[0].Rect(includeDecorations), false, nil
+		},
+		promptSave: func(img image.Image) (image.Image, string, bool, error) { return img, "", true, nil },
+		clipboard:  clipboard.NewFake(),
+		now:        func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local) },
+	}
+}
+
+func decodePNG(t *testing.T, path string) image.Image {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %q: %v", path, err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decode %q: %v", path, err)
+	}
+	return img
+}
+
+func TestHandleFull_FakeSource(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 60, 20))
+	outDir := t.TempDir()
+
+	path, cancelled, err := handleFull(fakeEnv(src, image.Rectangle{}), outDir, capture.DisplayPolicy{Mode: capture.DisplayIndex, Index: 1}, captureOptions{})
+	if err != nil || cancelled {
+		t.Fatalf("handleFull() path=%q cancelled=%v err=%v", path, cancelled, err)
+	}
+	if filepath.Dir(path) != outDir {
+		t.Fatalf("saved to %q, want inside %q", path, outDir)
+	}
+
+	img := decodePNG(t, path)
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 20 {
+		t.Fatalf("saved bounds=%v want 20x20", img.Bounds())
+	}
+	if got, want := img.At(0, 0), capture.FakePixel(1, 40, 0); got != want {
+		t.Fatalf("pixel=%v want=%v", got, want)
+	}
+}
+
+func TestHandleArea_FakeSourceSpanningDisplays(t *testing.T) {
+	t.Parallel()
+
+	// Secondary display left of the primary; the selection straddles both.
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(-20, 0, 0, 30))
+	selection := image.Rect(-5, 10, 5, 14)
+
+	path, cancelled, err := handleArea(fakeEnv(src, selection), t.TempDir(), captureOptions{postCapturePrompt: true})
+	if err != nil || cancelled {
+		t.Fatalf("handleArea() path=%q cancelled=%v err=%v", path, cancelled, err)
+	}
+
+	img := decodePNG(t, path)
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 4 {
+		t.Fatalf("saved bounds=%v want 10x4", img.Bounds())
+	}
+	if got, want := img.At(0, 0), capture.FakePixel(1, -5, 10); got != want {
+		t.Fatalf("left pixel=%v want=%v", got, want)
+	}
+	if got, want := img.At(9, 3), capture.FakePixel(0, 4, 13); got != want {
+		t.Fatalf("right pixel=%v want=%v", got, want)
+	}
+}
+
+func TestHandleArea_FilenameTemplate(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	tmpl, err := utils.ParseFilenameTemplate("{date}/{mode}_{display}_{w}x{h}_{counter}")
+	if err != nil {
+		t.Fatalf("ParseFilenameTemplate() error: %v", err)
+	}
+	outDir := t.TempDir()
+	opts := captureOptions{filename: tmpl}
+
+	for _, want := range []string{"area_1_12x8_001.png", "area_1_12x8_002.png"} {
+		path, cancelled, err := handleArea(fakeEnv(src, image.Rect(45, 5, 57, 13)), outDir, opts)
+		if err != nil || cancelled {
+			t.Fatalf("handleArea() path=%q cancelled=%v err=%v", path, cancelled, err)
+		}
+		if want := filepath.Join(outDir, "2025-01-02", want); path != want {
+			t.Fatalf("path got=%q want=%q", path, want)
+		}
+		decodePNG(t, path)
+	}
+
+	path, _, err := handleFull(fakeEnv(src, image.Rectangle{}), outDir, capture.DisplayPolicy{Mode: capture.DisplayAll}, opts)
+	if err != nil {
+		t.Fatalf("handleFull() error: %v", err)
+	}
+	if want := filepath.Join(outDir, "2025-01-02", "full_all_80x30_001.png"); path != want {
+		t.Fatalf("path got=%q want=%q", path, want)
+	}
+}
+
+func TestHandleArea_CancelledSelection(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	env.selectArea = func(capture.Source, overlay.AreaOptions) (image.Rectangle, bool, error) {
+		return image.Rectangle{}, true, nil
+	}
+
+	path, cancelled, err := handleArea(env, t.TempDir(), captureOptions{})
+	if err != nil || !cancelled || path != "" {
+		t.Fatalf("handleArea() path=%q cancelled=%v err=%v", path, cancelled, err)
+	}
+}
+
+func TestHandleArea_PassesConfiguredConstraint(t *testing.T) {
+	t.Parallel()
+
+	var got overlay.Constraint
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	env.selectArea = func(_ capture.Source, opts overlay.AreaOptions) (image.Rectangle, bool, error) {
+		got = opts.Constraint
+		return image.Rectangle{}, true, nil
+	}
+
+	opts := captureOptionsFor(config.Config{SelectionAspect: "16:9", SelectionMinSize: "64x36"})
+	if _, _, err := handleArea(env, t.TempDir(), opts); err != nil {
+		t.Fatalf("handleArea() error: %v", err)
+	}
+	want := overlay.Constraint{Aspect: image.Pt(16, 9), Min: image.Pt(64, 36)}
+	if got != want {
+		t.Fatalf("selectArea constraint got=%v want=%v", got, want)
+	}
+
+	if c := captureOptionsFor(config.Config{SelectionSize: "big"}).constraint; !c.IsZero() {
+		t.Fatalf("invalid constraint config got=%v want zero", c)
+	}
+}
+
+func TestHandleArea_ClipboardOnlyWritesNothing(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 40, 30)), image.Rect(2, 3, 12, 8))
+	fake := clipboard.NewFake()
+	env.clipboard = fake
+	outDir := t.TempDir()
+
+	opts := captureOptions{destination: clipboard.Destination{Mode: clipboard.DestinationClipboard}}
+	path, cancelled, err := handleArea(env, outDir, opts)
+	if err != nil || cancelled || path != "" {
+		t.Fatalf("handleArea() path=%q cancelled=%v err=%v", path, cancelled, err)
+	}
+	if entries, _ := os.ReadDir(outDir); len(entries) != 0 {
+		t.Fatalf("outDir has %d entries, want none", len(entries))
+	}
+
+	img, err := png.Decode(bytes.NewReader(fake.Image()))
+	if err != nil {
+		t.Fatalf("decode clipboard image: %v", err)
+	}
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 5 {
+		t.Fatalf("clipboard bounds=%v want 10x5", img.Bounds())
+	}
+}
+
+func TestHandleFull_BothCopiesSavedPath(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	fake := clipboard.NewFake()
+	env.clipboard = fake
+
+	opts := captureOptions{destination: clipboard.Destination{Mode: clipboard.DestinationBoth, Content: clipboard.ContentPath}}
+	path, _, err := handleFull(env, t.TempDir(), capture.DisplayPolicy{}, opts)
+	if err != nil {
+		t.Fatalf("handleFull() error: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("saved file: %v", err)
+	}
+	if fake.Text() != path {
+		t.Fatalf("clipboard text=%q want=%q", fake.Text(), path)
+	}
+}
+
+func TestHandleFull_ClipboardFailureKeepsSavedFile(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	fake := clipboard.NewFake()
+	fake.SetError(clipboard.ErrUnavailable)
+	env.clipboard = fake
+
+	path, _, err := handleFull(env, t.TempDir(), capture.DisplayPolicy{}, captureOptions{destination: clipboard.Destination{Mode: clipboard.DestinationBoth}})
+	if err != nil || path == "" {
+		t.Fatalf("handleFull() path=%q err=%v", path, err)
+	}
+
+	_, _, err = handleFull(env, t.TempDir(), capture.DisplayPolicy{}, captureOptions{destination: clipboard.Destination{Mode: clipboard.DestinationClipboard}})
+	if !errors.Is(err, clipboard.ErrUnavailable) {
+		t.Fatalf("clipboard-only error=%v want=%v", err, clipboard.ErrUnavailable)
+	}
+}
+
+func TestFinishCapture_SavesAnnotatedImage(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	marked := color.RGBA{R: 1, G: 2, B: 3, A: 255}
+	env.promptSave = func(img image.Image) (image.Image, string, bool, error) {
+		edited := image.NewRGBA(img.Bounds())
+		edited.SetRGBA(4, 4, marked)
+		return edited, "notes", true, nil
+	}
+
+	path, _, err := handleFull(env, t.TempDir(), capture.DisplayPolicy{}, captureOptions{postCapturePrompt: true})
+	if err != nil {
+		t.Fatalf("handleFull() error: %v", err)
+	}
+	if got := decodePNG(t, path).At(4, 4); color.RGBAModel.Convert(got) != marked {
+		t.Fatalf("saved pixel=%v want annotated %v", got, marked)
+	}
+}
+
+func TestCaptureDelay(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		seconds int
+		want    time.Duration
+	}{
+		{seconds: 0, want: defaultDelay},
+		{seconds: 5, want: 5 * time.Second},
+		{seconds: -1, want: defaultDelay},
+		{seconds: 600, want: defaultDelay},
+	}
+	for _, tc := range cases {
+		if got := captureDelay(config.Config{DelaySeconds: tc.seconds}); got != tc.want {
+			t.Fatalf("captureDelay(%d)=%v want=%v", tc.seconds, got, tc.want)
+		}
+	}
+}
+
+func TestWaitDelay_FallsBackWithoutCountdown(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 1, 1)), image.Rectangle{})
+	env.countdown = func(context.Context, time.Duration) error { return ui.ErrCountdownUnavailable }
+
+	start := time.Now()
+	if err := waitDelay(t.Context(), env, 20*time.Millisecond); err != nil {
+		t.Fatalf("waitDelay() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("waitDelay() returned after %v, want at least 20ms", elapsed)
+	}
+}
+
+func TestWaitDelay_CancelledDuringCountdown(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 1, 1)), image.Rectangle{})
+	env.countdown = func(ctx context.Context, _ time.Duration) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	if err := waitDelay(ctx, env, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("waitDelay() error=%v want=%v", err, context.Canceled)
+	}
+}
+
+func TestHandleWindow_ClientAreaOrFrame(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	env := fakeEnv(src, image.Rectangle{})
+	env.windows = capture.FakeWindows{
+		{ID: 7, Title: "editor", Frame: image.Rect(30, 2, 50, 20), Client: image.Rect(31, 8, 49, 19)},
+	}
+
+	for _, tc := range []struct {
+		decorations bool
+		want        image.Rectangle
+	}{
+		{decorations: false, want: image.Rect(31, 8, 49, 19)},
+		{decorations: true, want: image.Rect(30, 2, 50, 20)},
+	} {
+		path, _, err := handleWindow(env, t.TempDir(), tc.decorations, captureOptions{})
+		if err != nil {
+			t.Fatalf("handleWindow(decorations=%v) error: %v", tc.decorations, err)
+		}
+		img := decodePNG(t, path)
+		if img.Bounds().Size() != tc.want.Size() {
+			t.Fatalf("decorations=%v bounds=%v want size of %v", tc.decorations, img.Bounds(), tc.want)
+		}
+		if got, want := img.At(0, 0), capture.FakePixel(0, tc.want.Min.X, tc.want.Min.Y); got != want {
+			t.Fatalf("decorations=%v pixel=%v want=%v", tc.decorations, got, want)
+		}
+	}
+}
+
+func TestHandleWindow_EnumerationUnavailable(t *testing.T) {
+	t.Parallel()
+
+	env := fakeEnv(capture.NewFakeSource(image.Rect(0, 0, 10, 10)), image.Rectangle{})
+	if _, _, err := handleWindow(env, t.TempDir(), false, captureOptions{}); !errors.Is(err, capture.ErrWindowsUnavailable) {
+		t.Fatalf("handleWindow() error=%v want=%v", err, capture.ErrWindowsUnavailable)
+	}
+}
+
+func TestResolveHotkeys_KeepsValidOverridesOnUpgrade(t *testing.T) {
+	t.Parallel()
+
+	// A config from before the delayed captures existed: area moved to Ctrl+Shift+3, plus an
+	// entry this version rejects. Only the bad entry is dropped.
+	got := resolveHotkeys(map[string]string{
+		hotkeys.ActionArea:              "Ctrl+Shift+3",
+		hotkeys.ActionFullscreen:        "Ctrl+Shift+2",
+		hotkeys.ActionDelayedFullscreen: "Ctrl+Shift+3",
+		"screenshot":                    "Ctrl+9",
+	})
+	want := map[string]string{
+		hotkeys.ActionArea:       "Ctrl+Shift+3",
+		hotkeys.ActionFullscreen: "Ctrl+Shift+2",
+		hotkeys.ActionSettings:   "Ctrl+Shift+S",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("resolveHotkeys() got=%v want=%v", got, want)
+	}
+	for action, chord := range want {
+		if got[action].String() != chord {
+			t.Fatalf("resolveHotkeys() %s=%v want=%s", action, got[action], chord)
+		}
+	}
+}
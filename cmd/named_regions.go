@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"slices"
+	"strings"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/hotkeys"
+)
+
+// errUnknownRegion is returned for a region name that isn't in the config.
+var errUnknownRegion = errors.New("unknown region")
+
+// handleNamedRegion captures the named region from regions without the overlay.
+func handleNamedRegion(env captureEnv, name string, regions []config.NamedRegion, outDir string, opts captureOptions) (savedPath string, cancelled bool, err error) {
+	rect, err := namedRegionRect(env.src, name, regions)
+	if err != nil {
+		return "", false, err
+	}
+	opts.mode = "region"
+	opts.tags = append(opts.tags, name)
+	return finishSelection(env, rect, outDir, opts)
+}
+
+// namedRegionRect returns the named region in virtual-desktop coordinates, validated against
+// the current display bounds (see regionRect).
+func namedRegionRect(src capture.Source, name string, regions []config.NamedRegion) (image.Rectangle, error) {
+	i := slices.IndexFunc(regions, func(r config.NamedRegion) bool { return r.Name == name })
+	if i < 0 {
+		return image.Rectangle{}, fmt.Errorf("%w %q", errUnknownRegion, name)
+	}
+	rect, err := regionRect(regions[i].Region(), capture.DisplayBounds(src))
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("region %q: %w", name, err)
+	}
+	return rect, nil
+}
+
+// checkRegions validates the configured regions against the current display bounds, and the
+// region hotkeys against the regions. It runs whenever the config is loaded; problems are
+// returned together so they can be logged, and captures of broken regions fail on their own.
+func checkRegions(src capture.Source, cfg config.Config) error {
+	var errs []error
+	displays := capture.DisplayBounds(src)
+	seen := make(map[string]bool, len(cfg.Regions))
+	for _, r := range cfg.Regions {
+		switch {
+		case strings.TrimSpace(r.Name) == "":
+			errs = append(errs, fmt.Errorf("region %v has no name", r.Rect))
+			continue
+		case seen[r.Name]:
+			errs = append(errs, fmt.Errorf("region %q is defined more than once", r.Name))
+			continue
+		}
+		seen[r.Name] = true
+		if _, err := regionRect(r.Region(), displays); err != nil {
+			errs = append(errs, fmt.Errorf("region %q: %w", r.Name, err))
+		}
+	}
+	for action, binding := range cfg.Hotkeys {
+		if name, ok := hotkeys.RegionName(action); ok && strings.TrimSpace(binding) != "" && !seen[name] {
+			errs = append(errs, fmt.Errorf("hotkey %s: %w %q", binding, errUnknownRegion, name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// saveNamedRegion stores rect (virtual-desktop coordinates) in cfg under name, relative to the
+// display holding most of it, replacing a region of the same name, and saves cfg to cfgPath.
+func saveNamedRegion(src capture.Source, cfg *config.Config, cfgPath string, name string, rect image.Rectangle) error {
+	if name == "" {
+		return errors.New("region name is empty")
+	}
+	if strings.TrimSpace(cfgPath) == "" {
+		return errors.New("no config file to save the region to")
+	}
+	displays := capture.DisplayBounds(src)
+	index := regionDisplay(rect, displays)
+	if index < 0 {
+		return fmt.Errorf("%w: %v is on no display", errRegionOffscreen, rect)
+	}
+	local := rect.Sub(displays[index].Min)
+	region := config.NamedRegion{Name: name, Display: index, Rect: [4]int{local.Min.X, local.Min.Y, local.Max.X, local.Max.Y}}
+
+	updated := *cfg
+	updated.Regions = slices.Clone(cfg.Regions)
+	if i := slices.IndexFunc(updated.Regions, func(r config.NamedRegion) bool { return r.Name == name }); i >= 0 {
+		updated.Regions[i] = region
+	} else {
+		updated.Regions = append(updated.Regions, region)
+	}
+	if err := config.Save(cfgPath, updated); err != nil {
+		return err
+	}
+	*cfg = updated
+	log.Printf("saved region %q (display %d, %v)", name, index, local)
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+)
+
+func testRegions() []config.NamedRegion {
+	return []config.NamedRegion{
+		{Name: "grafana-panel", Display: 1, Rect: [4]int{5, 5, 20, 20}},
+		{Name: "too-wide", Display: 0, Rect: [4]int{30, 0, 50, 10}},
+	}
+}
+
+func TestHandleNamedRegion_CapturesWithoutOverlay(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	env := fakeEnv(src, image.Rectangle{})
+	env.selectArea = nil
+
+	path, _, err := handleNamedRegion(env, "grafana-panel", testRegions(), t.TempDir(), captureOptions{})
+	if err != nil {
+		t.Fatalf("handleNamedRegion() error: %v", err)
+	}
+	img := decodePNG(t, path)
+	if img.Bounds().Dx() != 15 || img.Bounds().Dy() != 15 {
+		t.Fatalf("bounds=%v want 15x15", img.Bounds())
+	}
+	if got, want := img.At(0, 0), capture.FakePixel(1, 45, 5); got != want {
+		t.Fatalf("pixel=%v want=%v", got, want)
+	}
+
+	if _, _, err := handleNamedRegion(env, "nope", testRegions(), t.TempDir(), captureOptions{}); !errors.Is(err, errUnknownRegion) {
+		t.Fatalf("unknown region error=%v want=%v", err, errUnknownRegion)
+	}
+}
+
+func TestNamedRegionRect_ValidatedAgainstDisplays(t *testing.T) {
+	t.Parallel()
+
+	// With only display 0 left, "too-wide" runs off its right edge and display 1 is gone.
+	single := capture.NewFakeSource(image.Rect(0, 0, 40, 30))
+	if _, err := namedRegionRect(single, "too-wide", testRegions()); !errors.Is(err, errRegionOffscreen) {
+		t.Fatalf("off-screen region error=%v want=%v", err, errRegionOffscreen)
+	}
+	if _, err := namedRegionRect(single, "grafana-panel", testRegions()); !errors.Is(err, errRegionOffscreen) {
+		t.Fatalf("missing display error=%v want=%v", err, errRegionOffscreen)
+	}
+	if n := len(single.Captures()); n != 0 {
+		t.Fatalf("captured %d times, want 0", n)
+	}
+}
+
+func TestCheckRegions_ReportsProblems(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	good := config.Config{Regions: testRegions()[:1], Hotkeys: map[string]string{"region:grafana-panel": "Ctrl+Alt+G"}}
+	if err := checkRegions(src, good); err != nil {
+		t.Fatalf("checkRegions(valid) error: %v", err)
+	}
+
+	bad := config.Config{
+		Regions: []config.NamedRegion{
+			{Name: "a", Display: 0, Rect: [4]int{0, 0, 10, 10}},
+			{Name: "a", Display: 0, Rect: [4]int{0, 0, 5, 5}},
+			{Name: "gone", Display: 3, Rect: [4]int{0, 0, 5, 5}},
+		},
+		Hotkeys: map[string]string{"region:missing": "Ctrl+Alt+M"},
+	}
+	err := checkRegions(src, bad)
+	for _, want := range []error{errRegionOffscreen, errUnknownRegion} {
+		if !errors.Is(err, want) {
+			t.Fatalf("checkRegions() error=%v, want it to wrap %v", err, want)
+		}
+	}
+}
+
+func TestSaveNamedRegion_AddsAndReplaces(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	cfgPath := filepath.Join(t.TempDir(), "config.json")
+	cfg := config.Config{OutputDir: "shots", Regions: testRegions()}
+
+	if err := saveNamedRegion(src, &cfg, cfgPath, "grafana-panel", image.Rect(42, 2, 62, 12)); err != nil {
+		t.Fatalf("saveNamedRegion(replace) error: %v", err)
+	}
+	if err := saveNamedRegion(src, &cfg, cfgPath, "logo", image.Rect(1, 1, 9, 9)); err != nil {
+		t.Fatalf("saveNamedRegion(add) error: %v", err)
+	}
+	want := []config.NamedRegion{
+		{Name: "grafana-panel", Display: 1, Rect: [4]int{2, 2, 22, 12}},
+		testRegions()[1],
+		{Name: "logo", Display: 0, Rect: [4]int{1, 1, 9, 9}},
+	}
+	if !reflect.DeepEqual(cfg.Regions, want) {
+		t.Fatalf("regions got=%+v want=%+v", cfg.Regions, want)
+	}
+	saved, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !reflect.DeepEqual(saved, cfg) {
+		t.Fatalf("saved config got=%+v want=%+v", saved, cfg)
+	}
+
+	if err := saveNamedRegion(src, &cfg, cfgPath, "", image.Rect(1, 1, 9, 9)); err == nil {
+		t.Fatalf("saveNamedRegion(empty name) error=nil")
+	}
+}
+
+func TestRunCommand_CaptureRegionByName(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	cfg := config.Config{Regions: testRegions()}
+	var out bytes.Buffer
+	args := []string{"capture", "region", "--name", "grafana-panel", "-o", "-"}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, t.TempDir(), cfg, &out); err != nil {
+		t.Fatalf("runCommand() error: %v", err)
+	}
+	img, err := png.Decode(&out)
+	if err != nil {
+		t.Fatalf("decode stdout: %v", err)
+	}
+	if got, want := img.At(0, 0), capture.FakePixel(1, 45, 5); got != want {
+		t.Fatalf("pixel=%v want=%v", got, want)
+	}
+
+	args = []string{"capture", "region", "--name", "grafana-panel", "--rect", "0,0,5,5"}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, t.TempDir(), cfg, &out); !errors.Is(err, errUsage) {
+		t.Fatalf("--name with --rect error=%v want=%v", err, errUsage)
+	}
+}
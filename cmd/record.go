@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/history"
+	"go-snip/internal/record"
+	"go-snip/internal/utils"
+)
+
+// recordOptions are the settings of one screen recording.
+type recordOptions struct {
+	record.Options
+	// maxDuration stops the recording if it isn't stopped earlier; zero runs until ctx is
+	// cancelled.
+	maxDuration time.Duration
+	// filename names the saved file; the zero template keeps the timestamp names.
+	filename utils.FilenameTemplate
+	// dateFolders saves into outDir/YYYY/MM/DD.
+	dateFolders bool
+}
+
+// recordOptionsFor returns the recording settings in cfg. Invalid values are logged and
+// replaced by the defaults.
+func recordOptionsFor(cfg config.Config) recordOptions {
+	opts := recordOptions{Options: record.Options{Format: record.FormatGIF}, maxDuration: record.DefaultMaxDuration, dateFolders: cfg.DateFolders}
+	if cfg.RecordFPS < 0 || cfg.RecordFPS > record.MaxFPS {
+		log.Printf("invalid recordFps %d (using %d)", cfg.RecordFPS, record.DefaultFPS)
+	} else {
+		opts.FPS = cfg.RecordFPS
+	}
+	switch {
+	case cfg.RecordMaxSeconds < 0:
+		log.Printf("invalid recordMaxSeconds %d (using %v)", cfg.RecordMaxSeconds, opts.maxDuration)
+	case cfg.RecordMaxSeconds > 0:
+		opts.maxDuration = time.Duration(cfg.RecordMaxSeconds) * time.Second
+	}
+	if f, err := record.ParseFormat(cfg.RecordFormat); err != nil {
+		log.Printf("invalid recordFormat: %v (using %s)", err, record.FormatGIF)
+	} else {
+		opts.Format = f
+	}
+	if t, err := utils.ParseFilenameTemplate(cfg.FilenameTemplate); err != nil {
+		log.Printf("invalid filename template (using timestamp names): %v", err)
+	} else {
+		opts.filename = t
+	}
+	return opts
+}
+
+// recordingPath returns a new file name in outDir for a recording of the virtual-desktop rect
+// started at t.
+func recordingPath(src capture.Source, outDir string, t time.Time, rect image.Rectangle, opts recordOptions) string {
+	exists := func(p string) bool {
+		_, err := os.Stat(p)
+		return err == nil
+	}
+	if opts.dateFolders {
+		outDir = utils.DateDir(outDir, t)
+	}
+	ext := record.Extension(opts.Format)
+	if opts.filename.IsZero() {
+		return utils.UniquePathWithBaseExt(outDir, utils.BaseNameForTimeAndName(t, "recording"), ext, exists)
+	}
+	fields := utils.FilenameFields{Time: t, Mode: "record", Display: displayOf(src, rect), Width: rect.Dx(), Height: rect.Dy(), Host: hostname()}
+	return opts.filename.UniquePath(outDir, fields, ext, exists)
+}
+
+// runRecording records the virtual-desktop rect until ctx is cancelled or opts.maxDuration has
+// passed, then writes the animation to dest (a new file in outDir if dest is empty). It
+// returns the path written.
+func runRecording(ctx context.Context, env captureEnv, rect image.Rectangle, dest, outDir string, opts recordOptions) (string, record.Stats, error) {
+	if err := opts.Validate(); err != nil {
+		return "", record.Stats{}, err
+	}
+	if rect.Empty() {
+		return "", record.Stats{}, fmt.Errorf("record: empty region %v", rect)
+	}
+	start := env.now()
+	if dest == "" {
+		dest = recordingPath(env.src, outDir, start, rect, opts)
+		if err := utils.EnsureDir(filepath.Dir(dest)); err != nil {
+			return "", record.Stats{}, fmt.Errorf("create output dir %q: %w", filepath.Dir(dest), err)
+		}
+	}
+	if opts.maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.maxDuration)
+		defer cancel()
+	}
+
+	grab := func() (image.Image, error) { return env.src.CaptureRect(rect) }
+	var buf bytes.Buffer
+	stats, err := record.Record(ctx, grab, record.Ticks(ctx, opts.Interval()), &buf, opts.Options)
+	if err != nil {
+		return "", stats, err
+	}
+	if err := os.WriteFile(dest, buf.Bytes(), 0o644); err != nil {
+		return "", stats, err
+	}
+	env.saved(history.Entry{
+		Path:    dest,
+		Time:    start,
+		Mode:    "record",
+		Display: displayOf(env.src, rect),
+		Rect:    rectArray(rect),
+		Width:   rect.Dx(),
+		Height:  rect.Dy(),
+		Tags:    []string{"recording", opts.Format},
+	})
+	return dest, stats, nil
+}
+
+// startRecording starts recording rect in the background, tied to ctx. The saved path and
+// stats (including dropped frames) are logged when it ends.
+func startRecording(ctx context.Context, env captureEnv, rect image.Rectangle, outDir string, opts recordOptions) *backgroundJob {
+	return startJob(ctx, func(ctx context.Context) {
+		log.Printf("recording %dx%d at %d fps (stops after %v)", rect.Dx(), rect.Dy(), int(time.Second/opts.Interval()), opts.maxDuration)
+		path, stats, err := runRecording(ctx, env, rect, "", outDir, opts)
+		if err != nil {
+			log.Printf("recording failed (%s): %v", stats, err)
+			return
+		}
+		log.Printf("recording saved to %s: %s", path, stats)
+	})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/record"
+	"go-snip/internal/utils"
+)
+
+func TestRecordOptionsFor(t *testing.T) {
+	t.Parallel()
+
+	got := recordOptionsFor(config.Config{RecordFPS: 25, RecordMaxSeconds: 5, RecordFormat: "APNG"})
+	if got.FPS != 25 || got.maxDuration != 5*time.Second || got.Format != record.FormatAPNG {
+		t.Fatalf("recordOptionsFor() got=%+v", got)
+	}
+
+	got = recordOptionsFor(config.Config{RecordFPS: 120, RecordMaxSeconds: -1, RecordFormat: "webm"})
+	if got.FPS != 0 || got.maxDuration != record.DefaultMaxDuration || got.Format != record.FormatGIF {
+		t.Fatalf("recordOptionsFor(invalid) got=%+v", got)
+	}
+}
+
+func TestRunRecording_WritesGIFOfRect(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	outDir := t.TempDir()
+	rect := image.Rect(35, 5, 50, 20) // spans both displays
+	opts := recordOptions{Options: record.Options{FPS: 50}, maxDuration: 60 * time.Millisecond}
+
+	path, stats, err := runRecording(context.Background(), fakeEnv(src, image.Rectangle{}), rect, "", outDir, opts)
+	if err != nil {
+		t.Fatalf("runRecording() error: %v", err)
+	}
+	if want := filepath.Join(outDir, "20250102_030405_000 - recording.gif"); path != want {
+		t.Fatalf("path got=%q want=%q", path, want)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %q: %v", path, err)
+	}
+	defer f.Close()
+	anim, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() error: %v", err)
+	}
+	if len(anim.Image) == 0 || len(anim.Image) != stats.Frames {
+		t.Fatalf("frames got=%d want=%d (>0)", len(anim.Image), stats.Frames)
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != 15 || b.Dy() != 15 {
+		t.Fatalf("frame bounds=%v want 15x15", b)
+	}
+	// Each frame grabs only rect, not every display.
+	for _, r := range src.Captures() {
+		if r != rect {
+			t.Fatalf("captured %v, want only %v", r, rect)
+		}
+	}
+}
+
+func TestRecordingPath_FilenameTemplate(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30))
+	tmpl, err := utils.ParseFilenameTemplate("{mode}/{date:20060102}_{w}x{h}")
+	if err != nil {
+		t.Fatalf("ParseFilenameTemplate() error: %v", err)
+	}
+	opts := recordOptions{Options: record.Options{Format: record.FormatAPNG}, filename: tmpl}
+
+	got := recordingPath(src, "out", time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local), image.Rect(5, 5, 25, 15), opts)
+	if want := filepath.Join("out", "record", "20250102_20x10.png"); got != want {
+		t.Fatalf("recordingPath() got=%q want=%q", got, want)
+	}
+}
+
+func TestStartRecording_StopSavesFile(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 10, 10))
+	outDir := t.TempDir()
+	j := startRecording(context.Background(), fakeEnv(src, image.Rectangle{}), image.Rect(0, 0, 10, 10), outDir, recordOptions{Options: record.Options{FPS: 50}})
+	for len(src.Captures()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	j.stop()
+	if j.running() {
+		t.Fatalf("running() = true after stop")
+	}
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatalf("ReadDir() error: %v", err)
+	}
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), " - recording.gif") {
+		t.Fatalf("output files=%v want one recording", entries)
+	}
+}
+
+func TestRunCommand_Record(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	outDir := t.TempDir()
+	dest := filepath.Join(outDir, "clip.png")
+	var out bytes.Buffer
+	args := []string{"record", "--rect", "0,0,20,10", "--display", "1", "--fps", "50", "--for", "30ms", "-o", dest}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, outDir, config.Config{}, &out); err != nil {
+		t.Fatalf("runCommand() error: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != dest {
+		t.Fatalf("printed %q want=%q", got, dest)
+	}
+	// The .png extension selects APNG, whose first frame any PNG decoder reads.
+	img := decodePNG(t, dest)
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 10 {
+		t.Fatalf("first frame bounds=%v want 20x10", img.Bounds())
+	}
+	if got, want := img.At(0, 0), capture.FakePixel(1, 40, 0); got != want {
+		t.Fatalf("first frame pixel got=%v want=%v", got, want)
+	}
+
+	out.Reset()
+	args = []string{"record", "--for", "20ms", "--fps", "50"}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rect(10, 10, 30, 20)), args, outDir, config.Config{}, &out); err != nil {
+		t.Fatalf("runCommand(interactive) error: %v", err)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(out.String()), " - recording.gif") {
+		t.Fatalf("printed %q want a .gif recording", out.String())
+	}
+
+	bad := [][]string{
+		{"record", "--fps", "0"},
+		{"record", "--fps", "51"},
+		{"record", "--format", "mp4"},
+		{"record", "--display", "1"},
+		{"record", "--name", "x", "--rect", "0,0,1,1"},
+		{"record", "--for", "-1s"},
+	}
+	for _, args := range bad {
+		if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, outDir, config.Config{}, &out); !errors.Is(err, errUsage) {
+			t.Fatalf("runCommand(%v) error=%v want=%v", args, err, errUsage)
+		}
+	}
+	args = []string{"record", "--rect", "30,0,50,10", "--display", "1", "--for", "10ms"}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, outDir, config.Config{}, &out); err == nil || errors.Is(err, errUsage) {
+		t.Fatalf("runCommand(offscreen rect) error=%v, want a region error", err)
+	}
+}
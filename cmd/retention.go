@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-snip/internal/config"
+	"go-snip/internal/retention"
+	"go-snip/internal/utils"
+)
+
+// retentionOptions are the retention policy and which files it applies to.
+type retentionOptions struct {
+	policy retention.Policy
+	match  retention.Matcher
+}
+
+// retentionOptionsFor returns the retention settings in cfg. Invalid limits are logged and
+// disabled. Names from a filename template count as go-snip's only if the template has a
+// {date}, {time} or {timestamp} placeholder; otherwise they could be anyone's files.
+func retentionOptionsFor(cfg config.Config) retentionOptions {
+	var opts retentionOptions
+	if cfg.RetentionDays < 0 {
+		log.Printf("invalid retentionDays %d (no age limit)", cfg.RetentionDays)
+	} else {
+		opts.policy.MaxAge = time.Duration(cfg.RetentionDays) * 24 * time.Hour
+	}
+	if cfg.RetentionMaxMB < 0 {
+		log.Printf("invalid retentionMaxMB %d (no size limit)", cfg.RetentionMaxMB)
+	} else {
+		opts.policy.MaxBytes = int64(cfg.RetentionMaxMB) << 20
+	}
+	if cfg.RetentionMaxFiles < 0 {
+		log.Printf("invalid retentionMaxFiles %d (no count limit)", cfg.RetentionMaxFiles)
+	} else {
+		opts.policy.MaxFiles = cfg.RetentionMaxFiles
+	}
+
+	tmpl, err := utils.ParseFilenameTemplate(cfg.FilenameTemplate)
+	switch {
+	case err != nil:
+		log.Printf("invalid filename template (retention covers timestamp names only): %v", err)
+	case !tmpl.IsZero() && !tmpl.HasTime():
+		if !opts.policy.IsZero() {
+			log.Printf("filename template %q has no {date}, {time} or {timestamp}: retention covers timestamp names only", tmpl)
+		}
+		tmpl = utils.FilenameTemplate{}
+	case !tmpl.IsZero() && tmpl.Pattern() == nil:
+		if !opts.policy.IsZero() {
+			log.Printf("filename template %q names can't be told apart from other files ({name} or {host} next to a time placeholder, or alone in the file name): retention covers timestamp names only", tmpl)
+		}
+	}
+	opts.match = retention.NamingScheme(tmpl.Pattern())
+	return opts
+}
+
+// janitor enforces the retention policy in the background: once when started and again after
+// each kick. Kicks arriving while it works are coalesced into one more pass.
+type janitor struct {
+	job   *backgroundJob
+	kicks chan struct{}
+}
+
+// startJanitor starts a janitor for the output directory dir returns, or returns nil if opts
+// has no limits.
+func startJanitor(ctx context.Context, dir func() string, opts retentionOptions, now func() time.Time) *janitor {
+	if opts.policy.IsZero() {
+		return nil
+	}
+	j := &janitor{kicks: make(chan struct{}, 1)}
+	j.job = startJob(ctx, func(ctx context.Context) {
+		for {
+			res, err := retention.Enforce(dir(), opts.match, opts.policy, now())
+			if err != nil {
+				log.Printf("retention cleanup failed (deleted %s): %v", res, err)
+			} else if res.Files > 0 {
+				log.Printf("retention cleanup deleted %s", res)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-j.kicks:
+			}
+		}
+	})
+	return j
+}
+
+// rebindJanitor swaps the janitor for one enforcing the retention settings of newCfg, as
+// rebindHotkeys does for hotkeys. current is kept if those settings are the same as oldCfg's.
+func rebindJanitor(ctx context.Context, current *janitor, oldCfg, newCfg config.Config, dir func() string, now func() time.Time) *janitor {
+	if sameRetention(oldCfg, newCfg) {
+		return current
+	}
+	current.stop()
+	return startJanitor(ctx, dir, retentionOptionsFor(newCfg), now)
+}
+
+// sameRetention reports whether a and b have the same retention limits and naming scheme.
+func sameRetention(a, b config.Config) bool {
+	return a.RetentionDays == b.RetentionDays &&
+		a.RetentionMaxMB == b.RetentionMaxMB &&
+		a.RetentionMaxFiles == b.RetentionMaxFiles &&
+		a.FilenameTemplate == b.FilenameTemplate
+}
+
+// kick asks the janitor for another pass; it never blocks.
+func (j *janitor) kick() {
+	if j == nil {
+		return
+	}
+	select {
+	case j.kicks <- struct{}{}:
+	default:
+	}
+}
+
+// stop ends the janitor, waiting for a pass in progress.
+func (j *janitor) stop() {
+	if j == nil {
+		return
+	}
+	j.job.stop()
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/history"
+	"go-snip/internal/retention"
+)
+
+// writeAged creates outDir/rel modified age before the fake environment's now.
+func writeAged(t *testing.T, outDir, rel string, age time.Duration) string {
+	t.Helper()
+	path := filepath.Join(outDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatalf("write %q: %v", rel, err)
+	}
+	mtime := fakeEnv(nil, image.Rectangle{}).now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("chtimes %q: %v", rel, err)
+	}
+	return path
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestRetentionOptionsFor(t *testing.T) {
+	t.Parallel()
+
+	opts := retentionOptionsFor(config.Config{RetentionDays: 7, RetentionMaxMB: 2, RetentionMaxFiles: -1, FilenameTemplate: "{date}/{mode}_{counter}"})
+	if want := (retention.Policy{MaxAge: 7 * 24 * time.Hour, MaxBytes: 2 << 20}); opts.policy != want {
+		t.Fatalf("policy got=%+v want=%+v", opts.policy, want)
+	}
+	if !opts.match("2025-01-02/area_001.png") || !opts.match("20250102_030405_000.png") {
+		t.Fatalf("matcher rejects go-snip names")
+	}
+
+	// Without a time placeholder, template names could be anyone's files.
+	opts = retentionOptionsFor(config.Config{RetentionDays: 7, FilenameTemplate: "{name}"})
+	if opts.match("holiday.png") {
+		t.Fatalf("matcher accepts names of a template without a time placeholder")
+	}
+
+	// Neither may free text next to a time placeholder, a free-text file name in a date folder
+	// or a shorter run of digits pass for a template name.
+	for _, tc := range []struct {
+		template string
+		mine     []string
+		others   []string
+	}{
+		{template: "{time}{name}", others: []string{"1.png", "2019 taxes.png", "150405 taxes.png"}},
+		{template: "{date}/{name}", others: []string{"2020-01-01/wedding.jpg"}},
+		{template: "{date:2006}/{name}", others: []string{"2019/holiday.png"}},
+		{template: "{date} {time} - {name}", mine: []string{"2025-01-02 030405 - login bug.png", "2025-01-02 030405.png"}, others: []string{"2019 taxes.png", "2025-01-02 0304 - x.png", "2025-1-02 030405.png"}},
+	} {
+		opts := retentionOptionsFor(config.Config{RetentionDays: 7, FilenameTemplate: tc.template})
+		for _, name := range tc.mine {
+			if !opts.match(name) {
+				t.Fatalf("template %q: matcher rejects %q", tc.template, name)
+			}
+		}
+		for _, name := range tc.others {
+			if opts.match(name) {
+				t.Fatalf("template %q: matcher accepts %q", tc.template, name)
+			}
+		}
+	}
+}
+
+func TestJanitor_CleansOnStartAndAfterSave(t *testing.T) {
+	t.Parallel()
+
+	outDir := t.TempDir()
+	old := writeAged(t, outDir, "20241201_000000_000.png", 32*24*time.Hour)
+	mine := writeAged(t, outDir, "holiday.png", 400*24*time.Hour)
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 20, 10))
+	env := fakeEnv(src, image.Rectangle{})
+	opts := retentionOptionsFor(config.Config{RetentionDays: 30, RetentionMaxFiles: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	j := startJanitor(ctx, func() string { return outDir }, opts, env.now)
+	defer j.stop()
+	for exists(old) {
+		time.Sleep(time.Millisecond)
+	}
+	if !exists(mine) {
+		t.Fatalf("janitor deleted a file go-snip didn't name")
+	}
+
+	// Three fresh captures: the oldest goes once the janitor has run after the last save.
+	env.afterSave = func(history.Entry) { j.kick() }
+	var paths []string
+	for i := 0; i < 3; i++ {
+		path, _, err := handleFull(env, outDir, capture.DisplayPolicy{Mode: capture.DisplayPrimary}, captureOptions{})
+		if err != nil {
+			t.Fatalf("handleFull() error: %v", err)
+		}
+		mtime := env.now().Add(time.Duration(i-3) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	j.kick()
+	for exists(paths[0]) {
+		time.Sleep(time.Millisecond)
+	}
+	if !exists(paths[1]) || !exists(paths[2]) || !exists(mine) {
+		t.Fatalf("janitor deleted more than the oldest capture")
+	}
+
+	if startJanitor(ctx, func() string { return outDir }, retentionOptionsFor(config.Config{}), env.now) != nil {
+		t.Fatalf("startJanitor() without limits returned a janitor")
+	}
+	var none *janitor
+	none.kick()
+	none.stop()
+}
+
+func TestRebindJanitor_FollowsRetentionSettings(t *testing.T) {
+	t.Parallel()
+
+	outDir := t.TempDir()
+	old := writeAged(t, outDir, "20241201_000000_000.png", 32*24*time.Hour)
+	env := fakeEnv(nil, image.Rectangle{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := func() string { return outDir }
+
+	// Limits added in the settings start a janitor, which cleans up right away.
+	none := config.Config{}
+	limited := config.Config{RetentionDays: 30}
+	j := rebindJanitor(ctx, nil, none, limited, dir, env.now)
+	if j == nil {
+		t.Fatalf("rebindJanitor() with new limits returned no janitor")
+	}
+	for exists(old) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Unrelated changes keep it; removing the limits stops it.
+	changed := limited
+	changed.DelaySeconds = 5
+	if got := rebindJanitor(ctx, j, limited, changed, dir, env.now); got != j {
+		t.Fatalf("rebindJanitor() replaced the janitor for an unrelated change")
+	}
+	if got := rebindJanitor(ctx, j, changed, none, dir, env.now); got != nil {
+		t.Fatalf("rebindJanitor() without limits returned a janitor")
+	}
+	select {
+	case <-j.job.done:
+	default:
+		t.Fatalf("rebindJanitor() left the old janitor running")
+	}
+}
+
+func TestRunCommand_Cleanup(t *testing.T) {
+	t.Parallel()
+
+	outDir := t.TempDir()
+	older := writeAged(t, outDir, "2024/11/01/20241101_000000_000.png", 60*24*time.Hour)
+	old := writeAged(t, outDir, "timelapse_20241201_000000/frame-00001.png", 32*24*time.Hour)
+	fresh := writeAged(t, outDir, "20250101_000000_000 - notes.jpg", 24*time.Hour)
+	mine := writeAged(t, outDir, "holiday.png", 400*24*time.Hour)
+	env := fakeEnv(nil, image.Rectangle{})
+	cfg := config.Config{RetentionDays: 30}
+
+	var out bytes.Buffer
+	if err := runCommand(t.Context(), env, []string{"cleanup", "--dry-run"}, outDir, cfg, &out); err != nil {
+		t.Fatalf("cleanup --dry-run error: %v", err)
+	}
+	if got, want := strings.Fields(out.String()), []string{older, old}; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("cleanup --dry-run printed %v want=%v", got, want)
+	}
+	for _, path := range []string{older, old, fresh, mine} {
+		if !exists(path) {
+			t.Fatalf("cleanup --dry-run deleted %q", path)
+		}
+	}
+
+	out.Reset()
+	if err := runCommand(t.Context(), env, []string{"cleanup", "--max-files", "0"}, outDir, cfg, &out); err != nil {
+		t.Fatalf("cleanup error: %v", err)
+	}
+	if exists(older) || exists(old) || !exists(fresh) || !exists(mine) {
+		t.Fatalf("cleanup kept/deleted the wrong files")
+	}
+	if exists(filepath.Join(outDir, "2024")) || exists(filepath.Dir(old)) {
+		t.Fatalf("cleanup left emptied folders behind")
+	}
+
+	out.Reset()
+	if err := runCommand(t.Context(), env, []string{"cleanup", "--days", "0"}, outDir, cfg, &out); !errors.Is(err, errUsage) {
+		t.Fatalf("cleanup without limits error=%v want=%v", err, errUsage)
+	}
+}
+
+func TestSaveCapture_DateFolders(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 20, 10))
+	outDir := t.TempDir()
+	opts := captureOptionsFor(config.Config{DateFolders: true})
+
+	path, _, err := handleFull(fakeEnv(src, image.Rectangle{}), outDir, capture.DisplayPolicy{Mode: capture.DisplayPrimary}, opts)
+	if err != nil {
+		t.Fatalf("handleFull() error: %v", err)
+	}
+	if want := filepath.Join(outDir, "2025", "01", "02", "20250102_030405_000.png"); path != want {
+		t.Fatalf("path got=%q want=%q", path, want)
+	}
+	if !retentionOptionsFor(config.Config{}).match("2025/01/02/20250102_030405_000.png") {
+		t.Fatalf("retention doesn't recognise captures in date folders")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"time"
+
+	"go-snip/internal/config"
+	"go-snip/internal/stitch"
+)
+
+// Scrolling capture defaults used when the config doesn't set them.
+const (
+	defaultScrollInterval    = 250 * time.Millisecond
+	defaultScrollMaxDuration = 2 * time.Minute
+)
+
+// scrollOptions are the settings of one scrolling capture.
+type scrollOptions struct {
+	interval time.Duration
+	// maxDuration stops the capture if it isn't stopped earlier; zero runs until ctx is done.
+	maxDuration time.Duration
+	// idle stops the capture once no new rows arrived for that long; zero disables it.
+	idle time.Duration
+}
+
+// scrollOptionsFor returns the scrolling capture settings in cfg. Invalid values are logged and
+// replaced by the defaults.
+func scrollOptionsFor(cfg config.Config) scrollOptions {
+	opts := scrollOptions{interval: defaultScrollInterval, maxDuration: defaultScrollMaxDuration}
+	switch {
+	case cfg.ScrollIntervalMillis < 0:
+		log.Printf("invalid scrollIntervalMillis %d (using %v)", cfg.ScrollIntervalMillis, opts.interval)
+	case cfg.ScrollIntervalMillis > 0:
+		opts.interval = time.Duration(cfg.ScrollIntervalMillis) * time.Millisecond
+	}
+	switch {
+	case cfg.ScrollMaxSeconds < 0:
+		log.Printf("invalid scrollMaxSeconds %d (using %v)", cfg.ScrollMaxSeconds, opts.maxDuration)
+	case cfg.ScrollMaxSeconds > 0:
+		opts.maxDuration = time.Duration(cfg.ScrollMaxSeconds) * time.Second
+	}
+	if cfg.ScrollIdleSeconds < 0 {
+		log.Printf("invalid scrollIdleSeconds %d (never stopping when idle)", cfg.ScrollIdleSeconds)
+	} else {
+		opts.idle = time.Duration(cfg.ScrollIdleSeconds) * time.Second
+	}
+	return opts
+}
+
+// runScroll captures the virtual-desktop rect every opts.interval while the user scrolls it and
+// stitches the frames, until ctx is done, opts.maxDuration has passed or nothing new scrolled
+// into view for opts.idle. Stopping is the normal end, so only a failed capture is an error.
+// The returned Stitcher holds the image and the frame counts.
+func runScroll(ctx context.Context, env captureEnv, rect image.Rectangle, opts scrollOptions) (*stitch.Stitcher, error) {
+	if opts.interval <= 0 {
+		return nil, fmt.Errorf("scroll interval %v must be positive", opts.interval)
+	}
+	if opts.maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.maxDuration)
+		defer cancel()
+	}
+
+	s := &stitch.Stitcher{}
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	lastGrowth := time.Now()
+	for {
+		frame, err := env.src.CaptureRect(rect)
+		if err != nil {
+			return s, err
+		}
+		grew, err := s.Add(frame)
+		switch {
+		case errors.Is(err, stitch.ErrNoOverlap):
+			// Scrolled too far between two frames; the next one may overlap again.
+		case err != nil:
+			return s, err
+		case grew > 0:
+			lastGrowth = time.Now()
+		}
+		if opts.idle > 0 && time.Since(lastGrowth) >= opts.idle {
+			return s, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return s, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrollSummary describes a finished scrolling capture for logs.
+func scrollSummary(s *stitch.Stitcher) string {
+	out := fmt.Sprintf("%d frames", s.Frames)
+	if img := s.Image(); img != nil {
+		out += fmt.Sprintf(", %dx%d", img.Rect.Dx(), img.Rect.Dy())
+	}
+	if s.Unmatched > 0 {
+		out += fmt.Sprintf(", %d skipped without overlap (scroll more slowly)", s.Unmatched)
+	}
+	return out
+}
+
+// startScroll starts a scrolling capture of rect in the background, tied to ctx. When it ends
+// the stitched image goes through finishCapture like any other capture.
+func startScroll(ctx context.Context, env captureEnv, rect image.Rectangle, outDir string, scroll scrollOptions, opts captureOptions) *backgroundJob {
+	return startJob(ctx, func(ctx context.Context) {
+		log.Printf("scrolling capture started: scroll the selected area, press the hotkey again to finish")
+		s, err := runScroll(ctx, env, rect, scroll)
+		if err != nil {
+			log.Printf("scrolling capture failed: %v", err)
+			return
+		}
+		opts.mode = "scroll"
+		opts.locate(env.src, rect)
+		path, cancelled, err := finishCapture(env, s.Image(), outDir, opts)
+		switch {
+		case cancelled:
+		case err != nil:
+			log.Printf("scrolling capture failed: %v", err)
+		case path == "":
+			log.Printf("scrolling capture copied to the clipboard: %s", scrollSummary(s))
+		default:
+			log.Printf("scrolling capture saved to %s: %s", path, scrollSummary(s))
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-snip/internal/config"
+)
+
+// scrollingSource is a one-display Source showing a tall page that scrolls down by step rows on
+// every capture until it reaches the end.
+type scrollingSource struct {
+	mu      sync.Mutex
+	display image.Rectangle
+	page    *image.RGBA
+	step    int
+	offset  int
+	// atEnd counts captures of the end of the page.
+	atEnd int
+	// captures are the rectangles passed to CaptureRect.
+	captures []image.Rectangle
+}
+
+func newScrollingSource(display image.Rectangle, pageHeight, step int) *scrollingSource {
+	page := image.NewRGBA(image.Rect(0, 0, display.Dx(), pageHeight))
+	for y := 0; y < pageHeight; y++ {
+		for x := 0; x < display.Dx(); x++ {
+			page.SetRGBA(x, y, color.RGBA{R: uint8(x * 3), G: uint8(y), B: uint8(y >> 8), A: 255})
+		}
+	}
+	return &scrollingSource{display: display, page: page, step: step}
+}
+
+func (s *scrollingSource) NumDisplays() int { return 1 }
+
+func (s *scrollingSource) DisplayBounds(i int) image.Rectangle {
+	if i != 0 {
+		return image.Rectangle{}
+	}
+	return s.display
+}
+
+func (s *scrollingSource) CaptureRect(r image.Rectangle) (image.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captures = append(s.captures, r)
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), s.page, r.Min.Sub(s.display.Min).Add(image.Pt(0, s.offset)), draw.Src)
+	end := s.page.Rect.Dy() - s.display.Dy()
+	if s.offset == end {
+		s.atEnd++
+	}
+	s.offset = min(s.offset+s.step, end)
+	return dst, nil
+}
+
+// pageRows returns the part of the page the stitched capture of rect should equal.
+func (s *scrollingSource) pageRows(rect image.Rectangle) *image.RGBA {
+	scrolled := s.page.Rect.Dy() - s.display.Dy()
+	r := rect.Sub(s.display.Min)
+	r.Max.Y += scrolled
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), s.page, r.Min, draw.Src)
+	return dst
+}
+
+func sameRGBA(a, b image.Image) bool {
+	if a.Bounds().Size() != b.Bounds().Size() {
+		return false
+	}
+	ab, bb := a.Bounds(), b.Bounds()
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			if color.RGBAModel.Convert(a.At(ab.Min.X+x, ab.Min.Y+y)) != color.RGBAModel.Convert(b.At(bb.Min.X+x, bb.Min.Y+y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestScrollOptionsFor(t *testing.T) {
+	t.Parallel()
+
+	got := scrollOptionsFor(config.Config{ScrollIntervalMillis: 100, ScrollMaxSeconds: 30, ScrollIdleSeconds: 4})
+	if got.interval != 100*time.Millisecond || got.maxDuration != 30*time.Second || got.idle != 4*time.Second {
+		t.Fatalf("scrollOptionsFor() got=%+v", got)
+	}
+	got = scrollOptionsFor(config.Config{ScrollIntervalMillis: -1, ScrollMaxSeconds: -1, ScrollIdleSeconds: -1})
+	if got.interval != defaultScrollInterval || got.maxDuration != defaultScrollMaxDuration || got.idle != 0 {
+		t.Fatalf("scrollOptionsFor(invalid) got=%+v", got)
+	}
+}
+
+func TestRunScroll_StitchesUntilIdle(t *testing.T) {
+	t.Parallel()
+
+	src := newScrollingSource(image.Rect(100, 0, 140, 80), 300, 9)
+	rect := image.Rect(105, 10, 125, 60)
+	opts := scrollOptions{interval: time.Millisecond, maxDuration: 5 * time.Second, idle: 30 * time.Millisecond}
+
+	s, err := runScroll(context.Background(), fakeEnv(src, image.Rectangle{}), rect, opts)
+	if err != nil {
+		t.Fatalf("runScroll() error: %v", err)
+	}
+	if !sameRGBA(s.Image(), src.pageRows(rect)) {
+		t.Fatalf("stitched %v, want the region over the whole scrolled page %v", s.Image().Rect, src.pageRows(rect).Rect)
+	}
+	if s.Unchanged == 0 {
+		t.Fatalf("stopped before the page stopped scrolling (frames=%d)", s.Frames)
+	}
+	for _, r := range src.captures {
+		if r != rect {
+			t.Fatalf("captured %v, want only the region %v", r, rect)
+		}
+	}
+}
+
+func TestStartScroll_StopSavesStitchedImage(t *testing.T) {
+	t.Parallel()
+
+	src := newScrollingSource(image.Rect(0, 0, 20, 30), 60, 3)
+	outDir := t.TempDir()
+	j := startScroll(context.Background(), fakeEnv(src, image.Rectangle{}), src.display, outDir, scrollOptions{interval: time.Millisecond}, captureOptionsFor(config.Config{}))
+	for {
+		src.mu.Lock()
+		done := src.atEnd > 0
+		src.mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	j.stop()
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("output files=%v err=%v want one capture", entries, err)
+	}
+	img := decodePNG(t, filepath.Join(outDir, entries[0].Name()))
+	if !sameRGBA(img, src.pageRows(src.display)) {
+		t.Fatalf("saved image %v does not match the scrolled page", img.Bounds())
+	}
+}
+
+func TestRunCommand_CaptureScroll(t *testing.T) {
+	t.Parallel()
+
+	src := newScrollingSource(image.Rect(0, 0, 30, 40), 120, 5)
+	outDir := t.TempDir()
+	var out bytes.Buffer
+	args := []string{"capture", "scroll", "--rect", "0,0,30,40", "--every", "1ms", "--idle", "20ms"}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, outDir, config.Config{}, &out); err != nil {
+		t.Fatalf("runCommand() error: %v", err)
+	}
+	img := decodePNG(t, strings.TrimSpace(out.String()))
+	if !sameRGBA(img, src.pageRows(image.Rect(0, 0, 30, 40))) {
+		t.Fatalf("saved image %v does not match the scrolled page", img.Bounds())
+	}
+
+	for _, bad := range [][]string{
+		{"capture", "scroll", "--every", "0s"},
+		{"capture", "scroll", "--display", "0"},
+		{"capture", "scroll", "--name", "x", "--rect", "0,0,1,1"},
+	} {
+		if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), bad, outDir, config.Config{}, &out); !errors.Is(err, errUsage) {
+			t.Fatalf("runCommand(%v) error=%v want=%v", bad, err, errUsage)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/history"
+	"go-snip/internal/timelapse"
+	"go-snip/internal/utils"
+)
+
+// timelapseOptions are the settings of one timelapse run.
+type timelapseOptions struct {
+	interval time.Duration
+	// duration is how long the run lasts; zero runs until ctx is cancelled.
+	duration      time.Duration
+	policy        capture.DisplayPolicy
+	region        string
+	skipIdentical bool
+	save          utils.SaveOptions
+	// dateFolders puts the session folder into outDir/YYYY/MM/DD.
+	dateFolders bool
+}
+
+// timelapseOptionsFor returns the timelapse settings in cfg. Invalid values are logged and
+// replaced by the defaults.
+func timelapseOptionsFor(cfg config.Config) timelapseOptions {
+	opts := timelapseOptions{
+		interval:      timelapse.DefaultInterval,
+		policy:        displayPolicy(cfg),
+		region:        cfg.TimelapseRegion,
+		skipIdentical: cfg.TimelapseSkipIdentical,
+		save:          captureOptionsFor(cfg).save,
+		dateFolders:   cfg.DateFolders,
+	}
+	switch {
+	case cfg.TimelapseSeconds < 0:
+		log.Printf("invalid timelapseSeconds %d (using %v)", cfg.TimelapseSeconds, opts.interval)
+	case cfg.TimelapseSeconds > 0:
+		opts.interval = time.Duration(cfg.TimelapseSeconds) * time.Second
+	}
+	if cfg.TimelapseMinutes < 0 {
+		log.Printf("invalid timelapseMinutes %d (running until stopped)", cfg.TimelapseMinutes)
+	} else {
+		opts.duration = time.Duration(cfg.TimelapseMinutes) * time.Minute
+	}
+	return opts
+}
+
+// timelapseFrame returns the capture function of a timelapse: the named region if opts has
+// one (validated against the displays once, up front), else the display policy.
+func timelapseFrame(env captureEnv, regions []config.NamedRegion, opts timelapseOptions) (func() (image.Image, error), error) {
+	if opts.region != "" {
+		rect, err := namedRegionRect(env.src, opts.region, regions)
+		if err != nil {
+			return nil, err
+		}
+		return func() (image.Image, error) { return env.src.CaptureRect(rect) }, nil
+	}
+	if err := opts.policy.Validate(); err != nil {
+		return nil, err
+	}
+	return func() (image.Image, error) {
+		img, _, err := capture.CapturePolicy(env.src, opts.policy)
+		return img, err
+	}, nil
+}
+
+// runTimelapse captures frames into a new session folder of outDir every opts.interval until
+// ctx is cancelled or opts.duration has passed.
+func runTimelapse(ctx context.Context, env captureEnv, regions []config.NamedRegion, outDir string, opts timelapseOptions) (timelapse.Stats, error) {
+	frame, err := timelapseFrame(env, regions, opts)
+	if err != nil {
+		return timelapse.Stats{}, err
+	}
+	if opts.interval <= 0 {
+		return timelapse.Stats{}, fmt.Errorf("timelapse interval %v must be positive", opts.interval)
+	}
+	if opts.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.duration)
+		defer cancel()
+	}
+
+	t := env.now()
+	if opts.dateFolders {
+		outDir = utils.DateDir(outDir, t)
+	}
+	exists := func(p string) bool {
+		_, statErr := os.Stat(p)
+		return statErr == nil
+	}
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	s := &timelapse.Session{
+		Dir:           timelapse.SessionDir(outDir, t, exists),
+		Capture:       frame,
+		SkipIdentical: opts.skipIdentical,
+		Save:          opts.save,
+	}
+	stats, err := s.Run(ctx, ticker.C)
+	if stats.Saved > 0 {
+		env.saved(history.Entry{Path: stats.Dir, Time: t, Mode: "timelapse", Display: -1, Tags: []string{"timelapse"}})
+	}
+	return stats, err
+}
+
+// startTimelapse starts a timelapse tied to ctx. Its stats are logged when it ends, whether by
+// duration, stop or ctx.
+func startTimelapse(ctx context.Context, env captureEnv, regions []config.NamedRegion, outDir string, opts timelapseOptions) *backgroundJob {
+	return startJob(ctx, func(ctx context.Context) {
+		log.Printf("timelapse started: every %v into %s", opts.interval, outDir)
+		stats, err := runTimelapse(ctx, env, regions, outDir, opts)
+		if err != nil {
+			log.Printf("timelapse failed (%s): %v", stats, err)
+			return
+		}
+		log.Printf("timelapse stopped: %s", stats)
+	})
+}
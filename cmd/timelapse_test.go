@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-snip/internal/capture"
+	"go-snip/internal/config"
+	"go-snip/internal/timelapse"
+)
+
+func TestTimelapseOptionsFor(t *testing.T) {
+	t.Parallel()
+
+	got := timelapseOptionsFor(config.Config{TimelapseSeconds: 2, TimelapseMinutes: 10, TimelapseRegion: "panel", TimelapseSkipIdentical: true})
+	if got.interval != 2*time.Second || got.duration != 10*time.Minute || got.region != "panel" || !got.skipIdentical {
+		t.Fatalf("timelapseOptionsFor() got=%+v", got)
+	}
+
+	got = timelapseOptionsFor(config.Config{TimelapseSeconds: -1, TimelapseMinutes: -1})
+	if got.interval != timelapse.DefaultInterval || got.duration != 0 {
+		t.Fatalf("timelapseOptionsFor(invalid) interval=%v duration=%v want=%v, 0", got.interval, got.duration, timelapse.DefaultInterval)
+	}
+}
+
+func TestRunTimelapse_SkipsIdenticalFramesOfRegion(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30))
+	outDir := t.TempDir()
+	opts := timelapseOptions{interval: time.Millisecond, duration: 30 * time.Millisecond, region: "grafana-panel", skipIdentical: true}
+
+	stats, err := runTimelapse(context.Background(), fakeEnv(src, image.Rectangle{}), testRegions(), outDir, opts)
+	if err != nil {
+		t.Fatalf("runTimelapse() error: %v", err)
+	}
+	// The fake desktop never changes: one frame, the rest skipped.
+	if stats.Saved != 1 || stats.Skipped == 0 {
+		t.Fatalf("runTimelapse() stats=%+v want saved=1 and some skipped", stats)
+	}
+	if want := filepath.Join(outDir, "timelapse_20250102_030405"); stats.Dir != want {
+		t.Fatalf("session dir got=%q want=%q", stats.Dir, want)
+	}
+	img := decodePNG(t, filepath.Join(stats.Dir, "frame-00001.png"))
+	if img.Bounds().Dx() != 15 || img.Bounds().Dy() != 15 {
+		t.Fatalf("frame bounds=%v want 15x15", img.Bounds())
+	}
+	// Frames grab only the region, not every display.
+	rect, err := namedRegionRect(src, "grafana-panel", testRegions())
+	if err != nil {
+		t.Fatalf("namedRegionRect() error: %v", err)
+	}
+	for _, r := range src.Captures() {
+		if r != rect {
+			t.Fatalf("captured %v, want only the region %v", r, rect)
+		}
+	}
+}
+
+func TestRunTimelapse_SameSecondGetsNewFolder(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30))
+	outDir := t.TempDir()
+	opts := timelapseOptions{interval: time.Hour, duration: 10 * time.Millisecond}
+
+	// The fake clock starts both sessions in the same second.
+	var dirs []string
+	for range 2 {
+		stats, err := runTimelapse(context.Background(), fakeEnv(src, image.Rectangle{}), nil, outDir, opts)
+		if err != nil || stats.Saved != 1 {
+			t.Fatalf("runTimelapse() stats=%+v err=%v", stats, err)
+		}
+		dirs = append(dirs, stats.Dir)
+	}
+	want := []string{filepath.Join(outDir, "timelapse_20250102_030405"), filepath.Join(outDir, "timelapse_20250102_030405_001")}
+	if dirs[0] != want[0] || dirs[1] != want[1] {
+		t.Fatalf("session dirs got=%v want=%v", dirs, want)
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "frame-00001.png")); err != nil {
+			t.Fatalf("frame of %s: %v", dir, err)
+		}
+	}
+}
+
+func TestRunTimelapse_UnknownRegion(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 40, 30))
+	opts := timelapseOptions{interval: time.Millisecond, region: "nope"}
+	if _, err := runTimelapse(context.Background(), fakeEnv(src, image.Rectangle{}), nil, t.TempDir(), opts); !errors.Is(err, errUnknownRegion) {
+		t.Fatalf("runTimelapse() error=%v want=%v", err, errUnknownRegion)
+	}
+	if n := len(src.Captures()); n != 0 {
+		t.Fatalf("captured %d times, want 0", n)
+	}
+}
+
+func TestStartTimelapse_StopWaitsForRun(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 10, 10))
+	outDir := t.TempDir()
+	r := startTimelapse(context.Background(), fakeEnv(src, image.Rectangle{}), nil, outDir, timelapseOptions{interval: time.Millisecond})
+	if !r.running() {
+		t.Fatalf("running() = false right after start")
+	}
+	r.stop()
+	if r.running() {
+		t.Fatalf("running() = true after stop")
+	}
+	if len(src.Captures()) == 0 {
+		t.Fatalf("no frame captured")
+	}
+
+	var none *backgroundJob
+	none.stop()
+	if none.running() {
+		t.Fatalf("nil job is running")
+	}
+}
+
+func TestRunCommand_Timelapse(t *testing.T) {
+	t.Parallel()
+
+	src := capture.NewFakeSource(image.Rect(0, 0, 10, 10))
+	outDir := t.TempDir()
+	var out bytes.Buffer
+	args := []string{"timelapse", "--every", "1ms", "--for", "20ms", "--skip-identical", "--display", "0"}
+	if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), args, outDir, config.Config{}, &out); err != nil {
+		t.Fatalf("runCommand() error: %v", err)
+	}
+	dir := strings.TrimSpace(out.String())
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir(%q) error: %v", dir, err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "frame-00001.png" {
+		t.Fatalf("session files=%v want [frame-00001.png]", entries)
+	}
+
+	for _, bad := range [][]string{{"timelapse", "--every", "0s"}, {"timelapse", "--display", "0", "--name", "x"}} {
+		if err := runCommand(t.Context(), fakeEnv(src, image.Rectangle{}), bad, outDir, config.Config{}, &out); !errors.Is(err, errUsage) {
+			t.Fatalf("runCommand(%v) error=%v want=%v", bad, err, errUsage)
+		}
+	}
+}
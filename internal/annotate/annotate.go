@@ -0,0 +1,123 @@
+// Package annotate draws annotations (boxes, arrows, pen strokes, text labels, highlights and
+// numbered step markers) onto captures. It has no UI dependencies: the post-capture prompt uses
+// it both for its live preview and to flatten the annotations before saving.
+package annotate
+
+import (
+	"image"
+	"image/color"
+
+	"go-snip/internal/redact"
+)
+
+// Annotation tools for Annotation.Tool.
+const (
+	// ToolRect outlines the rectangle spanned by the first and last point.
+	ToolRect = "rect"
+	// ToolArrow draws an arrow from the first point to the last.
+	ToolArrow = "arrow"
+	// ToolPen draws a freehand stroke through every point.
+	ToolPen = "pen"
+	// ToolText draws Text with its top-left corner at the first point.
+	ToolText = "text"
+	// ToolHighlighter draws a wide, translucent freehand stroke through every point.
+	ToolHighlighter = "highlighter"
+	// ToolStep draws a filled circle centred on the first point, labelled with Step.
+	ToolStep = "step"
+
+	// The redaction tools irreversibly hide the rectangle spanned by the first and last point
+	// (see package redact). Width is the block size or blur sigma; ToolFill uses Color.
+	ToolPixelate = redact.ModePixelate
+	ToolBlur     = redact.ModeBlur
+	ToolFill     = redact.ModeFill
+)
+
+// Tools returns every tool in toolbar order.
+func Tools() []string {
+	return []string{ToolRect, ToolArrow, ToolPen, ToolText, ToolHighlighter, ToolStep, ToolPixelate, ToolBlur, ToolFill}
+}
+
+// IsRedaction reports whether tool is one of the redaction tools.
+func IsRedaction(tool string) bool {
+	return tool == ToolPixelate || tool == ToolBlur || tool == ToolFill
+}
+
+// Annotation is one drawn shape. Points are in the pixel coordinates of the annotated image.
+type Annotation struct {
+	Tool   string
+	Points []image.Point
+	// Color is the tool colour; the highlighter makes it translucent.
+	Color color.NRGBA
+	// Width is the stroke width in pixels, or the font size for ToolText and ToolStep.
+	// Zero uses the tool's default.
+	Width int
+	// Text is the label of a ToolText annotation.
+	Text string
+	// Step is the number shown by a ToolStep annotation.
+	Step int
+}
+
+// Stack is an undoable list of annotations.
+type Stack struct {
+	items  []Annotation
+	undone []Annotation
+}
+
+// Push appends a. Anything undone is discarded, as in any editor.
+func (s *Stack) Push(a Annotation) {
+	s.items = append(s.items, a)
+	s.undone = s.undone[:0]
+}
+
+// Undo removes the last annotation. It reports false if there is nothing to undo.
+func (s *Stack) Undo() bool {
+	if len(s.items) == 0 {
+		return false
+	}
+	last := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	s.undone = append(s.undone, last)
+	return true
+}
+
+// Redo restores the last undone annotation. It reports false if there is nothing to redo.
+func (s *Stack) Redo() bool {
+	if len(s.undone) == 0 {
+		return false
+	}
+	last := s.undone[len(s.undone)-1]
+	s.undone = s.undone[:len(s.undone)-1]
+	s.items = append(s.items, last)
+	return true
+}
+
+// CanUndo reports whether Undo would remove an annotation.
+func (s *Stack) CanUndo() bool {
+	return len(s.items) > 0
+}
+
+// CanRedo reports whether Redo would restore an annotation.
+func (s *Stack) CanRedo() bool {
+	return len(s.undone) > 0
+}
+
+// Items returns the current annotations in drawing order.
+func (s *Stack) Items() []Annotation {
+	return append([]Annotation(nil), s.items...)
+}
+
+// Len returns the number of current annotations.
+func (s *Stack) Len() int {
+	return len(s.items)
+}
+
+// NextStep returns the number for a new step marker: one more than the highest current step.
+func (s *Stack) NextStep() int {
+	n := 0
+	for _, a := range s.items {
+		if a.Tool == ToolStep && a.Step > n {
+			n = a.Step
+		}
+	}
+	return n + 1
+}
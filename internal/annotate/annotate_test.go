@@ -0,0 +1,55 @@
+package annotate
+
+import "testing"
+
+func TestStack_UndoRedo(t *testing.T) {
+	t.Parallel()
+
+	var s Stack
+	s.Push(Annotation{Tool: ToolRect})
+	s.Push(Annotation{Tool: ToolArrow})
+
+	if !s.Undo() || s.Len() != 1 {
+		t.Fatalf("after Undo Len()=%d want 1", s.Len())
+	}
+	if !s.Redo() || s.Len() != 2 || s.Items()[1].Tool != ToolArrow {
+		t.Fatalf("after Redo items=%+v", s.Items())
+	}
+	if s.CanRedo() || s.Redo() {
+		t.Fatalf("Redo() with nothing undone reported true")
+	}
+
+	// A new annotation drops the redo history.
+	s.Undo()
+	s.Push(Annotation{Tool: ToolPen})
+	if s.Redo() {
+		t.Fatalf("Redo() after Push reported true")
+	}
+	if got := s.Items(); len(got) != 2 || got[1].Tool != ToolPen {
+		t.Fatalf("items=%+v", got)
+	}
+
+	s.Undo()
+	s.Undo()
+	if s.CanUndo() || s.Undo() {
+		t.Fatalf("Undo() on empty stack reported true")
+	}
+}
+
+func TestStack_NextStep(t *testing.T) {
+	t.Parallel()
+
+	var s Stack
+	if got := s.NextStep(); got != 1 {
+		t.Fatalf("NextStep()=%d want 1", got)
+	}
+	s.Push(Annotation{Tool: ToolStep, Step: 1})
+	s.Push(Annotation{Tool: ToolStep, Step: 2})
+	if got := s.NextStep(); got != 3 {
+		t.Fatalf("NextStep()=%d want 3", got)
+	}
+	s.Undo()
+	if got := s.NextStep(); got != 2 {
+		t.Fatalf("NextStep() after Undo=%d want 2", got)
+	}
+}
@@ -0,0 +1,275 @@
+package annotate
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"go-snip/internal/redact"
+)
+
+// highlighterAlpha is the opacity of highlighter strokes, whatever the tool colour.
+const highlighterAlpha = 96
+
+// DefaultWidth returns the width used for tool when Annotation.Width is zero.
+func DefaultWidth(tool string) int {
+	switch tool {
+	case ToolArrow:
+		return 4
+	case ToolHighlighter:
+		return 18
+	case ToolText:
+		return 24
+	case ToolStep:
+		return 18
+	}
+	return 3
+}
+
+// Flatten returns a copy of img with anns drawn on top, in order. The copy has img's bounds.
+func Flatten(img image.Image, anns []Annotation) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	for _, a := range anns {
+		Draw(dst, a)
+	}
+	return dst
+}
+
+// Draw draws a onto dst. Annotations without points, text or a known tool are ignored.
+func Draw(dst *image.RGBA, a Annotation) {
+	if len(a.Points) == 0 {
+		return
+	}
+	w := a.Width
+	if w <= 0 {
+		w = DefaultWidth(a.Tool)
+	}
+	first, last := a.Points[0], a.Points[len(a.Points)-1]
+	r := float64(w) / 2
+
+	switch a.Tool {
+	case ToolRect:
+		x0, y0, x1, y1 := first.X, first.Y, last.X, last.Y
+		corners := []image.Point{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}, {x0, y0}}
+		m := newMask(dst.Bounds(), corners, r)
+		m.polyline(corners, r)
+		m.paint(dst, a.Color)
+	case ToolArrow:
+		drawArrow(dst, first, last, w, a.Color)
+	case ToolPen:
+		m := newMask(dst.Bounds(), a.Points, r)
+		m.polyline(a.Points, r)
+		m.paint(dst, a.Color)
+	case ToolHighlighter:
+		// One mask for the whole stroke, so overlapping segments don't darken each other.
+		m := newMask(dst.Bounds(), a.Points, r)
+		m.polyline(a.Points, r)
+		c := a.Color
+		c.A = highlighterAlpha
+		m.paint(dst, c)
+	case ToolText:
+		drawText(dst, first, a.Text, w, a.Color)
+	case ToolStep:
+		drawStep(dst, first, a.Step, w, a.Color)
+	case ToolPixelate, ToolBlur, ToolFill:
+		redact.ApplyRegion(dst, redact.Region{Rect: image.Rectangle{Min: first, Max: last}.Canon(), Mode: a.Tool, Strength: a.Width, Color: a.Color})
+	}
+}
+
+func drawArrow(dst *image.RGBA, from, to image.Point, w int, c color.NRGBA) {
+	dx, dy := float64(to.X-from.X), float64(to.Y-from.Y)
+	length := math.Hypot(dx, dy)
+	if length < 1 {
+		return
+	}
+	ux, uy := dx/length, dy/length
+
+	headLen := math.Min(math.Max(12, 4*float64(w)), length)
+	headHalf := math.Max(6, 2.5*float64(w))
+	baseX, baseY := float64(to.X)-ux*headLen, float64(to.Y)-uy*headLen
+	left := image.Pt(round(baseX-uy*headHalf), round(baseY+ux*headHalf))
+	right := image.Pt(round(baseX+uy*headHalf), round(baseY-ux*headHalf))
+	// End the shaft inside the head so its round cap doesn't poke out of the tip.
+	shaftEnd := image.Pt(round(baseX+ux), round(baseY+uy))
+
+	m := newMask(dst.Bounds(), []image.Point{from, to, left, right}, float64(w)/2)
+	m.capsule(from, shaftEnd, float64(w)/2)
+	m.triangle(to, left, right)
+	m.paint(dst, c)
+}
+
+func drawText(dst *image.RGBA, topLeft image.Point, text string, size int, c color.NRGBA) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	face := newFace(size)
+	defer face.Close()
+
+	metrics := face.Metrics()
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face}
+	baseline := fixed.I(topLeft.Y) + metrics.Ascent
+	for _, line := range strings.Split(text, "\n") {
+		d.Dot = fixed.Point26_6{X: fixed.I(topLeft.X), Y: baseline}
+		d.DrawString(line)
+		baseline += metrics.Height
+	}
+}
+
+func drawStep(dst *image.RGBA, center image.Point, step int, size int, c color.NRGBA) {
+	radius := float64(size) * 0.9
+	m := newMask(dst.Bounds(), []image.Point{center}, radius)
+	m.capsule(center, center, radius)
+	m.paint(dst, c)
+
+	face := newFace(size)
+	defer face.Close()
+
+	label := strconv.Itoa(step)
+	metrics := face.Metrics()
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(contrastColor(c)), Face: face}
+	width := d.MeasureString(label)
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(center.X) - width/2,
+		Y: fixed.I(center.Y) + metrics.CapHeight/2,
+	}
+	d.DrawString(label)
+}
+
+// contrastColor returns black or white, whichever reads better on c.
+func contrastColor(c color.NRGBA) color.NRGBA {
+	luma := 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+	if luma > 160 {
+		return color.NRGBA{A: 255}
+	}
+	return color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+}
+
+var (
+	fontOnce sync.Once
+	fontData *opentype.Font
+)
+
+// newFace returns the label face at size pixels. Faces aren't safe for concurrent use,
+// so each draw gets its own.
+func newFace(size int) font.Face {
+	fontOnce.Do(func() {
+		f, err := opentype.Parse(gobold.TTF)
+		if err != nil {
+			// The font is embedded; this would be a programming error.
+			panic(err)
+		}
+		fontData = f
+	})
+	face, err := opentype.NewFace(fontData, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		panic(err)
+	}
+	return face
+}
+
+// mask accumulates the coverage of one annotation so it can be composited in a single pass.
+type mask struct {
+	alpha *image.Alpha
+}
+
+// newMask returns an empty mask covering pts grown by pad (plus a pixel for anti-aliasing),
+// clipped to clip.
+func newMask(clip image.Rectangle, pts []image.Point, pad float64) *mask {
+	var r image.Rectangle
+	for i, p := range pts {
+		pr := image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))}
+		if i == 0 {
+			r = pr
+		} else {
+			r = r.Union(pr)
+		}
+	}
+	grow := int(math.Ceil(pad)) + 1
+	return &mask{alpha: image.NewAlpha(r.Inset(-grow).Intersect(clip))}
+}
+
+func (m *mask) set(x, y int, coverage float64) {
+	if coverage <= 0 {
+		return
+	}
+	a := uint8(math.Min(coverage, 1) * 255)
+	if cur := m.alpha.AlphaAt(x, y).A; a > cur {
+		m.alpha.SetAlpha(x, y, color.Alpha{A: a})
+	}
+}
+
+// polyline adds capsules between consecutive points; a single point becomes a dot.
+func (m *mask) polyline(pts []image.Point, r float64) {
+	if len(pts) == 1 {
+		m.capsule(pts[0], pts[0], r)
+		return
+	}
+	for i := 1; i < len(pts); i++ {
+		m.capsule(pts[i-1], pts[i], r)
+	}
+}
+
+// capsule adds every pixel within r of the segment p-q, with a one-pixel soft edge.
+func (m *mask) capsule(p, q image.Point, r float64) {
+	lo := image.Pt(min(p.X, q.X), min(p.Y, q.Y))
+	hi := image.Pt(max(p.X, q.X), max(p.Y, q.Y))
+	grow := int(math.Ceil(r)) + 1
+	box := image.Rectangle{Min: lo, Max: hi.Add(image.Pt(1, 1))}.Inset(-grow).Intersect(m.alpha.Rect)
+
+	px, py := float64(p.X), float64(p.Y)
+	vx, vy := float64(q.X-p.X), float64(q.Y-p.Y)
+	lenSq := vx*vx + vy*vy
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			wx, wy := float64(x)-px, float64(y)-py
+			t := 0.0
+			if lenSq > 0 {
+				t = math.Max(0, math.Min(1, (wx*vx+wy*vy)/lenSq))
+			}
+			d := math.Hypot(wx-t*vx, wy-t*vy)
+			m.set(x, y, r+0.5-d)
+		}
+	}
+}
+
+// triangle adds every pixel whose centre lies inside the triangle a-b-c.
+func (m *mask) triangle(a, b, c image.Point) {
+	box := image.Rectangle{
+		Min: image.Pt(min(a.X, b.X, c.X), min(a.Y, b.Y, c.Y)),
+		Max: image.Pt(max(a.X, b.X, c.X)+1, max(a.Y, b.Y, c.Y)+1),
+	}.Intersect(m.alpha.Rect)
+
+	edge := func(p, q image.Point, x, y float64) float64 {
+		return (float64(q.X-p.X))*(y-float64(p.Y)) - (float64(q.Y-p.Y))*(x-float64(p.X))
+	}
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			fx, fy := float64(x), float64(y)
+			e0, e1, e2 := edge(a, b, fx, fy), edge(b, c, fx, fy), edge(c, a, fx, fy)
+			if (e0 >= 0 && e1 >= 0 && e2 >= 0) || (e0 <= 0 && e1 <= 0 && e2 <= 0) {
+				m.set(x, y, 1)
+			}
+		}
+	}
+}
+
+// paint composites c through the mask onto dst.
+func (m *mask) paint(dst *image.RGBA, c color.NRGBA) {
+	r := m.alpha.Rect
+	draw.DrawMask(dst, r, image.NewUniform(c), image.Point{}, m.alpha, r.Min, draw.Over)
+}
+
+func round(f float64) int {
+	return int(math.Round(f))
+}
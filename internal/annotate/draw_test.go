@@ -0,0 +1,179 @@
+package annotate
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var red = color.NRGBA{R: 255, A: 255}
+
+func whiteImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+func isWhite(c color.RGBA) bool {
+	return c == color.RGBA{R: 255, G: 255, B: 255, A: 255}
+}
+
+func TestFlatten_LeavesSourceUntouched(t *testing.T) {
+	t.Parallel()
+
+	src := whiteImage(20, 20)
+	out := Flatten(src, []Annotation{{Tool: ToolPen, Points: []image.Point{{2, 2}, {17, 17}}, Color: red}})
+
+	if !isWhite(src.RGBAAt(10, 10)) {
+		t.Fatalf("source pixel changed: %v", src.RGBAAt(10, 10))
+	}
+	if got := out.RGBAAt(10, 10); got != (color.RGBA{R: 255, A: 255}) {
+		t.Fatalf("stroke pixel=%v want red", got)
+	}
+	if out.Bounds() != src.Bounds() {
+		t.Fatalf("bounds=%v want=%v", out.Bounds(), src.Bounds())
+	}
+}
+
+func TestDraw_RectIsOutline(t *testing.T) {
+	t.Parallel()
+
+	img := whiteImage(40, 40)
+	Draw(img, Annotation{Tool: ToolRect, Points: []image.Point{{5, 5}, {30, 25}}, Color: red, Width: 2})
+
+	for _, p := range []image.Point{{5, 15}, {30, 15}, {15, 5}, {15, 25}} {
+		if got := img.RGBAAt(p.X, p.Y); got.G != 0 {
+			t.Fatalf("edge pixel %v=%v want red", p, got)
+		}
+	}
+	if got := img.RGBAAt(17, 15); !isWhite(got) {
+		t.Fatalf("inside pixel=%v want untouched", got)
+	}
+	if got := img.RGBAAt(35, 35); !isWhite(got) {
+		t.Fatalf("outside pixel=%v want untouched", got)
+	}
+}
+
+func TestDraw_ArrowHeadAtEnd(t *testing.T) {
+	t.Parallel()
+
+	img := whiteImage(60, 30)
+	Draw(img, Annotation{Tool: ToolArrow, Points: []image.Point{{5, 15}, {55, 15}}, Color: red, Width: 2})
+
+	// The head is wider than the shaft: 3px off-axis is painted near the tip, not near the tail.
+	if got := img.RGBAAt(47, 18); got.G != 0 {
+		t.Fatalf("head pixel=%v want red", got)
+	}
+	if got := img.RGBAAt(10, 18); !isWhite(got) {
+		t.Fatalf("shaft-side pixel=%v want untouched", got)
+	}
+}
+
+func TestDraw_HighlighterIsTranslucentAndEven(t *testing.T) {
+	t.Parallel()
+
+	img := whiteImage(40, 20)
+	// The stroke doubles back over itself; overlap must not darken.
+	Draw(img, Annotation{Tool: ToolHighlighter, Points: []image.Point{{5, 10}, {35, 10}, {10, 10}}, Color: color.NRGBA{B: 255, A: 255}})
+
+	a, b := img.RGBAAt(20, 10), img.RGBAAt(33, 10)
+	if a != b {
+		t.Fatalf("overlap pixel=%v single pixel=%v want equal", a, b)
+	}
+	if a.R == 255 || a.R == 0 || a.B != 255 {
+		t.Fatalf("highlight pixel=%v want translucent blue over white", a)
+	}
+}
+
+func TestDraw_TextAndStepPaintNearAnchor(t *testing.T) {
+	t.Parallel()
+
+	cases := []Annotation{
+		{Tool: ToolText, Points: []image.Point{{10, 10}}, Text: "Hi", Color: red},
+		{Tool: ToolStep, Points: []image.Point{{30, 30}}, Step: 7, Color: red},
+	}
+	for _, a := range cases {
+		img := whiteImage(80, 80)
+		Draw(img, a)
+
+		painted := 0
+		var box image.Rectangle
+		for y := 0; y < 80; y++ {
+			for x := 0; x < 80; x++ {
+				if !isWhite(img.RGBAAt(x, y)) {
+					painted++
+					box = box.Union(image.Rect(x, y, x+1, y+1))
+				}
+			}
+		}
+		if painted == 0 {
+			t.Fatalf("%s: nothing painted", a.Tool)
+		}
+		if !a.Points[0].In(box.Inset(-6)) {
+			t.Fatalf("%s: painted box %v doesn't contain anchor %v", a.Tool, box, a.Points[0])
+		}
+	}
+}
+
+func TestDraw_StepLabelContrasts(t *testing.T) {
+	t.Parallel()
+
+	img := whiteImage(60, 60)
+	Draw(img, Annotation{Tool: ToolStep, Points: []image.Point{{30, 30}}, Step: 1, Color: red})
+
+	// Somewhere inside the circle the white label must show through the red fill.
+	found := false
+	for y := 22; y < 38 && !found; y++ {
+		for x := 22; x < 38; x++ {
+			if c := img.RGBAAt(x, y); c.G > 200 {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("no label pixels inside the step marker")
+	}
+}
+
+func TestDraw_IgnoresEmptyAndUnknown(t *testing.T) {
+	t.Parallel()
+
+	img := whiteImage(10, 10)
+	Draw(img, Annotation{Tool: ToolPen, Color: red})
+	Draw(img, Annotation{Tool: "blur", Points: []image.Point{{5, 5}}, Color: red})
+	Draw(img, Annotation{Tool: ToolText, Points: []image.Point{{5, 5}}, Text: "  ", Color: red})
+	for i := range img.Pix {
+		if img.Pix[i] != 255 {
+			t.Fatalf("pixel byte %d=%d, want untouched image", i, img.Pix[i])
+		}
+	}
+}
+
+func TestDraw_ClipsToImage(t *testing.T) {
+	t.Parallel()
+
+	img := whiteImage(10, 10)
+	Draw(img, Annotation{Tool: ToolArrow, Points: []image.Point{{-50, -50}, {100, 100}}, Color: red})
+	Draw(img, Annotation{Tool: ToolStep, Points: []image.Point{{500, 500}}, Step: 3, Color: red})
+	if got := img.RGBAAt(5, 5); got.G != 0 {
+		t.Fatalf("diagonal pixel=%v want red", got)
+	}
+}
+
+func TestDraw_RedactionHidesRegion(t *testing.T) {
+	t.Parallel()
+
+	img := whiteImage(30, 30)
+	Draw(img, Annotation{Tool: ToolPen, Points: []image.Point{{0, 15}, {29, 15}}, Color: red, Width: 1})
+	Draw(img, Annotation{Tool: ToolFill, Points: []image.Point{{20, 25}, {5, 5}}, Color: color.NRGBA{A: 255}})
+
+	if got := img.RGBAAt(10, 15); got != (color.RGBA{A: 255}) {
+		t.Fatalf("redacted pixel=%v want black", got)
+	}
+	if got := img.RGBAAt(25, 15); got.G != 0 {
+		t.Fatalf("stroke outside the box=%v want red", got)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"errors"
 	"image"
 	"image/draw"
-
-	"github.com/kbinani/screenshot"
 )
 
 var (
@@ -14,16 +12,16 @@ var (
 	ErrEmptyCrop        = errors.New("capture: empty crop after clamping")
 )
 
-// CaptureDisplay captures a full screenshot of the specified display.
+// CaptureDisplay captures a full screenshot of the specified display from src.
 // If displayIndex is out of range, it is clamped to the nearest valid index.
-func CaptureDisplay(displayIndex int) (image.Image, error) {
-	n := screenshot.NumActiveDisplays()
+func CaptureDisplay(src Source, displayIndex int) (image.Image, error) {
+	n := src.NumDisplays()
 	if n <= 0 {
 		return nil, ErrNoActiveDisplays
 	}
 	i := clampDisplayIndex(displayIndex, n)
-	bounds := screenshot.GetDisplayBounds(i)
-	img, err := screenshot.CaptureRect(bounds)
+	bounds := src.DisplayBounds(i)
+	img, err := src.CaptureRect(bounds)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,24 @@
+package capture
+
+import (
+	"image"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// CursorPosition returns the mouse cursor position in virtual-desktop coordinates.
+func CursorPosition() (image.Point, error) {
+	c, err := xgb.NewConn()
+	if err != nil {
+		return image.Point{}, ErrCursorUnavailable
+	}
+	defer c.Close()
+
+	root := xproto.Setup(c).DefaultScreen(c).Root
+	reply, err := xproto.QueryPointer(c, root).Reply()
+	if err != nil {
+		return image.Point{}, err
+	}
+	return image.Pt(int(reply.RootX), int(reply.RootY)), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package capture
+
+import "image"
+
+// CursorPosition is unavailable on this platform; callers fall back to the primary display.
+func CursorPosition() (image.Point, error) {
+	return image.Point{}, ErrCursorUnavailable
+}
@@ -0,0 +1,16 @@
+package capture
+
+import (
+	"image"
+
+	"github.com/lxn/win"
+)
+
+// CursorPosition returns the mouse cursor position in virtual-desktop coordinates.
+func CursorPosition() (image.Point, error) {
+	var pt win.POINT
+	if !win.GetCursorPos(&pt) {
+		return image.Point{}, ErrCursorUnavailable
+	}
+	return image.Pt(int(pt.X), int(pt.Y)), nil
+}
@@ -0,0 +1,176 @@
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+)
+
+// Display selection modes for DisplayPolicy.Mode.
+const (
+	// DisplayPrimary captures the primary display (index 0). It is the default for an empty mode.
+	DisplayPrimary = "primary"
+	// DisplayIndex captures the display at DisplayPolicy.Index (clamped to the valid range).
+	DisplayIndex = "index"
+	// DisplayCursor captures the display under the mouse cursor, falling back to the primary
+	// display if the cursor position is unavailable.
+	DisplayCursor = "cursor"
+	// DisplayAll composites every active display into one image by virtual-desktop coordinates.
+	DisplayAll = "all"
+)
+
+var (
+	ErrUnknownDisplayMode = errors.New("capture: unknown display mode")
+	ErrCursorUnavailable  = errors.New("capture: cursor position unavailable")
+)
+
+// DisplayPolicy selects which display(s) a capture covers.
+type DisplayPolicy struct {
+	Mode  string
+	Index int
+}
+
+// Validate reports whether p.Mode is a known mode.
+func (p DisplayPolicy) Validate() error {
+	switch strings.ToLower(strings.TrimSpace(p.Mode)) {
+	case "", DisplayPrimary, DisplayIndex, DisplayCursor, DisplayAll:
+		return nil
+	}
+	return fmt.Errorf("%w %q", ErrUnknownDisplayMode, p.Mode)
+}
+
+// DisplayBounds returns the bounds of every active display of src in virtual-desktop coordinates.
+func DisplayBounds(src Source) []image.Rectangle {
+	n := src.NumDisplays()
+	out := make([]image.Rectangle, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, src.DisplayBounds(i))
+	}
+	return out
+}
+
+// CapturePolicy captures the display(s) selected by p from src.
+//
+// The returned bounds are the virtual-desktop rectangle covered by img (the union of all displays
+// for DisplayAll), suitable for mapping screen-space selections onto img.
+func CapturePolicy(src Source, p DisplayPolicy) (img image.Image, bounds image.Rectangle, err error) {
+	displays := DisplayBounds(src)
+	if len(displays) == 0 {
+		return nil, image.Rectangle{}, ErrNoActiveDisplays
+	}
+	cursor := func() (image.Point, error) { return image.Point{}, ErrCursorUnavailable }
+	if cs, ok := src.(CursorSource); ok {
+		cursor = cs.CursorPosition
+	}
+	indices, err := resolveDisplays(p, displays, cursor)
+	if err != nil {
+		return nil, image.Rectangle{}, err
+	}
+
+	frames := make([]image.Image, 0, len(indices))
+	rects := make([]image.Rectangle, 0, len(indices))
+	for _, i := range indices {
+		frame, err := src.CaptureRect(displays[i])
+		if err != nil {
+			return nil, image.Rectangle{}, fmt.Errorf("capture display %d: %w", i, err)
+		}
+		frames = append(frames, frame)
+		rects = append(rects, displays[i])
+	}
+	if len(frames) == 1 {
+		return frames[0], rects[0], nil
+	}
+	return Composite(frames, rects), UnionBounds(rects), nil
+}
+
+// resolveDisplays returns the display indices selected by p, in index order.
+// The cursor lookup is injected for testability.
+func resolveDisplays(p DisplayPolicy, displays []image.Rectangle, cursor func() (image.Point, error)) ([]int, error) {
+	if len(displays) == 0 {
+		return nil, ErrNoActiveDisplays
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(p.Mode)) {
+	case DisplayIndex:
+		return []int{clampDisplayIndex(p.Index, len(displays))}, nil
+	case DisplayCursor:
+		pt, err := cursor()
+		if err != nil {
+			return []int{0}, nil
+		}
+		return []int{DisplayAt(pt, displays)}, nil
+	case DisplayAll:
+		all := make([]int, len(displays))
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+	return []int{0}, nil
+}
+
+// DisplayAt returns the index of the display containing pt. If no display contains it
+// (e.g. the cursor is in a gap of an irregular layout), it returns the nearest display.
+func DisplayAt(pt image.Point, displays []image.Rectangle) int {
+	best, bestDist := 0, -1
+	for i, b := range displays {
+		if pt.In(b) {
+			return i
+		}
+		d := distanceSq(pt, b)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+func distanceSq(pt image.Point, r image.Rectangle) int {
+	dx, dy := 0, 0
+	if pt.X < r.Min.X {
+		dx = r.Min.X - pt.X
+	} else if pt.X >= r.Max.X {
+		dx = pt.X - r.Max.X + 1
+	}
+	if pt.Y < r.Min.Y {
+		dy = r.Min.Y - pt.Y
+	} else if pt.Y >= r.Max.Y {
+		dy = pt.Y - r.Max.Y + 1
+	}
+	return dx*dx + dy*dy
+}
+
+// UnionBounds returns the smallest rectangle containing every rectangle in bounds.
+func UnionBounds(bounds []image.Rectangle) image.Rectangle {
+	var u image.Rectangle
+	for _, b := range bounds {
+		u = u.Union(b)
+	}
+	return u
+}
+
+// Composite draws each frame at its virtual-desktop bounds into one image covering
+// UnionBounds(bounds). Areas not covered by any display, or whose frame is nil, stay transparent.
+//
+// Virtual-desktop coordinates may be negative (displays left of or above the primary), so the
+// result is translated to start at (0,0): a screen point s maps to s - UnionBounds(bounds).Min.
+func Composite(frames []image.Image, bounds []image.Rectangle) *image.RGBA {
+	union := UnionBounds(bounds)
+	dst := image.NewRGBA(image.Rect(0, 0, union.Dx(), union.Dy()))
+	for i, frame := range frames {
+		if i >= len(bounds) {
+			break
+		}
+		if frame == nil {
+			continue
+		}
+		target := bounds[i].Sub(union.Min)
+		draw.Draw(dst, target, frame, frame.Bounds().Min, draw.Src)
+	}
+	return dst
+}
@@ -0,0 +1,115 @@
+package capture
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestResolveDisplays(t *testing.T) {
+	t.Parallel()
+
+	displays := []image.Rectangle{
+		image.Rect(0, 0, 1920, 1080),
+		image.Rect(-1280, 0, 0, 1024),
+		image.Rect(1920, -200, 3840, 880),
+	}
+	cursorOn := func(p image.Point) func() (image.Point, error) {
+		return func() (image.Point, error) { return p, nil }
+	}
+	noCursor := func() (image.Point, error) { return image.Point{}, ErrCursorUnavailable }
+
+	cases := []struct {
+		name   string
+		policy DisplayPolicy
+		cursor func() (image.Point, error)
+		want   []int
+	}{
+		{name: "empty", policy: DisplayPolicy{}, cursor: noCursor, want: []int{0}},
+		{name: "primary", policy: DisplayPolicy{Mode: DisplayPrimary}, cursor: noCursor, want: []int{0}},
+		{name: "index", policy: DisplayPolicy{Mode: DisplayIndex, Index: 2}, cursor: noCursor, want: []int{2}},
+		{name: "index clamped", policy: DisplayPolicy{Mode: DisplayIndex, Index: 7}, cursor: noCursor, want: []int{2}},
+		{name: "cursor", policy: DisplayPolicy{Mode: DisplayCursor}, cursor: cursorOn(image.Pt(-5, 10)), want: []int{1}},
+		{name: "cursor unavailable", policy: DisplayPolicy{Mode: DisplayCursor}, cursor: noCursor, want: []int{0}},
+		{name: "all", policy: DisplayPolicy{Mode: "ALL"}, cursor: noCursor, want: []int{0, 1, 2}},
+	}
+	for _, tc := range cases {
+		got, err := resolveDisplays(tc.policy, displays, tc.cursor)
+		if err != nil {
+			t.Fatalf("%s: resolveDisplays() error: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: resolveDisplays() got=%v want=%v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestResolveDisplays_UnknownMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := resolveDisplays(DisplayPolicy{Mode: "left"}, []image.Rectangle{image.Rect(0, 0, 10, 10)}, nil)
+	if !errors.Is(err, ErrUnknownDisplayMode) {
+		t.Fatalf("expected ErrUnknownDisplayMode, got=%v", err)
+	}
+}
+
+func TestDisplayAt_NearestWhenInGap(t *testing.T) {
+	t.Parallel()
+
+	displays := []image.Rectangle{
+		image.Rect(0, 0, 100, 100),
+		image.Rect(100, 50, 200, 100),
+	}
+	// (150, 10) is above display 1, in the gap to the right of display 0.
+	if got := DisplayAt(image.Pt(150, 10), displays); got != 1 {
+		t.Fatalf("DisplayAt(gap)=%d want=1", got)
+	}
+	if got := DisplayAt(image.Pt(99, 99), displays); got != 0 {
+		t.Fatalf("DisplayAt(inside)=%d want=0", got)
+	}
+}
+
+func TestComposite_NegativeOrigin(t *testing.T) {
+	t.Parallel()
+
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	// Secondary display left of the primary, with its own image bounds at the origin.
+	left := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	left.Set(0, 0, red)
+	primary := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	primary.Set(2, 2, blue)
+
+	bounds := []image.Rectangle{image.Rect(0, 0, 3, 3), image.Rect(-4, 1, 0, 3)}
+	if got, want := UnionBounds(bounds), image.Rect(-4, 0, 3, 3); got != want {
+		t.Fatalf("UnionBounds()=%v want=%v", got, want)
+	}
+
+	out := Composite([]image.Image{primary, left}, bounds)
+	if out.Bounds() != image.Rect(0, 0, 7, 3) {
+		t.Fatalf("Composite() bounds=%v", out.Bounds())
+	}
+	// Screen (-4,1) -> image (0,1); screen (2,2) -> image (6,2).
+	if got := out.RGBAAt(0, 1); got != red {
+		t.Fatalf("left display pixel=%v want=%v", got, red)
+	}
+	if got := out.RGBAAt(6, 2); got != blue {
+		t.Fatalf("primary display pixel=%v want=%v", got, blue)
+	}
+	// The gap above the left display stays transparent.
+	if got := out.RGBAAt(0, 0); got.A != 0 {
+		t.Fatalf("gap pixel=%v want transparent", got)
+	}
+
+	// A missing frame leaves its display blank but still draws the ones after it.
+	out = Composite([]image.Image{nil, left}, bounds)
+	if got := out.RGBAAt(0, 1); got != red {
+		t.Fatalf("left display pixel after a nil frame=%v want=%v", got, red)
+	}
+	if got := out.RGBAAt(6, 2); got.A != 0 {
+		t.Fatalf("nil frame pixel=%v want transparent", got)
+	}
+}
@@ -0,0 +1,120 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // decoders for NewFakeSourceFromFile
+	_ "image/png"
+	"os"
+	"sync"
+)
+
+// FakeSource is a deterministic in-memory Source for tests and headless runs.
+//
+// It holds one virtual-desktop image covering every display; CaptureRect copies out of it.
+// Pixels outside all displays (gaps in irregular layouts) are transparent.
+type FakeSource struct {
+	mu       sync.Mutex
+	displays []image.Rectangle
+	desktop  *image.RGBA
+	cursor   *image.Point
+	captures []image.Rectangle
+}
+
+// NewFakeSource returns a FakeSource with the given display bounds, filled with a synthetic
+// pattern (see FakePixel) so crops can be checked pixel by pixel.
+func NewFakeSource(displays ...image.Rectangle) *FakeSource {
+	f := &FakeSource{
+		displays: append([]image.Rectangle(nil), displays...),
+		desktop:  image.NewRGBA(UnionBounds(displays)),
+	}
+	for i, b := range displays {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				f.desktop.SetRGBA(x, y, FakePixel(i, x, y))
+			}
+		}
+	}
+	return f
+}
+
+// NewFakeSourceFromImage returns a single-display FakeSource serving img, with the display's
+// top-left corner at origin in virtual-desktop coordinates.
+func NewFakeSourceFromImage(img image.Image, origin image.Point) *FakeSource {
+	b := img.Bounds()
+	display := image.Rectangle{Min: origin, Max: origin.Add(b.Size())}
+	f := &FakeSource{displays: []image.Rectangle{display}, desktop: image.NewRGBA(display)}
+	draw.Draw(f.desktop, display, img, b.Min, draw.Src)
+	return f
+}
+
+// NewFakeSourceFromFile is NewFakeSourceFromImage for a PNG or JPEG file at the virtual-desktop origin.
+func NewFakeSourceFromFile(path string) (*FakeSource, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	img, _, err := image.Decode(fh)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q: %w", path, err)
+	}
+	return NewFakeSourceFromImage(img, image.Point{}), nil
+}
+
+// FakePixel is the synthetic colour NewFakeSource uses at virtual-desktop pixel (x, y) of
+// display i. R and G encode the coordinates, B the display, so a crop's origin is recoverable.
+func FakePixel(i, x, y int) color.RGBA {
+	return color.RGBA{R: uint8(x), G: uint8(y), B: uint8(i * 40), A: 255}
+}
+
+// SetCursor makes the fake implement a known cursor position (used by DisplayCursor).
+func (f *FakeSource) SetCursor(p image.Point) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cursor = &p
+}
+
+// Captures returns every rectangle passed to CaptureRect so far, in call order.
+func (f *FakeSource) Captures() []image.Rectangle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]image.Rectangle(nil), f.captures...)
+}
+
+func (f *FakeSource) NumDisplays() int {
+	return len(f.displays)
+}
+
+func (f *FakeSource) DisplayBounds(i int) image.Rectangle {
+	if i < 0 || i >= len(f.displays) {
+		return image.Rectangle{}
+	}
+	return f.displays[i]
+}
+
+// CaptureRect returns a copy of r with bounds starting at (0,0), like screenshot.CaptureRect.
+func (f *FakeSource) CaptureRect(r image.Rectangle) (image.Image, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.captures = append(f.captures, r)
+
+	if r.Empty() {
+		return nil, fmt.Errorf("capture: empty rect %v", r)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), f.desktop, r.Min, draw.Src)
+	return dst, nil
+}
+
+func (f *FakeSource) CursorPosition() (image.Point, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cursor == nil {
+		return image.Point{}, ErrCursorUnavailable
+	}
+	return *f.cursor, nil
+}
@@ -0,0 +1,78 @@
+package capture
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFakeSource_CaptureDisplayServesPattern(t *testing.T) {
+	t.Parallel()
+
+	src := NewFakeSource(image.Rect(0, 0, 40, 30), image.Rect(-20, 5, 0, 25))
+
+	img, err := CaptureDisplay(src, 1)
+	if err != nil {
+		t.Fatalf("CaptureDisplay() error: %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 20, 20) {
+		t.Fatalf("bounds=%v want 20x20 at origin", img.Bounds())
+	}
+	// Image (3,4) is virtual-desktop (-17, 9) on display 1.
+	if got, want := img.At(3, 4), FakePixel(1, -17, 9); got != want {
+		t.Fatalf("pixel=%v want=%v", got, want)
+	}
+	if got := src.Captures(); len(got) != 1 || got[0] != image.Rect(-20, 5, 0, 25) {
+		t.Fatalf("Captures()=%v", got)
+	}
+}
+
+func TestFakeSource_CapturePolicyUsesCursor(t *testing.T) {
+	t.Parallel()
+
+	src := NewFakeSource(image.Rect(0, 0, 10, 10), image.Rect(10, 0, 20, 10))
+	src.SetCursor(image.Pt(15, 5))
+
+	_, bounds, err := CapturePolicy(src, DisplayPolicy{Mode: DisplayCursor})
+	if err != nil {
+		t.Fatalf("CapturePolicy() error: %v", err)
+	}
+	if bounds != image.Rect(10, 0, 20, 10) {
+		t.Fatalf("bounds=%v want display 1", bounds)
+	}
+}
+
+func TestNewFakeSourceFromFile(t *testing.T) {
+	t.Parallel()
+
+	frame := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	frame.SetRGBA(7, 5, FakePixel(2, 1, 1))
+	path := filepath.Join(t.TempDir(), "frame.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := png.Encode(f, frame); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	src, err := NewFakeSourceFromFile(path)
+	if err != nil {
+		t.Fatalf("NewFakeSourceFromFile() error: %v", err)
+	}
+	if src.NumDisplays() != 1 || src.DisplayBounds(0) != frame.Bounds() {
+		t.Fatalf("displays=%d bounds=%v", src.NumDisplays(), src.DisplayBounds(0))
+	}
+	img, err := src.CaptureRect(image.Rect(6, 4, 8, 6))
+	if err != nil {
+		t.Fatalf("CaptureRect() error: %v", err)
+	}
+	if got, want := img.At(1, 1), FakePixel(2, 1, 1); got != want {
+		t.Fatalf("pixel=%v want=%v", got, want)
+	}
+}
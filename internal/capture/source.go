@@ -0,0 +1,59 @@
+package capture
+
+import (
+	"image"
+
+	"github.com/kbinani/screenshot"
+)
+
+// Source provides display geometry and screen pixels. Display bounds and capture rectangles
+// are in virtual-desktop coordinates (the primary display's top-left is the origin).
+//
+// Screen is the real implementation; FakeSource serves deterministic frames for tests.
+type Source interface {
+	// NumDisplays returns the number of active displays.
+	NumDisplays() int
+	// DisplayBounds returns the bounds of display i, for 0 <= i < NumDisplays().
+	DisplayBounds(i int) image.Rectangle
+	// CaptureRect captures the pixels inside r.
+	CaptureRect(r image.Rectangle) (image.Image, error)
+}
+
+// CursorSource is implemented by sources that know the mouse cursor position.
+// DisplayCursor falls back to the primary display for sources that don't.
+type CursorSource interface {
+	CursorPosition() (image.Point, error)
+}
+
+// Screen returns the default Source, backed by github.com/kbinani/screenshot.
+func Screen() Source {
+	return screenSource{}
+}
+
+type screenSource struct{}
+
+func (screenSource) NumDisplays() int {
+	return screenshot.NumActiveDisplays()
+}
+
+func (screenSource) DisplayBounds(i int) image.Rectangle {
+	return screenshot.GetDisplayBounds(i)
+}
+
+func (screenSource) CaptureRect(r image.Rectangle) (image.Image, error) {
+	img, err := screenshot.CaptureRect(r)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func (screenSource) CursorPosition() (image.Point, error) {
+	return CursorPosition()
+}
+
+// Windows lists the top-level windows (see SystemWindows), so the selection overlay can snap
+// to window bounds.
+func (screenSource) Windows() ([]Window, error) {
+	return SystemWindows().Windows()
+}
@@ -0,0 +1,11 @@
+package capture
+
+import "testing"
+
+func TestScreen_ImplementsCursorSource(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := Screen().(CursorSource); !ok {
+		t.Fatalf("Screen() should implement CursorSource")
+	}
+}
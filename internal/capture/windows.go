@@ -0,0 +1,92 @@
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+var ErrWindowsUnavailable = errors.New("capture: window enumeration unavailable")
+
+// Window is a top-level application window in virtual-desktop coordinates.
+type Window struct {
+	ID    uint32
+	Title string
+	// Frame includes the window manager's decorations (title bar and borders).
+	Frame image.Rectangle
+	// Client is the application's own content area.
+	Client image.Rectangle
+}
+
+// Rect returns the window's frame rectangle, or its client area without decorations.
+func (w Window) Rect(includeDecorations bool) image.Rectangle {
+	if includeDecorations {
+		return w.Frame
+	}
+	return w.Client
+}
+
+// WindowLister enumerates the visible top-level windows, topmost first.
+//
+// SystemWindows is the real implementation; FakeWindows serves a canned list for tests.
+type WindowLister interface {
+	Windows() ([]Window, error)
+}
+
+// WindowAt returns the topmost window whose frame contains pt. windows must be topmost first,
+// as returned by WindowLister.
+func WindowAt(pt image.Point, windows []Window) (Window, bool) {
+	for _, w := range windows {
+		if pt.In(w.Frame) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// FakeWindows is a WindowLister serving a fixed list (topmost first).
+type FakeWindows []Window
+
+func (f FakeWindows) Windows() ([]Window, error) {
+	return append([]Window(nil), f...), nil
+}
+
+// windowTree is the part of a window system that window enumeration needs. The X11 tree
+// (windows_linux.go) is the real one; tests use a canned tree.
+type windowTree interface {
+	// Stacking returns the managed top-level windows, bottommost first.
+	Stacking() ([]uint32, error)
+	// Info describes window id.
+	Info(id uint32) (windowInfo, error)
+}
+
+// windowInfo is what a windowTree knows about one window.
+type windowInfo struct {
+	Title string
+	// Client is the content area in root (virtual-desktop) coordinates.
+	Client image.Rectangle
+	// Extents are the decoration widths around Client: left, right, top, bottom.
+	Extents [4]int
+	// Visible is false for unmapped or minimized windows.
+	Visible bool
+}
+
+// listWindows turns tree into visible windows, topmost first. Windows that vanish while being
+// queried are skipped; a failure to read the stacking order is returned.
+func listWindows(tree windowTree) ([]Window, error) {
+	ids, err := tree.Stacking()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWindowsUnavailable, err)
+	}
+	out := make([]Window, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		info, err := tree.Info(ids[i])
+		if err != nil || !info.Visible || info.Client.Empty() {
+			continue
+		}
+		e := info.Extents
+		frame := image.Rect(info.Client.Min.X-e[0], info.Client.Min.Y-e[2], info.Client.Max.X+e[1], info.Client.Max.Y+e[3])
+		out = append(out, Window{ID: ids[i], Title: info.Title, Frame: frame, Client: info.Client})
+	}
+	return out, nil
+}
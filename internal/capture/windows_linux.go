@@ -0,0 +1,123 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// SystemWindows returns the X11 window lister. It uses the EWMH stacking list kept by the
+// window manager, falling back to the root window's children without one.
+func SystemWindows() WindowLister {
+	return x11Windows{}
+}
+
+type x11Windows struct{}
+
+func (x11Windows) Windows() ([]Window, error) {
+	c, err := xgb.NewConn()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWindowsUnavailable, err)
+	}
+	defer c.Close()
+	return listWindows(&x11Tree{c: c, root: xproto.Setup(c).DefaultScreen(c).Root, atoms: map[string]xproto.Atom{}})
+}
+
+// x11Tree is the windowTree of an X server connection.
+type x11Tree struct {
+	c     *xgb.Conn
+	root  xproto.Window
+	atoms map[string]xproto.Atom
+}
+
+// atom returns the atom called name, or xproto.AtomNone if the server doesn't have it.
+func (t *x11Tree) atom(name string) xproto.Atom {
+	if a, ok := t.atoms[name]; ok {
+		return a
+	}
+	a := xproto.Atom(xproto.AtomNone)
+	if reply, err := xproto.InternAtom(t.c, true, uint16(len(name)), name).Reply(); err == nil {
+		a = reply.Atom
+	}
+	t.atoms[name] = a
+	return a
+}
+
+// property returns the raw value of property name on w, or nil if it is missing.
+func (t *x11Tree) property(w xproto.Window, name string) *xproto.GetPropertyReply {
+	a := t.atom(name)
+	if a == xproto.AtomNone {
+		return nil
+	}
+	reply, err := xproto.GetProperty(t.c, false, w, a, xproto.GetPropertyTypeAny, 0, 1<<16).Reply()
+	if err != nil || reply.ValueLen == 0 {
+		return nil
+	}
+	return reply
+}
+
+func (t *x11Tree) property32(w xproto.Window, name string) []uint32 {
+	reply := t.property(w, name)
+	if reply == nil || reply.Format != 32 {
+		return nil
+	}
+	out := make([]uint32, 0, reply.ValueLen)
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		out = append(out, xgb.Get32(reply.Value[i:]))
+	}
+	return out
+}
+
+func (t *x11Tree) Stacking() ([]uint32, error) {
+	if ids := t.property32(t.root, "_NET_CLIENT_LIST_STACKING"); len(ids) > 0 {
+		return ids, nil
+	}
+	// No EWMH window manager: the root's children are the top-level windows, bottommost first.
+	reply, err := xproto.QueryTree(t.c, t.root).Reply()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint32, len(reply.Children))
+	for i, w := range reply.Children {
+		ids[i] = uint32(w)
+	}
+	return ids, nil
+}
+
+func (t *x11Tree) Info(id uint32) (windowInfo, error) {
+	w := xproto.Window(id)
+	attrs, err := xproto.GetWindowAttributes(t.c, w).Reply()
+	if err != nil {
+		return windowInfo{}, err
+	}
+	geom, err := xproto.GetGeometry(t.c, xproto.Drawable(w)).Reply()
+	if err != nil {
+		return windowInfo{}, err
+	}
+	origin, err := xproto.TranslateCoordinates(t.c, w, t.root, 0, 0).Reply()
+	if err != nil {
+		return windowInfo{}, err
+	}
+
+	info := windowInfo{
+		Client:  image.Rect(int(origin.DstX), int(origin.DstY), int(origin.DstX)+int(geom.Width), int(origin.DstY)+int(geom.Height)),
+		Visible: attrs.MapState == xproto.MapStateViewable,
+	}
+	if e := t.property32(w, "_NET_FRAME_EXTENTS"); len(e) == 4 {
+		info.Extents = [4]int{int(e[0]), int(e[1]), int(e[2]), int(e[3])}
+	}
+	hidden := t.atom("_NET_WM_STATE_HIDDEN")
+	for _, s := range t.property32(w, "_NET_WM_STATE") {
+		if hidden != xproto.AtomNone && xproto.Atom(s) == hidden {
+			info.Visible = false
+		}
+	}
+	if p := t.property(w, "_NET_WM_NAME"); p != nil {
+		info.Title = string(p.Value)
+	} else if p := t.property(w, "WM_NAME"); p != nil {
+		info.Title = string(p.Value)
+	}
+	return info, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package capture
+
+// SystemWindows returns a lister that always fails: window enumeration is only implemented
+// for X11 so far.
+func SystemWindows() WindowLister {
+	return unavailableWindows{}
+}
+
+type unavailableWindows struct{}
+
+func (unavailableWindows) Windows() ([]Window, error) {
+	return nil, ErrWindowsUnavailable
+}
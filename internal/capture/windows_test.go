@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"testing"
+)
+
+// fakeTree is a canned windowTree: stacking bottommost first, plus per-window info.
+type fakeTree struct {
+	stacking []uint32
+	info     map[uint32]windowInfo
+	err      error
+}
+
+func (f fakeTree) Stacking() ([]uint32, error) { return f.stacking, f.err }
+
+func (f fakeTree) Info(id uint32) (windowInfo, error) {
+	info, ok := f.info[id]
+	if !ok {
+		return windowInfo{}, errors.New("BadWindow")
+	}
+	return info, nil
+}
+
+func TestListWindows_TopmostFirstWithFrames(t *testing.T) {
+	t.Parallel()
+
+	tree := fakeTree{
+		stacking: []uint32{1, 2, 3, 4, 5},
+		info: map[uint32]windowInfo{
+			1: {Title: "desktop", Client: image.Rect(0, 0, 100, 100), Visible: true},
+			2: {Title: "editor", Client: image.Rect(10, 30, 60, 80), Extents: [4]int{2, 2, 20, 2}, Visible: true},
+			3: {Title: "minimized", Client: image.Rect(0, 0, 50, 50)},
+			// 4 vanished between the stacking query and Info.
+			5: {Title: "terminal", Client: image.Rect(40, 40, 90, 90), Visible: true},
+		},
+	}
+
+	got, err := listWindows(tree)
+	if err != nil {
+		t.Fatalf("listWindows() error: %v", err)
+	}
+	want := []Window{
+		{ID: 5, Title: "terminal", Frame: image.Rect(40, 40, 90, 90), Client: image.Rect(40, 40, 90, 90)},
+		{ID: 2, Title: "editor", Frame: image.Rect(8, 10, 62, 82), Client: image.Rect(10, 30, 60, 80)},
+		{ID: 1, Title: "desktop", Frame: image.Rect(0, 0, 100, 100), Client: image.Rect(0, 0, 100, 100)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("listWindows() got=%+v want=%+v", got, want)
+	}
+}
+
+func TestListWindows_StackingError(t *testing.T) {
+	t.Parallel()
+
+	_, err := listWindows(fakeTree{err: errors.New("connection refused")})
+	if !errors.Is(err, ErrWindowsUnavailable) {
+		t.Fatalf("listWindows() error=%v want=%v", err, ErrWindowsUnavailable)
+	}
+}
+
+func TestWindowAt_PicksTopmost(t *testing.T) {
+	t.Parallel()
+
+	windows := FakeWindows{
+		{ID: 5, Frame: image.Rect(40, 40, 90, 90), Client: image.Rect(42, 60, 88, 88)},
+		{ID: 2, Frame: image.Rect(8, 10, 62, 82), Client: image.Rect(10, 30, 60, 80)},
+	}
+	list, _ := windows.Windows()
+
+	if w, ok := WindowAt(image.Pt(50, 50), list); !ok || w.ID != 5 {
+		t.Fatalf("WindowAt(overlap)=%+v,%v want window 5", w, ok)
+	}
+	if w, ok := WindowAt(image.Pt(20, 12), list); !ok || w.ID != 2 || w.Rect(false) != image.Rect(10, 30, 60, 80) || w.Rect(true) != image.Rect(8, 10, 62, 82) {
+		t.Fatalf("WindowAt(title bar)=%+v,%v want window 2", w, ok)
+	}
+	if _, ok := WindowAt(image.Pt(95, 5), list); ok {
+		t.Fatalf("WindowAt(empty desktop) found a window")
+	}
+}
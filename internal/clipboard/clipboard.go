@@ -0,0 +1,143 @@
+// Package clipboard puts captures on the system clipboard.
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"sync"
+
+	"golang.design/x/clipboard"
+)
+
+// Capture destinations for Destination.Mode.
+const (
+	// DestinationFile saves captures into the output directory. It is the default for an empty mode.
+	DestinationFile = "file"
+	// DestinationClipboard only copies captures to the clipboard; nothing is written to disk.
+	DestinationClipboard = "clipboard"
+	// DestinationBoth saves captures and copies them to the clipboard.
+	DestinationBoth = "both"
+)
+
+// Clipboard contents for Destination.Content.
+const (
+	// ContentImage copies the capture as image/png. It is the default for an empty content.
+	ContentImage = "image"
+	// ContentPath copies the saved file path as text. It needs a saved file (DestinationBoth).
+	ContentPath = "path"
+)
+
+var (
+	ErrUnknownDestination = errors.New("clipboard: unknown destination")
+	ErrUnknownContent     = errors.New("clipboard: unknown clipboard content")
+	ErrPathWithoutFile    = errors.New(`clipboard: path content needs the "both" destination`)
+	ErrUnavailable        = errors.New("clipboard: unavailable")
+)
+
+// Destination selects where captures go.
+type Destination struct {
+	Mode    string
+	Content string
+}
+
+// Validate reports unknown modes and contents, and path content outside DestinationBoth: a
+// path needs a saved file and a clipboard to copy it to.
+func (d Destination) Validate() error {
+	switch normalize(d.Mode) {
+	case "", DestinationFile, DestinationClipboard, DestinationBoth:
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownDestination, d.Mode)
+	}
+	switch normalize(d.Content) {
+	case "", ContentImage:
+	case ContentPath:
+		if normalize(d.Mode) != DestinationBoth {
+			return ErrPathWithoutFile
+		}
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownContent, d.Content)
+	}
+	return nil
+}
+
+// ToFile reports whether captures are saved into the output directory.
+func (d Destination) ToFile() bool {
+	m := normalize(d.Mode)
+	return m == "" || m == DestinationFile || m == DestinationBoth
+}
+
+// ToClipboard reports whether captures are copied to the clipboard.
+func (d Destination) ToClipboard() bool {
+	m := normalize(d.Mode)
+	return m == DestinationClipboard || m == DestinationBoth
+}
+
+// CopiesPath reports whether the clipboard gets the saved path rather than the image.
+func (d Destination) CopiesPath() bool {
+	return normalize(d.Content) == ContentPath
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Writer puts data on a clipboard.
+//
+// System is the real implementation; Fake records writes for tests and headless runs.
+type Writer interface {
+	// WriteImage replaces the clipboard contents with PNG-encoded image data (image/png).
+	WriteImage(png []byte) error
+	// WriteText replaces the clipboard contents with UTF-8 text.
+	WriteText(s string) error
+}
+
+// CopyImage PNG-encodes img and writes it to w.
+func CopyImage(w Writer, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return w.WriteImage(buf.Bytes())
+}
+
+// System returns the system clipboard, backed by golang.design/x/clipboard.
+// The backend is initialised on first use; if that fails every write returns ErrUnavailable.
+func System() Writer {
+	return systemClipboard{}
+}
+
+type systemClipboard struct{}
+
+var (
+	initOnce sync.Once
+	initErr  error
+)
+
+func (systemClipboard) init() error {
+	initOnce.Do(func() {
+		if err := clipboard.Init(); err != nil {
+			initErr = fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+	})
+	return initErr
+}
+
+func (c systemClipboard) WriteImage(png []byte) error {
+	if err := c.init(); err != nil {
+		return err
+	}
+	clipboard.Write(clipboard.FmtImage, png)
+	return nil
+}
+
+func (c systemClipboard) WriteText(s string) error {
+	if err := c.init(); err != nil {
+		return err
+	}
+	clipboard.Write(clipboard.FmtText, []byte(s))
+	return nil
+}
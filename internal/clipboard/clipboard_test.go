@@ -0,0 +1,73 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestDestination_Validate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		d    Destination
+		want error
+	}{
+		{d: Destination{}, want: nil},
+		{d: Destination{Mode: " Both ", Content: "PATH"}, want: nil},
+		{d: Destination{Mode: DestinationClipboard}, want: nil},
+		{d: Destination{Mode: DestinationClipboard, Content: ContentPath}, want: ErrPathWithoutFile},
+		{d: Destination{Mode: DestinationFile, Content: ContentPath}, want: ErrPathWithoutFile},
+		{d: Destination{Content: ContentPath}, want: ErrPathWithoutFile},
+		{d: Destination{Mode: "printer"}, want: ErrUnknownDestination},
+		{d: Destination{Mode: DestinationBoth, Content: "html"}, want: ErrUnknownContent},
+	}
+	for _, tc := range cases {
+		if err := tc.d.Validate(); !errors.Is(err, tc.want) {
+			t.Fatalf("%+v.Validate()=%v want=%v", tc.d, err, tc.want)
+		}
+	}
+}
+
+func TestDestination_Targets(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		mode            string
+		file, clipboard bool
+	}{
+		{mode: "", file: true},
+		{mode: DestinationFile, file: true},
+		{mode: DestinationClipboard, clipboard: true},
+		{mode: DestinationBoth, file: true, clipboard: true},
+	}
+	for _, tc := range cases {
+		d := Destination{Mode: tc.mode}
+		if d.ToFile() != tc.file || d.ToClipboard() != tc.clipboard {
+			t.Fatalf("mode %q: ToFile=%v ToClipboard=%v want %v %v", tc.mode, d.ToFile(), d.ToClipboard(), tc.file, tc.clipboard)
+		}
+	}
+}
+
+func TestCopyImage_WritesPNG(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.SetRGBA(2, 1, color.RGBA{R: 9, A: 255})
+
+	fake := NewFake()
+	if err := CopyImage(fake, img); err != nil {
+		t.Fatalf("CopyImage() error: %v", err)
+	}
+	got, err := png.Decode(bytes.NewReader(fake.Image()))
+	if err != nil {
+		t.Fatalf("decode clipboard image: %v", err)
+	}
+	r, _, _, a := got.At(2, 1).RGBA()
+	if got.Bounds() != img.Bounds() || r>>8 != 9 || a>>8 != 255 {
+		t.Fatalf("clipboard image bounds=%v pixel=%v", got.Bounds(), got.At(2, 1))
+	}
+}
@@ -0,0 +1,68 @@
+package clipboard
+
+import "sync"
+
+// Fake is an in-memory Writer for tests and headless runs. It keeps the last write, like a
+// real clipboard, and counts every write.
+type Fake struct {
+	mu     sync.Mutex
+	image  []byte
+	text   string
+	writes int
+	err    error
+}
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// SetError makes every following write fail with err (nil restores normal behaviour).
+func (f *Fake) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// Image returns the PNG data of the last write, or nil if it was text.
+func (f *Fake) Image() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]byte(nil), f.image...)
+}
+
+// Text returns the text of the last write, or "" if it was an image.
+func (f *Fake) Text() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.text
+}
+
+// Writes returns the number of successful writes so far.
+func (f *Fake) Writes() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes
+}
+
+func (f *Fake) WriteImage(png []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.image, f.text = append([]byte(nil), png...), ""
+	f.writes++
+	return nil
+}
+
+func (f *Fake) WriteText(s string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.image, f.text = nil, s
+	f.writes++
+	return nil
+}
@@ -0,0 +1,34 @@
+package clipboard
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFake_KeepsLastWrite(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake()
+	if err := f.WriteImage([]byte{1, 2}); err != nil {
+		t.Fatalf("WriteImage() error: %v", err)
+	}
+	if err := f.WriteText("/tmp/shot.png"); err != nil {
+		t.Fatalf("WriteText() error: %v", err)
+	}
+	if f.Image() != nil || f.Text() != "/tmp/shot.png" || f.Writes() != 2 {
+		t.Fatalf("image=%v text=%q writes=%d", f.Image(), f.Text(), f.Writes())
+	}
+}
+
+func TestFake_SetError(t *testing.T) {
+	t.Parallel()
+
+	f := NewFake()
+	f.SetError(ErrUnavailable)
+	if err := f.WriteText("x"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("WriteText() error=%v want=%v", err, ErrUnavailable)
+	}
+	if f.Writes() != 0 {
+		t.Fatalf("Writes()=%d want 0", f.Writes())
+	}
+}
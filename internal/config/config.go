@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"go-snip/internal/utils"
 )
 
 // Config holds user-configurable settings for go-snip.
@@ -14,9 +16,115 @@ type Config struct {
 	// If empty, callers should fall back to other sources (env/flags/default).
 	OutputDir string `json:"outputDir"`
 
+	// FilenameTemplate names saved captures, e.g.
+	// "{date:2006-01-02}/{mode}_{display}_{w}x{h}_{name}{counter}"; "/" starts a subdirectory
+	// of OutputDir. See utils.ParseFilenameTemplate for the placeholders. Empty keeps the
+	// default YYYYMMDD_HHMMSS_mmm[ - name] names. Load rejects invalid templates.
+	FilenameTemplate string `json:"filenameTemplate,omitempty"`
+
+	// DateFolders saves captures, recordings and timelapse folders into OutputDir/YYYY/MM/DD.
+	DateFolders bool `json:"dateFolders,omitempty"`
+
+	// RetentionDays, RetentionMaxMB and RetentionMaxFiles limit the captures kept in OutputDir
+	// (0: no limit). Older files, and the oldest beyond the total size or count, are deleted on
+	// startup and after each save; only files named by go-snip are touched.
+	RetentionDays     int `json:"retentionDays,omitempty"`
+	RetentionMaxMB    int `json:"retentionMaxMB,omitempty"`
+	RetentionMaxFiles int `json:"retentionMaxFiles,omitempty"`
+
+	// StripMetadata leaves out the PNG text chunks with the capture time, go-snip version,
+	// display, selection, hostname, name and notes that are otherwise written into captures.
+	StripMetadata bool `json:"stripMetadata,omitempty"`
+
 	// PostCapturePrompt enables showing a post-capture dialog that lets the user
 	// preview, name, and choose Save/Delete before writing the file.
 	PostCapturePrompt bool `json:"postCapturePrompt"`
+
+	// Hotkeys maps an action name (see internal/hotkeys) to a binding such as "Ctrl+Shift+1".
+	// Missing actions use their default binding; an empty binding disables the action.
+	Hotkeys map[string]string `json:"hotkeys,omitempty"`
+
+	// Display selects which display(s) captures use: "primary" (default when empty), "index",
+	// "cursor" (the display under the mouse) or "all" (every display composited into one image).
+	Display string `json:"display,omitempty"`
+
+	// DisplayIndex is the display used when Display is "index".
+	DisplayIndex int `json:"displayIndex,omitempty"`
+
+	// Format is the image format for saved captures: "png" (default when empty), "jpeg",
+	// "gif", "bmp" or "tiff". The options below only apply to their own format; zero
+	// values use the encoder defaults.
+	Format          string `json:"format,omitempty"`
+	PNGCompression  string `json:"pngCompression,omitempty"`  // default, none, speed, best
+	JPEGQuality     int    `json:"jpegQuality,omitempty"`     // 1..100, default 90
+	GIFColors       int    `json:"gifColors,omitempty"`       // 2..256, default 256
+	TIFFCompression string `json:"tiffCompression,omitempty"` // deflate (default), none
+
+	// IncludeDecorations makes window captures include the window manager's frame and title
+	// bar; by default only the window's content area is captured.
+	IncludeDecorations bool `json:"includeDecorations,omitempty"`
+
+	// DelaySeconds is how long the delayed-capture hotkeys (and `capture --delay`) wait before
+	// capturing, e.g. 3, 5 or 10. Zero means the default of 3 seconds.
+	DelaySeconds int `json:"delaySeconds,omitempty"`
+
+	// Destination is where captures go: "file" (default when empty) saves into OutputDir,
+	// "clipboard" only copies them to the clipboard, "both" does both.
+	Destination string `json:"destination,omitempty"`
+
+	// ClipboardContent is what gets copied: "image" (default when empty, as image/png) or
+	// "path" (the saved file path as text; needs Destination "both").
+	ClipboardContent string `json:"clipboardContent,omitempty"`
+
+	// SelectionAspect locks area selections to a width:height ratio such as "16:9",
+	// SelectionSize fixes them to a pixel size such as "1280x720" (placed with a click), and
+	// SelectionMinSize sets a minimum such as "64x64". Empty means unconstrained; holding
+	// Shift in the overlay selects freely.
+	SelectionAspect  string `json:"selectionAspect,omitempty"`
+	SelectionSize    string `json:"selectionSize,omitempty"`
+	SelectionMinSize string `json:"selectionMinSize,omitempty"`
+
+	// Regions are named capture rectangles. Capture one with
+	// `go-snip capture region --name <name>` or bind a hotkey to the "region:<name>" action.
+	Regions []NamedRegion `json:"regions,omitempty"`
+
+	// TimelapseSeconds is the time between timelapse frames (default 5), and TimelapseMinutes
+	// how long a timelapse runs (0: until its hotkey is pressed again). TimelapseRegion names a
+	// region to capture instead of the display policy; TimelapseSkipIdentical drops frames
+	// identical to the previous one.
+	TimelapseSeconds       int    `json:"timelapseSeconds,omitempty"`
+	TimelapseMinutes       int    `json:"timelapseMinutes,omitempty"`
+	TimelapseRegion        string `json:"timelapseRegion,omitempty"`
+	TimelapseSkipIdentical bool   `json:"timelapseSkipIdentical,omitempty"`
+
+	// RecordFPS is the frame rate of screen recordings (default 10, at most 50),
+	// RecordMaxSeconds stops a recording that wasn't stopped by its hotkey (default 30), and
+	// RecordFormat is "gif" (default when empty) or "apng".
+	RecordFPS        int    `json:"recordFps,omitempty"`
+	RecordMaxSeconds int    `json:"recordMaxSeconds,omitempty"`
+	RecordFormat     string `json:"recordFormat,omitempty"`
+
+	// ScrollIntervalMillis is how often a scrolling capture grabs its region while the user
+	// scrolls (default 250), ScrollMaxSeconds stops it if it isn't stopped by its hotkey
+	// (default 120), and ScrollIdleSeconds stops it once nothing new scrolled into view for that
+	// long (0: never).
+	ScrollIntervalMillis int `json:"scrollIntervalMillis,omitempty"`
+	ScrollMaxSeconds     int `json:"scrollMaxSeconds,omitempty"`
+	ScrollIdleSeconds    int `json:"scrollIdleSeconds,omitempty"`
+}
+
+// NamedRegion is a capture rectangle saved under a name, e.g.
+// {"name": "grafana-panel", "display": 1, "rect": [100, 200, 900, 700]}.
+// Rect is x0, y0, x1, y1 relative to the top-left corner of the display.
+type NamedRegion struct {
+	Name    string `json:"name"`
+	Display int    `json:"display"`
+	Rect    [4]int `json:"rect"`
+}
+
+// Region returns the rectangle of r as a Region.
+func (r NamedRegion) Region() Region {
+	return Region{Display: r.Display, X0: r.Rect[0], Y0: r.Rect[1], X1: r.Rect[2], Y1: r.Rect[3]}
 }
 
 // DefaultPath returns the per-user config file path:
@@ -31,28 +139,44 @@ func DefaultPath() (string, error) {
 
 // Load loads the config from path. If the file does not exist, it returns a zero Config and nil error.
 func Load(path string) (Config, error) {
+	var cfg Config
+	if err := readJSON(path, &cfg); err != nil {
+		return Config{}, err
+	}
+	if _, err := utils.ParseFilenameTemplate(cfg.FilenameTemplate); err != nil {
+		return Config{}, fmt.Errorf("%q: filenameTemplate: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes cfg to path as JSON, creating parent directories as needed.
+// It writes atomically via a temp file + rename.
+func Save(path string, cfg Config) error {
+	return writeJSON(path, cfg)
+}
+
+// readJSON decodes the file at path into v, leaving v untouched if the file does not exist.
+func readJSON(path string, v any) error {
 	if path == "" {
-		return Config{}, errors.New("config path is empty")
+		return errors.New("config path is empty")
 	}
 
 	b, err := os.ReadFile(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return Config{}, nil
+			return nil
 		}
-		return Config{}, err
+		return err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(b, &cfg); err != nil {
-		return Config{}, fmt.Errorf("parse %q: %w", path, err)
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("parse %q: %w", path, err)
 	}
-	return cfg, nil
+	return nil
 }
 
-// Save writes cfg to path as JSON, creating parent directories as needed.
-// It writes atomically via a temp file + rename.
-func Save(path string, cfg Config) error {
+// writeJSON atomically replaces the file at path with v as indented JSON.
+func writeJSON(path string, v any) error {
 	if path == "" {
 		return errors.New("config path is empty")
 	}
@@ -62,7 +186,7 @@ func Save(path string, cfg Config) error {
 		return err
 	}
 
-	b, err := json.MarshalIndent(cfg, "", "  ")
+	b, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"errors"
+	"image"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"go-snip/internal/utils"
 )
 
 func TestDefaultPath(t *testing.T) {
@@ -39,7 +44,14 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 	t.Parallel()
 
 	p := filepath.Join(t.TempDir(), "config.json")
-	orig := Config{OutputDir: `C:\some\dir`, PostCapturePrompt: true}
+	orig := Config{
+		OutputDir:         `C:\some\dir`,
+		PostCapturePrompt: true,
+		Hotkeys:           map[string]string{"area": "Ctrl+Alt+P"},
+		Destination:       "both",
+		ClipboardContent:  "path",
+		Regions:           []NamedRegion{{Name: "grafana-panel", Display: 1, Rect: [4]int{100, 200, 900, 700}}},
+	}
 
 	if err := Save(p, orig); err != nil {
 		t.Fatalf("Save() error: %v", err)
@@ -52,7 +64,7 @@ func TestSaveAndLoad_RoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Load() error: %v", err)
 	}
-	if got != orig {
+	if !reflect.DeepEqual(got, orig) {
 		t.Fatalf("roundtrip mismatch got=%+v want=%+v", got, orig)
 	}
 }
@@ -76,3 +88,33 @@ func TestLoad_MissingPostCapturePromptDefaultsFalse(t *testing.T) {
 		t.Fatalf("expected PostCapturePrompt=false by default")
 	}
 }
+
+func TestLoad_ValidatesFilenameTemplate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	if err := Save(good, Config{FilenameTemplate: "{date}/{mode}_{name}{counter}"}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if cfg, err := Load(good); err != nil || cfg.FilenameTemplate != "{date}/{mode}_{name}{counter}" {
+		t.Fatalf("Load(good) got=%q,%v", cfg.FilenameTemplate, err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := Save(bad, Config{FilenameTemplate: "{date}/{nope}"}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := Load(bad); !errors.Is(err, utils.ErrInvalidTemplate) {
+		t.Fatalf("Load(bad) error=%v want=%v", err, utils.ErrInvalidTemplate)
+	}
+}
+
+func TestNamedRegion_Region(t *testing.T) {
+	t.Parallel()
+
+	r := NamedRegion{Name: "grafana-panel", Display: 1, Rect: [4]int{100, 200, 900, 700}}
+	if got, want := r.Region(), NewRegion(1, image.Rect(100, 200, 900, 700)); got != want {
+		t.Fatalf("Region() got=%+v want=%+v", got, want)
+	}
+}
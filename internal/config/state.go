@@ -0,0 +1,59 @@
+package config
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+)
+
+// State is what go-snip remembers between runs on its own, as opposed to Config, which
+// holds the user's settings. It lives in its own file so saving one never clobbers the other.
+type State struct {
+	// LastRegion is the last confirmed area selection, or nil before the first one.
+	LastRegion *Region `json:"lastRegion,omitempty"`
+}
+
+// Region is an area selection remembered for repeat captures: a rectangle relative to the
+// top-left corner of display Display (the display holding most of it), like the --rect of
+// `go-snip capture region`.
+type Region struct {
+	Display int `json:"display"`
+	X0      int `json:"x0"`
+	Y0      int `json:"y0"`
+	X1      int `json:"x1"`
+	Y1      int `json:"y1"`
+}
+
+// NewRegion returns the Region for the display-relative rectangle r on display.
+func NewRegion(display int, r image.Rectangle) Region {
+	return Region{Display: display, X0: r.Min.X, Y0: r.Min.Y, X1: r.Max.X, Y1: r.Max.Y}
+}
+
+// Rect returns the display-relative rectangle of r.
+func (r Region) Rect() image.Rectangle {
+	return image.Rect(r.X0, r.Y0, r.X1, r.Y1)
+}
+
+// DefaultStatePath returns the per-user state file path:
+// <UserConfigDir>/go-snip/state.json
+func DefaultStatePath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "go-snip", "state.json"), nil
+}
+
+// LoadState loads the state from path. If the file does not exist, it returns a zero State and nil error.
+func LoadState(path string) (State, error) {
+	var st State
+	if err := readJSON(path, &st); err != nil {
+		return State{}, err
+	}
+	return st, nil
+}
+
+// SaveState writes st to path as JSON, atomically like Save.
+func SaveState(path string, st State) error {
+	return writeJSON(path, st)
+}
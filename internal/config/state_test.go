@@ -0,0 +1,59 @@
+package config
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultStatePath(t *testing.T) {
+	t.Parallel()
+
+	p, err := DefaultStatePath()
+	if err != nil {
+		t.Fatalf("DefaultStatePath() error: %v", err)
+	}
+	if !strings.HasSuffix(filepath.ToSlash(p), "go-snip/state.json") {
+		t.Fatalf("DefaultStatePath()=%q, expected suffix go-snip/state.json", p)
+	}
+}
+
+func TestSaveStateAndLoadState_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "state.json")
+	if st, err := LoadState(p); err != nil || st.LastRegion != nil {
+		t.Fatalf("LoadState(missing) got=%+v err=%v want zero, nil", st, err)
+	}
+
+	region := NewRegion(1, image.Rect(10, 20, 330, 260))
+	orig := State{LastRegion: &region}
+	if err := SaveState(p, orig); err != nil {
+		t.Fatalf("SaveState() error: %v", err)
+	}
+	got, err := LoadState(p)
+	if err != nil {
+		t.Fatalf("LoadState() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, orig) {
+		t.Fatalf("roundtrip mismatch got=%+v want=%+v", got, orig)
+	}
+	if r := got.LastRegion.Rect(); r != image.Rect(10, 20, 330, 260) {
+		t.Fatalf("Region.Rect() got=%v", r)
+	}
+}
+
+func TestLoadState_CorruptFile(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(p, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(p); err == nil {
+		t.Fatalf("LoadState(corrupt) error=nil")
+	}
+}
@@ -0,0 +1,308 @@
+// Package history keeps an index of saved captures as JSON lines, one Entry per save, so they
+// can be listed and searched without walking the output directory.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLocked is returned when another process holds the index lock for longer than lockTimeout.
+var ErrLocked = errors.New("history: index is locked")
+
+// Changes to the index (Append, Write, Update, Replace) are serialized: mu within the process,
+// a "<index>.lock" file across processes (the daemon and one-shot commands). A rewrite racing
+// with an append would otherwise drop the appended entry.
+var mu sync.Mutex
+
+const (
+	lockTimeout = 5 * time.Second
+	// staleLock is the age after which a lock file is taken to be left over by a crash.
+	staleLock = 30 * time.Second
+)
+
+// lock takes the index lock for path and returns the function releasing it.
+func lock(path string) (unlock func(), err error) {
+	mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		mu.Unlock()
+		return nil, err
+	}
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			f.Close()
+			return func() {
+				_ = os.Remove(lockPath)
+				mu.Unlock()
+			}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			mu.Unlock()
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLock {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			mu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrLocked, lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Entry describes one saved capture, recording or timelapse folder.
+type Entry struct {
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+	// Mode is how it was captured (full, area, window, last, region, scroll, record,
+	// timelapse); empty for entries rebuilt from the output directory.
+	Mode string `json:"mode,omitempty"`
+	// Display is the display index, or -1 for all displays or unknown.
+	Display int `json:"display"`
+	// Rect is the captured virtual-desktop rectangle x0,y0,x1,y1, if known.
+	Rect   [4]int `json:"rect"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	// Name is the name entered in the post-capture prompt.
+	Name string `json:"name,omitempty"`
+	// SHA256 is the hex digest of the file contents; empty for folders.
+	SHA256 string   `json:"sha256,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// DefaultPath returns the per-user index path:
+// <UserConfigDir>/go-snip/history.jsonl
+func DefaultPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "go-snip", "history.jsonl"), nil
+}
+
+// Append adds e to the index at path, creating it if needed. Each entry is one line written
+// with a single append, so a concurrent reader never sees half an entry.
+func Append(path string, e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	_, writeErr := f.Write(append(b, '\n'))
+	return errors.Join(writeErr, f.Close())
+}
+
+// Load reads the index at path, oldest first. A path saved more than once keeps only its
+// latest entry. Lines that don't parse (e.g. cut short by a crash) are skipped and counted in
+// skipped. A missing index returns an error wrapping os.ErrNotExist.
+func Load(path string) (entries []Entry, skipped int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	entries, skipped, err = read(f)
+	if err != nil {
+		return nil, skipped, fmt.Errorf("read %q: %w", path, err)
+	}
+	return entries, skipped, nil
+}
+
+func read(r io.Reader) ([]Entry, int, error) {
+	var all []Entry
+	skipped := 0
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil || e.Path == "" {
+			skipped++
+			continue
+		}
+		all = append(all, e)
+	}
+
+	// Keep the last entry of each path, in the order of those last entries.
+	seen := make(map[string]bool, len(all))
+	var entries []Entry
+	for i := len(all) - 1; i >= 0; i-- {
+		if !seen[all[i].Path] {
+			seen[all[i].Path] = true
+			entries = append(entries, all[i])
+		}
+	}
+	for l, r := 0, len(entries)-1; l < r; l, r = l+1, r-1 {
+		entries[l], entries[r] = entries[r], entries[l]
+	}
+	return entries, skipped, sc.Err()
+}
+
+// Write atomically replaces the index at path with entries.
+func Write(path string, entries []Entry) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return write(path, entries)
+}
+
+// Update rewrites the index at path with what fn returns for its current entries (none if it
+// doesn't exist yet). Nothing can change the index in between.
+func Update(path string, fn func(entries []Entry) []Entry) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, _, err := Load(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return write(path, fn(entries))
+}
+
+func write(path string, entries []Entry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "history-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, writeErr := tmp.Write(buf.Bytes())
+	if err := errors.Join(writeErr, tmp.Close()); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		// os.Rename won't replace an existing file on some platforms.
+		_ = os.Remove(path)
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			_ = os.Remove(tmp.Name())
+			return err
+		}
+	}
+	return nil
+}
+
+// Replace rewrites the index at path with the entry of oldPath replaced by e, or removed if e
+// is nil. Entries of other paths are kept as they are.
+func Replace(path, oldPath string, e *Entry) error {
+	return Update(path, func(entries []Entry) []Entry {
+		out := entries[:0]
+		for _, old := range entries {
+			if old.Path != oldPath {
+				out = append(out, old)
+			} else if e != nil {
+				out = append(out, *e)
+				e = nil
+			}
+		}
+		if e != nil {
+			out = append(out, *e)
+		}
+		return out
+	})
+}
+
+// Prune drops the entries whose path no longer exists, e.g. deleted outside go-snip.
+func Prune(entries []Entry, exists func(path string) bool) (kept []Entry, removed int) {
+	kept = make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if exists(e.Path) {
+			kept = append(kept, e)
+		}
+	}
+	return kept, len(entries) - len(kept)
+}
+
+// HashFile returns the hex SHA-256 of the file at path.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Filter selects entries for Search. Zero fields match everything.
+type Filter struct {
+	// Query matches, case-insensitively, a substring of the name, file path, mode or a tag;
+	// every whitespace-separated word must match.
+	Query string
+	// Mode matches the capture mode exactly.
+	Mode string
+	// Since and Until bound the capture time (Until exclusive).
+	Since, Until time.Time
+}
+
+// Match reports whether e passes f.
+func (f Filter) Match(e Entry) bool {
+	if f.Mode != "" && !strings.EqualFold(f.Mode, e.Mode) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	haystack := strings.ToLower(strings.Join(append([]string{e.Name, e.Path, e.Mode}, e.Tags...), "\n"))
+	for _, word := range strings.Fields(strings.ToLower(f.Query)) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Newest returns entries sorted newest first (by capture time, then by index order).
+func Newest(entries []Entry) []Entry {
+	out := make([]Entry, len(entries))
+	for i, e := range entries {
+		out[len(entries)-1-i] = e
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	return out
+}
@@ -0,0 +1,211 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func paths(entries []Entry) []string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Path)
+	}
+	return out
+}
+
+func TestAppendLoad_RoundTripAndLatestWins(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sub", "history.jsonl")
+	if _, _, err := Load(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load(missing) error=%v want os.ErrNotExist", err)
+	}
+
+	a := Entry{Path: "a.png", Time: t0, Mode: "area", Display: 1, Rect: [4]int{1, 2, 3, 4}, Width: 2, Height: 2, Name: "bug", SHA256: "ab", Tags: []string{"png"}}
+	b := Entry{Path: "b.png", Time: t0.Add(time.Minute), Display: -1}
+	renamed := a
+	renamed.Name = "login bug"
+	for _, e := range []Entry{a, b, renamed} {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	f.WriteString(`{"path": "c.png", "ti`) // cut short by a crash
+	f.Close()
+
+	got, skipped, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if skipped != 1 {
+		t.Fatalf("skipped got=%d want=1", skipped)
+	}
+	if want := []Entry{b, renamed}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load() got=%+v want=%+v", got, want)
+	}
+
+	if err := Write(path, got[:1]); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	got, skipped, err = Load(path)
+	if err != nil || skipped != 0 || !reflect.DeepEqual(got, []Entry{b}) {
+		t.Fatalf("Load() after Write got=%+v skipped=%d err=%v", got, skipped, err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := Write(path, []Entry{{Path: "a"}, {Path: "b"}, {Path: "c"}}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if err := Replace(path, "b", &Entry{Path: "b2", Name: "renamed"}); err != nil {
+		t.Fatalf("Replace() error: %v", err)
+	}
+	if err := Replace(path, "a", nil); err != nil {
+		t.Fatalf("Replace(nil) error: %v", err)
+	}
+	got, _, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if want := []Entry{{Path: "b2", Name: "renamed"}, {Path: "c"}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after Replace got=%+v want=%+v", got, want)
+	}
+}
+
+func TestUpdate_KeepsConcurrentAppends(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := Append(path, Entry{Path: "/keep.png", Time: t0}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	const n = 50
+	done := make(chan error, 2)
+	go func() {
+		for i := range n {
+			if err := Append(path, Entry{Path: fmt.Sprintf("/%03d.png", i), Time: t0}); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	go func() {
+		for range n {
+			// A slow rewrite that keeps everything, like loadHistory checking every file.
+			if err := Update(path, func(entries []Entry) []Entry {
+				time.Sleep(time.Millisecond)
+				return entries
+			}); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for range 2 {
+		if err := <-done; err != nil {
+			t.Fatalf("concurrent Append/Update error: %v", err)
+		}
+	}
+
+	got, _, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(got) != n+1 {
+		t.Fatalf("Load() got %d entries want=%d (appends lost to rewrites)", len(got), n+1)
+	}
+	if _, err := os.Stat(path + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
+
+func TestAppend_TakesOverStaleLock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path+".lock", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(path, Entry{Path: "/a.png", Time: t0}); err != nil {
+		t.Fatalf("Append() with a stale lock error: %v", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	entries := []Entry{{Path: "a"}, {Path: "gone"}, {Path: "b"}}
+	kept, removed := Prune(entries, func(p string) bool { return p != "gone" })
+	if removed != 1 || !reflect.DeepEqual(paths(kept), []string{"a", "b"}) {
+		t.Fatalf("Prune() kept=%v removed=%d", paths(kept), removed)
+	}
+}
+
+func TestFilterAndNewest(t *testing.T) {
+	t.Parallel()
+
+	entries := []Entry{
+		{Path: "/shots/20250102_030405_000 - Login Bug.png", Time: t0, Mode: "area", Name: "Login Bug"},
+		{Path: "/shots/20250103_000000_000.gif", Time: t0.Add(24 * time.Hour), Mode: "record", Tags: []string{"recording"}},
+		{Path: "/shots/20250101_000000_000.png", Time: t0.Add(-24 * time.Hour), Mode: "full"},
+	}
+	cases := []struct {
+		filter Filter
+		want   []string
+	}{
+		{filter: Filter{}, want: []string{"/shots/20250103_000000_000.gif", "/shots/20250102_030405_000 - Login Bug.png", "/shots/20250101_000000_000.png"}},
+		{filter: Filter{Query: "bug login"}, want: []string{"/shots/20250102_030405_000 - Login Bug.png"}},
+		{filter: Filter{Query: "RECORDING"}, want: []string{"/shots/20250103_000000_000.gif"}},
+		{filter: Filter{Mode: "full"}, want: []string{"/shots/20250101_000000_000.png"}},
+		{filter: Filter{Since: t0, Until: t0.Add(time.Hour)}, want: []string{"/shots/20250102_030405_000 - Login Bug.png"}},
+		{filter: Filter{Query: "nothing"}, want: nil},
+	}
+	for _, tc := range cases {
+		var got []string
+		for _, e := range Newest(entries) {
+			if tc.filter.Match(e) {
+				got = append(got, e.Path)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("filter %+v got=%v want=%v", tc.filter, got, tc.want)
+		}
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "x")
+	if err := os.WriteFile(path, []byte("abc"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("HashFile() error: %v", err)
+	}
+	if want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Fatalf("HashFile() got=%s want=%s", got, want)
+	}
+}
@@ -0,0 +1,372 @@
+package hotkeys
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action names used as keys in config.Config.Hotkeys.
+const (
+	ActionFullscreen = "fullscreen"
+	ActionArea       = "area"
+	ActionSettings   = "settings"
+	// ActionWindow captures the window picked with a click.
+	ActionWindow = "window"
+	// ActionDelayedFullscreen and ActionDelayedArea wait for the configured delay first,
+	// so menus and tooltips can be opened before the capture.
+	ActionDelayedFullscreen = "delayedFullscreen"
+	ActionDelayedArea       = "delayedArea"
+	// ActionRepeatArea captures the last selected area again without showing the overlay.
+	ActionRepeatArea = "repeatArea"
+	// ActionTimelapse starts a timelapse, or stops the running one.
+	ActionTimelapse = "timelapse"
+	// ActionRecord selects an area and records it as an animation, or stops the recording.
+	ActionRecord = "record"
+	// ActionScroll selects an area and captures it while it is scrolled, or stops and saves
+	// the stitched image.
+	ActionScroll = "scroll"
+	// ActionHistory opens the history browser.
+	ActionHistory = "history"
+)
+
+// RegionActionPrefix starts the action names that capture a named region from the config,
+// e.g. "region:grafana-panel". They have no default binding.
+const RegionActionPrefix = "region:"
+
+// RegionAction returns the action name capturing the named region.
+func RegionAction(name string) string {
+	return RegionActionPrefix + name
+}
+
+// RegionName returns the region captured by action, if action is a region action.
+func RegionName(action string) (string, bool) {
+	name, ok := strings.CutPrefix(action, RegionActionPrefix)
+	return name, ok && name != ""
+}
+
+var (
+	ErrEmptyBinding  = errors.New("hotkeys: empty binding")
+	ErrUnknownKey    = errors.New("hotkeys: unknown key")
+	ErrUnknownAction = errors.New("hotkeys: unknown action")
+	ErrNoKey         = errors.New("hotkeys: binding has no key")
+	ErrMultipleKeys  = errors.New("hotkeys: binding has more than one key")
+	ErrNoModifier    = errors.New("hotkeys: binding needs at least one modifier")
+	ErrDuplicate     = errors.New("hotkeys: duplicate binding")
+)
+
+// Modifier is a platform-neutral modifier key. Callers map it onto the
+// platform-specific golang.design/x/hotkey modifiers.
+type Modifier int
+
+const (
+	ModCtrl Modifier = iota
+	ModAlt
+	ModShift
+	// ModSuper is the Windows key on Windows, Cmd on macOS and Mod4 on X11.
+	ModSuper
+)
+
+func (m Modifier) String() string {
+	switch m {
+	case ModCtrl:
+		return "Ctrl"
+	case ModAlt:
+		return "Alt"
+	case ModShift:
+		return "Shift"
+	case ModSuper:
+		return "Super"
+	}
+	return fmt.Sprintf("Modifier(%d)", int(m))
+}
+
+// Chord is a parsed binding such as Ctrl+Shift+1.
+//
+// Mods are sorted and de-duplicated; Key is the canonical key name (see KeyNames).
+type Chord struct {
+	Mods []Modifier
+	Key  string
+}
+
+// String returns the canonical form of c, e.g. "Ctrl+Shift+S".
+// Parse(c.String()) yields an equal Chord.
+func (c Chord) String() string {
+	parts := make([]string, 0, len(c.Mods)+1)
+	for _, m := range c.Mods {
+		parts = append(parts, m.String())
+	}
+	parts = append(parts, c.Key)
+	return strings.Join(parts, "+")
+}
+
+var modifierAliases = map[string]Modifier{
+	"ctrl":    ModCtrl,
+	"control": ModCtrl,
+	"alt":     ModAlt,
+	"option":  ModAlt,
+	"opt":     ModAlt,
+	"shift":   ModShift,
+	"super":   ModSuper,
+	"win":     ModSuper,
+	"cmd":     ModSuper,
+	"command": ModSuper,
+	"meta":    ModSuper,
+}
+
+var keyAliases = map[string]string{
+	"space":  "Space",
+	"enter":  "Return",
+	"return": "Return",
+	"esc":    "Escape",
+	"escape": "Escape",
+	"del":    "Delete",
+	"delete": "Delete",
+	"tab":    "Tab",
+	"left":   "Left",
+	"right":  "Right",
+	"up":     "Up",
+	"down":   "Down",
+}
+
+// KeyNames returns every canonical key name accepted by Parse, in a stable order.
+// The names match the golang.design/x/hotkey Key constants without the "Key" prefix.
+func KeyNames() []string {
+	names := make([]string, 0, 10+26+20+len(keyAliases))
+	for c := '0'; c <= '9'; c++ {
+		names = append(names, string(c))
+	}
+	for c := 'A'; c <= 'Z'; c++ {
+		names = append(names, string(c))
+	}
+	for i := 1; i <= 20; i++ {
+		names = append(names, fmt.Sprintf("F%d", i))
+	}
+	names = append(names, "Space", "Return", "Escape", "Delete", "Tab", "Left", "Right", "Up", "Down")
+	return names
+}
+
+func canonicalKey(s string) (string, bool) {
+	lower := strings.ToLower(s)
+	if k, ok := keyAliases[lower]; ok {
+		return k, true
+	}
+	if len(s) == 1 {
+		c := s[0]
+		switch {
+		case c >= '0' && c <= '9':
+			return s, true
+		case c >= 'a' && c <= 'z':
+			return strings.ToUpper(s), true
+		case c >= 'A' && c <= 'Z':
+			return s, true
+		}
+		return "", false
+	}
+	if isFunctionKey(strings.ToUpper(s)) {
+		return strings.ToUpper(s), true
+	}
+	return "", false
+}
+
+func isFunctionKey(upper string) bool {
+	if len(upper) < 2 || upper[0] != 'F' {
+		return false
+	}
+	n := 0
+	for _, c := range upper[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return upper[1] != '0' && n >= 1 && n <= 20
+}
+
+// Parse parses a binding such as "Ctrl+Alt+P" (case-insensitive, '+'-separated).
+//
+// A binding is any number of modifiers followed by exactly one key. At least one modifier
+// is required, except for function keys (F1..F20), which may be bound on their own.
+func Parse(s string) (Chord, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Chord{}, ErrEmptyBinding
+	}
+
+	var mods [4]bool
+	var key string
+	for _, raw := range strings.Split(s, "+") {
+		part := strings.TrimSpace(raw)
+		if part == "" {
+			return Chord{}, fmt.Errorf("%w in %q", ErrUnknownKey, s)
+		}
+		if m, ok := modifierAliases[strings.ToLower(part)]; ok {
+			mods[m] = true
+			continue
+		}
+		k, ok := canonicalKey(part)
+		if !ok {
+			return Chord{}, fmt.Errorf("%w %q in %q", ErrUnknownKey, part, s)
+		}
+		if key != "" {
+			return Chord{}, fmt.Errorf("%w: %q", ErrMultipleKeys, s)
+		}
+		key = k
+	}
+	if key == "" {
+		return Chord{}, fmt.Errorf("%w: %q", ErrNoKey, s)
+	}
+
+	c := Chord{Key: key}
+	for m, set := range mods {
+		if set {
+			c.Mods = append(c.Mods, Modifier(m))
+		}
+	}
+	if len(c.Mods) == 0 && !isFunctionKey(key) {
+		return Chord{}, fmt.Errorf("%w: %q", ErrNoModifier, s)
+	}
+	return c, nil
+}
+
+// Actions returns the known action names in a stable order.
+func Actions() []string {
+	return []string{ActionFullscreen, ActionArea, ActionWindow, ActionDelayedFullscreen, ActionDelayedArea, ActionRepeatArea, ActionTimelapse, ActionRecord, ActionScroll, ActionHistory, ActionSettings}
+}
+
+// Defaults returns the default bindings. Only the original three actions have one; the others
+// are opt-in, so an upgrade never grabs chords that other applications may already use.
+func Defaults() map[string]string {
+	return map[string]string{
+		ActionFullscreen: "Ctrl+Shift+1",
+		ActionArea:       "Ctrl+Shift+2",
+		ActionSettings:   "Ctrl+Shift+S",
+	}
+}
+
+// Resolve merges overrides onto Defaults and parses the result.
+//
+// An override with an empty value disables that action (it is omitted from the result).
+// Region actions (see RegionAction) are accepted for any region name.
+// All problems are reported together: unknown actions, unparsable bindings and chords bound
+// to more than one action.
+func Resolve(overrides map[string]string) (map[string]Chord, error) {
+	merged := Defaults()
+	for action, binding := range overrides {
+		merged[action] = binding
+	}
+
+	actions := make([]string, 0, len(merged))
+	for action := range merged {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	known := make(map[string]bool, len(Actions()))
+	for _, a := range Actions() {
+		known[a] = true
+	}
+
+	var errs []error
+	out := make(map[string]Chord, len(merged))
+	byChord := make(map[string]string, len(merged))
+	for _, action := range actions {
+		binding := strings.TrimSpace(merged[action])
+		if _, region := RegionName(action); !known[action] && !region {
+			errs = append(errs, fmt.Errorf("%w %q", ErrUnknownAction, action))
+			continue
+		}
+		if binding == "" {
+			continue
+		}
+		c, err := Parse(binding)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", action, err))
+			continue
+		}
+		if other, ok := byChord[c.String()]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s is bound to both %q and %q", ErrDuplicate, c, other, action))
+			continue
+		}
+		byChord[c.String()] = action
+		out[action] = c
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
+
+// ResolveLenient is Resolve for a config that may be wrong, as in the daemon: rather than
+// failing, it drops the bad overrides and resolves the rest. Overrides are checked in sorted
+// order; one with an unknown action, an unparsable binding or a chord an earlier override
+// already took is dropped, so that action keeps its default. A default whose chord an override
+// took is disabled, so a user's binding always beats a default. Every dropped override and
+// disabled default is reported in the error; the bindings are usable either way, unless the
+// defaults themselves are broken, in which case they are nil as from Resolve.
+func ResolveLenient(overrides map[string]string) (map[string]Chord, error) {
+	actions := make([]string, 0, len(overrides))
+	for action := range overrides {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	known := make(map[string]bool, len(Actions()))
+	for _, a := range Actions() {
+		known[a] = true
+	}
+
+	var errs []error
+	kept := make(map[string]string, len(overrides))
+	byChord := make(map[string]string, len(overrides))
+	for _, action := range actions {
+		binding := strings.TrimSpace(overrides[action])
+		if _, region := RegionName(action); !known[action] && !region {
+			errs = append(errs, fmt.Errorf("%w %q", ErrUnknownAction, action))
+			continue
+		}
+		if binding == "" {
+			kept[action] = ""
+			continue
+		}
+		c, err := Parse(binding)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", action, err))
+			continue
+		}
+		if other, ok := byChord[c.String()]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s is bound to both %q and %q (ignoring %q)", ErrDuplicate, c, other, action, action))
+			continue
+		}
+		byChord[c.String()] = action
+		kept[action] = binding
+	}
+
+	defaults := Defaults()
+	defaultActions := make([]string, 0, len(defaults))
+	for action := range defaults {
+		defaultActions = append(defaultActions, action)
+	}
+	sort.Strings(defaultActions)
+	for _, action := range defaultActions {
+		if _, ok := kept[action]; ok {
+			continue
+		}
+		c, err := Parse(defaults[action])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("default %s: %w", action, err))
+			continue
+		}
+		if other, ok := byChord[c.String()]; ok {
+			kept[action] = ""
+			errs = append(errs, fmt.Errorf("%w: %s is bound to %q, so %q has no hotkey", ErrDuplicate, c, other, action))
+		}
+	}
+
+	chords, err := Resolve(kept)
+	if err != nil {
+		return nil, errors.Join(append(errs, err)...)
+	}
+	return chords, errors.Join(errs...)
+}
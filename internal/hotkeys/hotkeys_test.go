@@ -0,0 +1,167 @@
+package hotkeys
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse_Canonicalizes(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{in: "Ctrl+Shift+1", want: "Ctrl+Shift+1"},
+		{in: " shift + ctrl + s ", want: "Ctrl+Shift+S"},
+		{in: "Control+Option+p", want: "Ctrl+Alt+P"},
+		{in: "Cmd+Esc", want: "Super+Escape"},
+		{in: "f9", want: "F9"},
+		{in: "Ctrl+Ctrl+Enter", want: "Ctrl+Return"},
+	}
+	for _, tc := range cases {
+		c, err := Parse(tc.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tc.in, err)
+		}
+		if got := c.String(); got != tc.want {
+			t.Fatalf("Parse(%q)=%q want=%q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in   string
+		want error
+	}{
+		{in: "", want: ErrEmptyBinding},
+		{in: "Ctrl+PrintScreen", want: ErrUnknownKey},
+		{in: "Ctrl++1", want: ErrUnknownKey},
+		{in: "Ctrl+F21", want: ErrUnknownKey},
+		{in: "Ctrl+Shift", want: ErrNoKey},
+		{in: "Ctrl+A+B", want: ErrMultipleKeys},
+		{in: "P", want: ErrNoModifier},
+	}
+	for _, tc := range cases {
+		_, err := Parse(tc.in)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("Parse(%q) error=%v want=%v", tc.in, err, tc.want)
+		}
+	}
+}
+
+func TestParse_AllKeyNamesRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, k := range KeyNames() {
+		c, err := Parse("Ctrl+" + k)
+		if err != nil {
+			t.Fatalf("Parse(Ctrl+%s) error: %v", k, err)
+		}
+		if c.Key != k {
+			t.Fatalf("Parse(Ctrl+%s).Key=%q", k, c.Key)
+		}
+	}
+}
+
+func TestResolve_DefaultsAndOverrides(t *testing.T) {
+	t.Parallel()
+
+	got, err := Resolve(map[string]string{ActionArea: "Ctrl+Alt+P", ActionSettings: "", ActionHistory: "Ctrl+Alt+H"})
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	// Actions added after the first three are only bound when configured.
+	want := map[string]Chord{
+		ActionFullscreen: {Mods: []Modifier{ModCtrl, ModShift}, Key: "1"},
+		ActionArea:       {Mods: []Modifier{ModCtrl, ModAlt}, Key: "P"},
+		ActionHistory:    {Mods: []Modifier{ModCtrl, ModAlt}, Key: "H"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Resolve() got=%v want=%v", got, want)
+	}
+}
+
+func TestResolve_RegionActions(t *testing.T) {
+	t.Parallel()
+
+	got, err := Resolve(map[string]string{RegionAction("grafana-panel"): "Ctrl+Alt+G", RegionAction("off"): ""})
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if c, ok := got["region:grafana-panel"]; !ok || c.String() != "Ctrl+Alt+G" {
+		t.Fatalf("Resolve() region binding got=%v ok=%v", c, ok)
+	}
+	if _, ok := got["region:off"]; ok {
+		t.Fatalf("Resolve() kept the disabled region binding")
+	}
+	if name, ok := RegionName("region:grafana-panel"); !ok || name != "grafana-panel" {
+		t.Fatalf("RegionName() got=%q,%v", name, ok)
+	}
+	for _, action := range []string{"region:", ActionArea} {
+		if _, ok := RegionName(action); ok {
+			t.Fatalf("RegionName(%q) ok=true", action)
+		}
+	}
+
+	_, err = Resolve(map[string]string{RegionAction("a"): "Ctrl+Shift+1", "region:": "Ctrl+Alt+X"})
+	for _, want := range []error{ErrDuplicate, ErrUnknownAction} {
+		if !errors.Is(err, want) {
+			t.Fatalf("Resolve() error=%v, want it to wrap %v", err, want)
+		}
+	}
+}
+
+func TestResolve_ReportsAllProblems(t *testing.T) {
+	t.Parallel()
+
+	_, err := Resolve(map[string]string{
+		ActionArea:     "shift+ctrl+1",
+		ActionSettings: "Ctrl+Nope",
+		"screenshot":   "Ctrl+9",
+	})
+	for _, want := range []error{ErrDuplicate, ErrUnknownKey, ErrUnknownAction} {
+		if !errors.Is(err, want) {
+			t.Fatalf("Resolve() error=%v, want it to wrap %v", err, want)
+		}
+	}
+}
+
+func TestResolveLenient_DropsOnlyBadOverrides(t *testing.T) {
+	t.Parallel()
+
+	got, err := ResolveLenient(map[string]string{
+		ActionArea:       "Ctrl+Shift+1", // takes the fullscreen default
+		ActionHistory:    "Ctrl+Alt+H",
+		ActionRecord:     "Ctrl+Alt+H", // taken by history, which sorts first
+		ActionScroll:     "Ctrl+Nope",
+		ActionSettings:   "",
+		"screenshot":     "Ctrl+9",
+		ActionRepeatArea: "Ctrl+Alt+R",
+	})
+	for _, want := range []error{ErrDuplicate, ErrUnknownKey, ErrUnknownAction} {
+		if !errors.Is(err, want) {
+			t.Fatalf("ResolveLenient() error=%v, want it to wrap %v", err, want)
+		}
+	}
+	want := map[string]Chord{
+		ActionArea:       {Mods: []Modifier{ModCtrl, ModShift}, Key: "1"},
+		ActionHistory:    {Mods: []Modifier{ModCtrl, ModAlt}, Key: "H"},
+		ActionRepeatArea: {Mods: []Modifier{ModCtrl, ModAlt}, Key: "R"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ResolveLenient() got=%v want=%v", got, want)
+	}
+
+	got, err = ResolveLenient(map[string]string{ActionArea: "Ctrl+Nope"})
+	if !errors.Is(err, ErrUnknownKey) || got[ActionArea].String() != "Ctrl+Shift+2" {
+		t.Fatalf("ResolveLenient(bad area) area=%v err=%v, want the default and ErrUnknownKey", got[ActionArea], err)
+	}
+	if got, err := ResolveLenient(nil); err != nil || len(got) != len(Defaults()) {
+		t.Fatalf("ResolveLenient(nil) got=%v err=%v, want the defaults", got, err)
+	}
+}
@@ -2,12 +2,22 @@ package overlay
 
 import (
 	"errors"
+	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"math"
+	"strconv"
+	"strings"
+
+	"go-snip/internal/capture"
 )
 
 var (
 	ErrNoActiveDisplays = errors.New("overlay: no active displays")
+	ErrNoWindows        = errors.New("overlay: no windows to select")
+	// ErrInvalidConstraint is returned by ParseConstraint for malformed sizes and ratios.
+	ErrInvalidConstraint = errors.New("overlay: invalid selection constraint")
 )
 
 type CanvasPos struct {
@@ -36,30 +46,494 @@ func clampRect(r, bounds image.Rectangle) image.Rectangle {
 	return r.Intersect(bounds)
 }
 
+// AreaOptions configure SelectArea.
+type AreaOptions struct {
+	// Constraint shapes the selection; see Constraint.
+	Constraint Constraint
+
+	// SaveRegion, if set, lets the user save the adjusted selection under a name (press N)
+	// without ending the selection. rect is in virtual-desktop coordinates; an error is shown
+	// to the user.
+	SaveRegion func(name string, rect image.Rectangle) error
+}
+
+// Constraint restricts the shape of an area selection. The zero value allows any rectangle.
+type Constraint struct {
+	// Aspect is a width:height ratio such as (16, 9); zero allows any ratio.
+	Aspect image.Point
+	// Size is a fixed selection size in pixels, placed with a click at its top-left corner.
+	// It overrides Aspect and Min.
+	Size image.Point
+	// Min is the smallest width and height a drag selects.
+	Min image.Point
+}
+
+// ParseConstraint parses the config strings of a Constraint: aspect as "W:H" (e.g. "16:9"),
+// size and minSize as "WxH" (e.g. "1280x720"). Empty strings leave that part unconstrained.
+func ParseConstraint(aspect, size, minSize string) (Constraint, error) {
+	var c Constraint
+	var errs []error
+	var err error
+	if c.Aspect, err = parsePair(aspect, ":", 1); err != nil {
+		errs = append(errs, fmt.Errorf("aspect: %w", err))
+	}
+	if c.Size, err = parsePair(size, "x", 1); err != nil {
+		errs = append(errs, fmt.Errorf("size: %w", err))
+	}
+	if c.Min, err = parsePair(minSize, "x", 0); err != nil {
+		errs = append(errs, fmt.Errorf("min size: %w", err))
+	}
+	if len(errs) > 0 {
+		return Constraint{}, errors.Join(errs...)
+	}
+	return c, nil
+}
+
+// parsePair parses "A<sep>B" into (A, B), both at least lowest; "" is (0, 0).
+func parsePair(s, sep string, lowest int) (image.Point, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return image.Point{}, nil
+	}
+	a, b, ok := strings.Cut(s, sep)
+	if !ok {
+		return image.Point{}, fmt.Errorf("%w: want N%sN, got %q", ErrInvalidConstraint, sep, s)
+	}
+	x, errX := strconv.Atoi(strings.TrimSpace(a))
+	y, errY := strconv.Atoi(strings.TrimSpace(b))
+	if errX != nil || errY != nil || x < lowest || y < lowest {
+		return image.Point{}, fmt.Errorf("%w: want N%sN with N >= %d, got %q", ErrInvalidConstraint, sep, lowest, s)
+	}
+	return image.Pt(x, y), nil
+}
+
+// IsZero reports whether c allows any rectangle.
+func (c Constraint) IsZero() bool {
+	return c == Constraint{}
+}
+
+// constraintAxis picks which side of a constrained rectangle drives the aspect ratio.
+type constraintAxis int
+
+const (
+	axisAuto constraintAxis = iota // whichever side the pointer has moved further along
+	axisX
+	axisY
+)
+
+// constrainRect returns the selection spanned from anchor towards p under c, inside bounds.
+//
+// A fixed Size is placed with its top-left corner at p and slid back inside bounds. Otherwise
+// the rectangle grows from anchor in the direction of p: first to the locked aspect ratio
+// (enclosing p unless axis says which side to follow), then up to Min. It is then shrunk,
+// keeping the ratio, until it fits in bounds, so near a display edge bounds win over Min.
+func constrainRect(anchor, p image.Point, c Constraint, axis constraintAxis, bounds image.Rectangle) image.Rectangle {
+	if bounds.Empty() {
+		return image.Rectangle{}
+	}
+	if c.Size != (image.Point{}) {
+		return slideInside(image.Rectangle{Min: p, Max: p.Add(c.Size)}, bounds)
+	}
+
+	anchor = clampPoint(anchor, bounds)
+	p = clampPoint(p, bounds)
+	sx, sy := 1, 1
+	if p.X < anchor.X {
+		sx = -1
+	}
+	if p.Y < anchor.Y {
+		sy = -1
+	}
+	w, h := abs(p.X-anchor.X), abs(p.Y-anchor.Y)
+
+	ax, ay := c.Aspect.X, c.Aspect.Y
+	locked := ax > 0 && ay > 0
+	if locked {
+		if axis == axisX || (axis == axisAuto && w*ay >= h*ax) {
+			h = divRound(w*ay, ax)
+		} else {
+			w = divRound(h*ax, ay)
+		}
+		if w < c.Min.X || h < c.Min.Y {
+			w = max(c.Min.X, divCeil(c.Min.Y*ax, ay))
+			h = divRound(w*ay, ax)
+		}
+	} else {
+		w, h = max(w, c.Min.X), max(h, c.Min.Y)
+	}
+
+	// Room between the anchor and the bounds edge in the direction of the drag.
+	roomX, roomY := bounds.Max.X-anchor.X, bounds.Max.Y-anchor.Y
+	if sx < 0 {
+		roomX = anchor.X - bounds.Min.X
+	}
+	if sy < 0 {
+		roomY = anchor.Y - bounds.Min.Y
+	}
+	if w > roomX {
+		w = roomX
+		if locked {
+			h = w * ay / ax
+		}
+	}
+	if h > roomY {
+		h = roomY
+		if locked {
+			w = h * ax / ay
+		}
+	}
+
+	return normalizeRect(image.Rectangle{Min: anchor, Max: anchor.Add(image.Pt(sx*w, sy*h))})
+}
+
+// slideInside moves r so it lies in bounds without changing its size; a rectangle larger
+// than bounds is aligned to bounds' top-left corner and clamped.
+func slideInside(r, bounds image.Rectangle) image.Rectangle {
+	d := image.Point{}
+	if r.Max.X > bounds.Max.X {
+		d.X = bounds.Max.X - r.Max.X
+	}
+	if r.Max.Y > bounds.Max.Y {
+		d.Y = bounds.Max.Y - r.Max.Y
+	}
+	if r.Min.X+d.X < bounds.Min.X {
+		d.X = bounds.Min.X - r.Min.X
+	}
+	if r.Min.Y+d.Y < bounds.Min.Y {
+		d.Y = bounds.Min.Y - r.Min.Y
+	}
+	return clampRect(r.Add(d), bounds)
+}
+
+// clampPoint returns p moved onto bounds (Max inclusive, as selection corners may lie on it).
+func clampPoint(p image.Point, bounds image.Rectangle) image.Point {
+	return image.Pt(min(max(p.X, bounds.Min.X), bounds.Max.X), min(max(p.Y, bounds.Min.Y), bounds.Max.Y))
+}
+
+func divRound(a, b int) int {
+	return (a + b/2) / b
+}
+
+func divCeil(a, b int) int {
+	return (a + b - 1) / b
+}
+
 // canvasRectToScreenRect converts a start/end drag in canvas coordinates into a screen-space
 // rectangle compatible with screenshot.CaptureRect on the provided display.
 //
 // The conversion scales the drag positions by the ratio between the display pixel bounds
-// and the canvas logical size, and offsets by displayBounds.Min.
-func canvasRectToScreenRect(start, end CanvasPos, canvasSize CanvasSize, displayBounds image.Rectangle) image.Rectangle {
+// and the canvas logical size, and offsets by displayBounds.Min. The result is clamped to
+// displayBounds, or to within if given, so a drag that leaves the display can keep its part
+// on the neighbouring ones.
+func canvasRectToScreenRect(start, end CanvasPos, canvasSize CanvasSize, displayBounds image.Rectangle, within ...image.Rectangle) image.Rectangle {
 	if canvasSize.W <= 0 || canvasSize.H <= 0 || displayBounds.Dx() <= 0 || displayBounds.Dy() <= 0 {
 		return image.Rectangle{}
 	}
 
+	p1 := canvasToScreenPoint(start, canvasSize, displayBounds)
+	p2 := canvasToScreenPoint(end, canvasSize, displayBounds)
+
+	r := image.Rectangle{Min: p1, Max: p2}
+	r = normalizeRect(r)
+	if len(within) > 0 {
+		return clampRect(r, within[0])
+	}
+	return clampRect(r, displayBounds)
+}
+
+// canvasDrag is a drag in the canvas coordinates of the overlay that received the press.
+// That overlay keeps receiving the pointer when the drag leaves its display, so both ends
+// convert with its scale.
+type canvasDrag struct {
+	start, end    CanvasPos
+	canvasSize    CanvasSize
+	displayBounds image.Rectangle
+	desktop       image.Rectangle
+}
+
+// rect is the selection the drag spans on the virtual desktop.
+func (d *canvasDrag) rect() image.Rectangle {
+	return canvasRectToScreenRect(d.start, d.end, d.canvasSize, d.displayBounds, d.desktop)
+}
+
+// canvasToScreenPoint converts a canvas position on a window covering displayBounds into a
+// virtual-desktop pixel position. Each display has its own scale (pixels per canvas unit), so
+// the same canvas position maps differently on a 1x and a 2x display.
+//
+// The result is not clamped: while dragging, the window that received the mouse press keeps
+// receiving positions outside its own canvas, and those extrapolate onto neighbouring displays.
+func canvasToScreenPoint(p CanvasPos, canvasSize CanvasSize, displayBounds image.Rectangle) image.Point {
+	if canvasSize.W <= 0 || canvasSize.H <= 0 {
+		return displayBounds.Min
+	}
 	sx := float64(displayBounds.Dx()) / float64(canvasSize.W)
 	sy := float64(displayBounds.Dy()) / float64(canvasSize.H)
 
-	toScreen := func(p CanvasPos) image.Point {
-		// Round to nearest pixel to reduce off-by-one drift under scaling.
-		x := int(math.Round(float64(p.X) * sx))
-		y := int(math.Round(float64(p.Y) * sy))
-		return image.Pt(displayBounds.Min.X+x, displayBounds.Min.Y+y)
+	// Round to nearest pixel to reduce off-by-one drift under scaling.
+	x := int(math.Round(float64(p.X) * sx))
+	y := int(math.Round(float64(p.Y) * sy))
+	return image.Pt(displayBounds.Min.X+x, displayBounds.Min.Y+y)
+}
+
+// screenRectToCanvasRect is the inverse of canvasRectToScreenRect for a window covering
+// displayBounds: it returns the canvas position and size of the part of r on that display.
+// ok is false if r doesn't intersect the display.
+func screenRectToCanvasRect(r image.Rectangle, canvasSize CanvasSize, displayBounds image.Rectangle) (pos CanvasPos, size CanvasSize, ok bool) {
+	if canvasSize.W <= 0 || canvasSize.H <= 0 || displayBounds.Dx() <= 0 || displayBounds.Dy() <= 0 {
+		return CanvasPos{}, CanvasSize{}, false
+	}
+	vis := clampRect(normalizeRect(r), displayBounds)
+	if vis.Empty() {
+		return CanvasPos{}, CanvasSize{}, false
 	}
 
-	p1 := toScreen(start)
-	p2 := toScreen(end)
+	sx := float64(canvasSize.W) / float64(displayBounds.Dx())
+	sy := float64(canvasSize.H) / float64(displayBounds.Dy())
+	local := vis.Sub(displayBounds.Min)
+	pos = CanvasPos{X: float32(float64(local.Min.X) * sx), Y: float32(float64(local.Min.Y) * sy)}
+	size = CanvasSize{W: float32(float64(local.Dx()) * sx), H: float32(float64(local.Dy()) * sy)}
+	return pos, size, true
+}
 
-	r := image.Rectangle{Min: p1, Max: p2}
+// screenSelection returns the normalized rectangle spanned by two virtual-desktop points,
+// clamped to desktop (the union of all display bounds).
+func screenSelection(start, end image.Point, desktop image.Rectangle) image.Rectangle {
+	return clampRect(normalizeRect(image.Rectangle{Min: start, Max: end}), desktop)
+}
+
+// windowSelection returns the rectangle of the topmost window under p (its frame or client
+// area), clamped to desktop, or an empty rectangle if p is over no window.
+func windowSelection(p image.Point, windows []capture.Window, includeDecorations bool, desktop image.Rectangle) image.Rectangle {
+	w, ok := capture.WindowAt(p, windows)
+	if !ok {
+		return image.Rectangle{}
+	}
+	return clampRect(w.Rect(includeDecorations), desktop)
+}
+
+const (
+	// snapRadius is how far (in pixels) a selection edge may move to reach a snap target.
+	snapRadius = 8
+	// snapEdgeThreshold is the minimum mean luminance step (0..255) along a selection edge
+	// that counts as a strong image edge.
+	snapEdgeThreshold = 24
+)
+
+// lumaMap is a grayscale copy of the frozen background in virtual-desktop coordinates,
+// used to find strong edges to snap the selection to.
+type lumaMap struct {
+	rect image.Rectangle
+	luma []uint8
+}
+
+// newLumaMap converts img to luminance. origin is the virtual-desktop position of
+// img.Bounds().Min.
+func newLumaMap(img image.Image, origin image.Point) *lumaMap {
+	b := img.Bounds()
+	m := &lumaMap{rect: image.Rectangle{Min: origin, Max: origin.Add(b.Size())}, luma: make([]uint8, b.Dx()*b.Dy())}
+	if rgba, ok := img.(*image.RGBA); ok {
+		// Fast path for captures: read the pixel buffer directly instead of boxing every colour.
+		i := 0
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := rgba.Pix[rgba.PixOffset(b.Min.X, y):]
+			for x := 0; x < b.Dx(); x++ {
+				p := row[4*x : 4*x+3]
+				m.luma[i] = uint8((299*uint32(p[0]) + 587*uint32(p[1]) + 114*uint32(p[2])) / 1000)
+				i++
+			}
+		}
+		return m
+	}
+	i := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			// Rec. 601 weights on 16-bit channels, scaled back to 8 bits.
+			m.luma[i] = uint8((299*r + 587*g + 114*bl) / 1000 >> 8)
+			i++
+		}
+	}
+	return m
+}
+
+func (m *lumaMap) at(x, y int) int {
+	return int(m.luma[(y-m.rect.Min.Y)*m.rect.Dx()+(x-m.rect.Min.X)])
+}
+
+// columnEdge returns the mean luminance step across the vertical boundary between columns x-1
+// and x, over rows [y0, y1). Boundaries at or outside the map edge have no strength.
+func (m *lumaMap) columnEdge(x, y0, y1 int) float64 {
+	if m == nil || x <= m.rect.Min.X || x >= m.rect.Max.X {
+		return 0
+	}
+	y0, y1 = max(y0, m.rect.Min.Y), min(y1, m.rect.Max.Y)
+	if y0 >= y1 {
+		return 0
+	}
+	sum := 0
+	for y := y0; y < y1; y++ {
+		sum += abs(m.at(x-1, y) - m.at(x, y))
+	}
+	return float64(sum) / float64(y1-y0)
+}
+
+// rowEdge is columnEdge for the horizontal boundary between rows y-1 and y, over columns [x0, x1).
+func (m *lumaMap) rowEdge(y, x0, x1 int) float64 {
+	if m == nil || y <= m.rect.Min.Y || y >= m.rect.Max.Y {
+		return 0
+	}
+	x0, x1 = max(x0, m.rect.Min.X), min(x1, m.rect.Max.X)
+	if x0 >= x1 {
+		return 0
+	}
+	sum := 0
+	for x := x0; x < x1; x++ {
+		sum += abs(m.at(x, y-1) - m.at(x, y))
+	}
+	return float64(sum) / float64(x1-x0)
+}
+
+// snapCoord returns the boundary near v to snap to. The nearest guide within radius wins,
+// since guides (window bounds) are exact; otherwise the strongest boundary within radius whose
+// strength reaches snapEdgeThreshold, preferring the nearest on ties. Without either, v is kept.
+func snapCoord(v, radius int, guides []int, strength func(b int) float64) int {
+	best, bestDist := v, radius+1
+	for _, g := range guides {
+		if d := abs(g - v); d < bestDist {
+			best, bestDist = g, d
+		}
+	}
+	if bestDist <= radius {
+		return best
+	}
+
+	best, bestStrength, bestDist := v, float64(snapEdgeThreshold), radius+1
+	for b := v - radius; b <= v+radius; b++ {
+		s := strength(b)
+		d := abs(b - v)
+		if s > bestStrength || (s == bestStrength && s >= snapEdgeThreshold && d < bestDist) {
+			best, bestStrength, bestDist = b, s, d
+		}
+	}
+	return best
+}
+
+// snapRect moves each edge of r to a nearby guide edge (e.g. known window bounds) or strong
+// edge in edges (which may be nil). Each edge's strength is measured along r's span, so a
+// border running the length of the selection wins over a short one. If snapping would collapse
+// r, it is returned unchanged.
+func snapRect(r image.Rectangle, edges *lumaMap, guides []image.Rectangle, radius int) image.Rectangle {
 	r = normalizeRect(r)
-	return clampRect(r, displayBounds)
+	var xs, ys []int
+	for _, g := range guides {
+		xs = append(xs, g.Min.X, g.Max.X)
+		ys = append(ys, g.Min.Y, g.Max.Y)
+	}
+	column := func(b int) float64 { return edges.columnEdge(b, r.Min.Y, r.Max.Y) }
+	row := func(b int) float64 { return edges.rowEdge(b, r.Min.X, r.Max.X) }
+
+	snapped := image.Rectangle{
+		Min: image.Pt(snapCoord(r.Min.X, radius, xs, column), snapCoord(r.Min.Y, radius, ys, row)),
+		Max: image.Pt(snapCoord(r.Max.X, radius, xs, column), snapCoord(r.Max.Y, radius, ys, row)),
+	}
+	if snapped.Empty() {
+		return r
+	}
+	return snapped
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+const (
+	// loupePixels is the edge of the magnified neighbourhood, in screen pixels (odd, so the
+	// cursor pixel is centred).
+	loupePixels = 15
+	// loupeZoom is the magnification: each screen pixel becomes a loupeZoom x loupeZoom block.
+	loupeZoom = 8
+	// loupeMargin is the canvas distance between the cursor and the loupe.
+	loupeMargin = 24
+)
+
+var (
+	loupeOutside   = color.RGBA{R: 32, G: 32, B: 32, A: 255}
+	loupeCrosshair = color.RGBA{R: 255, G: 40, B: 40, A: 255}
+)
+
+// loupeImage magnifies the pixels x picks from bg around center (in bg's pixel coordinates)
+// into a (pixels*zoom)-square image, nearest-neighbour, so individual pixels stay crisp.
+// Pixels outside bg are dark gray. Crosshair guides run through the centre row and column,
+// and the centre pixel is outlined.
+func loupeImage(bg image.Image, center image.Point, pixels, zoom int) *image.RGBA {
+	size := pixels * zoom
+	out := image.NewRGBA(image.Rect(0, 0, size, size))
+	half := pixels / 2
+	b := bg.Bounds()
+	for py := 0; py < pixels; py++ {
+		for px := 0; px < pixels; px++ {
+			src := image.Pt(center.X-half+px, center.Y-half+py)
+			var c color.Color = loupeOutside
+			if src.In(b) {
+				c = bg.At(src.X, src.Y)
+			}
+			cell := image.Rect(px*zoom, py*zoom, (px+1)*zoom, (py+1)*zoom)
+			draw.Draw(out, cell, image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+
+	// Guides: one line through the middle of the centre row and column, leaving the centre
+	// pixel itself visible inside its outline.
+	mid := half*zoom + zoom/2
+	lo, hi := half*zoom, (half+1)*zoom
+	for i := 0; i < size; i++ {
+		if i < lo || i >= hi {
+			out.SetRGBA(i, mid, loupeCrosshair)
+			out.SetRGBA(mid, i, loupeCrosshair)
+		}
+	}
+	for i := lo; i < hi; i++ {
+		out.SetRGBA(i, lo, loupeCrosshair)
+		out.SetRGBA(i, hi-1, loupeCrosshair)
+		out.SetRGBA(lo, i, loupeCrosshair)
+		out.SetRGBA(hi-1, i, loupeCrosshair)
+	}
+	return out
+}
+
+// loupePosition places a loupe of size loupe below-right of the cursor, flipping to the other
+// side of the cursor along any axis where it would leave the canvas.
+func loupePosition(cursor CanvasPos, canvasSize, loupe CanvasSize) CanvasPos {
+	pos := CanvasPos{X: cursor.X + loupeMargin, Y: cursor.Y + loupeMargin}
+	if pos.X+loupe.W > canvasSize.W {
+		pos.X = cursor.X - loupeMargin - loupe.W
+	}
+	if pos.Y+loupe.H > canvasSize.H {
+		pos.Y = cursor.Y - loupeMargin - loupe.H
+	}
+	return pos
+}
+
+// selectedRect is the rectangle a selection overlay finishes with: the window under the cursor
+// while picking one, else the area selection, whose drags span canvasRectToScreenRect. The
+// loupe readout shows the size of the same rectangle, so the readout is what gets captured.
+func selectedRect(sel *selectionMachine, pickingWindow bool, hover image.Rectangle) image.Rectangle {
+	if pickingWindow {
+		return hover
+	}
+	return sel.Rect()
+}
+
+// cursorReadout is the text under the loupe: the cursor position relative to displayBounds
+// (the coordinates `capture region --rect` takes) and, while selecting, the selection size in
+// real pixels.
+func cursorReadout(cursor image.Point, displayBounds, selection image.Rectangle, selecting bool) string {
+	local := cursor.Sub(displayBounds.Min)
+	if !selecting {
+		return fmt.Sprintf("%d, %d", local.X, local.Y)
+	}
+	return fmt.Sprintf("%d, %d   %d×%d", local.X, local.Y, selection.Dx(), selection.Dy())
 }
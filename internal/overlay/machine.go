@@ -0,0 +1,336 @@
+package overlay
+
+import "image"
+
+// selectionPhase is where an area selection is in its life cycle.
+type selectionPhase int
+
+const (
+	// phaseIdle waits for the first press.
+	phaseIdle selectionPhase = iota
+	// phaseDragging follows the pointer from the press point.
+	phaseDragging
+	// phaseAdjusting keeps the released selection on screen for keyboard and handle refinement.
+	phaseAdjusting
+	// phaseResizing follows the pointer with one handle of the adjusting selection.
+	phaseResizing
+	// phaseDone and phaseCancelled are final.
+	phaseDone
+	phaseCancelled
+)
+
+// handle is a grab point of the adjusting selection.
+type handle int
+
+const (
+	handleNone handle = iota
+	handleMove        // inside the selection: moves it
+	handleN
+	handleS
+	handleE
+	handleW
+	handleNE
+	handleNW
+	handleSE
+	handleSW
+)
+
+// Keys understood by selectionMachine.Key; they match Fyne's key names.
+const (
+	keyLeft   = "Left"
+	keyRight  = "Right"
+	keyUp     = "Up"
+	keyDown   = "Down"
+	keyReturn = "Return"
+	keyEnter  = "KP_Enter"
+	keyEscape = "Escape"
+)
+
+// selectionMachine is the area-selection state machine, kept free of Fyne so it can be tested.
+// All points and rectangles are in virtual-desktop pixels.
+//
+// A drag selects a rectangle, releasing enters the adjust phase, and Enter confirms it:
+//
+//	idle --press--> dragging --release--> adjusting --Enter--> done
+//	                               adjusting --press on handle--> resizing --release--> adjusting
+//	                               adjusting --press outside--> dragging (a new selection)
+//	any --Escape--> cancelled
+//
+// While adjusting, arrows nudge the selection by one pixel and Shift+arrows move its
+// bottom-right corner. The selection always stays inside desktop.
+//
+// A non-zero constraint shapes drags and handle resizes (see constrainRect) unless free is
+// set; with a fixed size, every handle moves the selection instead of resizing it.
+type selectionMachine struct {
+	desktop image.Rectangle
+	// snap, if set, adjusts the rectangle of a fresh unconstrained drag (see snapRect).
+	snap func(image.Rectangle) image.Rectangle
+	// span, if set, returns the rectangle of a fresh unconstrained drag, computed from the
+	// pointer positions start and current were converted from; else the drag spans those two.
+	span       func() image.Rectangle
+	constraint Constraint
+	free       bool
+
+	phase selectionPhase
+	// start and current are the drag's press and latest points.
+	start, current image.Point
+	// rect is the selection while adjusting or resizing.
+	rect image.Rectangle
+
+	// grab, grabAt and grabRect describe the handle being dragged while resizing.
+	grab     handle
+	grabAt   image.Point
+	grabRect image.Rectangle
+}
+
+// Rect returns the current selection: the live drag while dragging, else the adjusted rectangle.
+func (m *selectionMachine) Rect() image.Rectangle {
+	if m.phase == phaseDragging {
+		if m.constrained() {
+			return constrainRect(m.start, m.current, m.constraint, axisAuto, m.desktop)
+		}
+		r := screenSelection(m.start, m.current, m.desktop)
+		if m.span != nil {
+			r = m.span()
+		}
+		if m.snap != nil {
+			r = clampRect(m.snap(r), m.desktop)
+		}
+		return r
+	}
+	return m.rect
+}
+
+// constrained reports whether the constraint currently applies.
+func (m *selectionMachine) constrained() bool {
+	return !m.free && !m.constraint.IsZero()
+}
+
+// Active reports whether there is a selection to draw.
+func (m *selectionMachine) Active() bool {
+	switch m.phase {
+	case phaseDragging, phaseAdjusting, phaseResizing, phaseDone:
+		return true
+	}
+	return false
+}
+
+// Adjusting reports whether the selection is waiting for refinement or confirmation.
+func (m *selectionMachine) Adjusting() bool {
+	return m.phase == phaseAdjusting || m.phase == phaseResizing
+}
+
+// Press handles a mouse press at p. tolerance is the handle hit size in pixels.
+func (m *selectionMachine) Press(p image.Point, tolerance int) {
+	switch m.phase {
+	case phaseIdle:
+		m.startDrag(p)
+	case phaseAdjusting:
+		if h := handleAt(p, m.rect, tolerance); h != handleNone {
+			if m.constrained() && m.constraint.Size != (image.Point{}) {
+				h = handleMove
+			}
+			m.phase = phaseResizing
+			m.grab, m.grabAt, m.grabRect = h, p, m.rect
+			return
+		}
+		m.startDrag(p)
+	}
+}
+
+func (m *selectionMachine) startDrag(p image.Point) {
+	m.phase = phaseDragging
+	m.start, m.current = p, p
+}
+
+// Drag handles pointer movement with the button held.
+func (m *selectionMachine) Drag(p image.Point) {
+	switch m.phase {
+	case phaseDragging:
+		m.current = p
+	case phaseResizing:
+		m.rect = dragHandle(m.grabRect, m.grab, p.Sub(m.grabAt), m.desktop)
+		if m.grab != handleMove && m.constrained() {
+			m.rect = constrainHandle(m.grabRect, m.rect, m.grab, m.constraint, m.desktop)
+		}
+	}
+}
+
+// Release handles the mouse button going up at p. A drag too small to select anything returns
+// to the previous selection if there was one, else to idle; it never cancels.
+func (m *selectionMachine) Release(p image.Point) {
+	switch m.phase {
+	case phaseDragging:
+		m.current = p
+		r := m.Rect()
+		if r.Empty() {
+			m.phase = phaseIdle
+			if !m.rect.Empty() {
+				m.phase = phaseAdjusting
+			}
+			return
+		}
+		m.rect = r
+		m.phase = phaseAdjusting
+	case phaseResizing:
+		m.Drag(p)
+		m.phase = phaseAdjusting
+	}
+}
+
+// Key handles a key press. shift reports whether Shift is held.
+func (m *selectionMachine) Key(key string, shift bool) {
+	if key == keyEscape {
+		m.phase = phaseCancelled
+		return
+	}
+	if m.phase != phaseAdjusting {
+		return
+	}
+
+	var d image.Point
+	switch key {
+	case keyReturn, keyEnter:
+		m.phase = phaseDone
+		return
+	case keyLeft:
+		d = image.Pt(-1, 0)
+	case keyRight:
+		d = image.Pt(1, 0)
+	case keyUp:
+		d = image.Pt(0, -1)
+	case keyDown:
+		d = image.Pt(0, 1)
+	default:
+		return
+	}
+	if shift {
+		m.rect = dragHandle(m.rect, handleSE, d, m.desktop)
+		return
+	}
+	m.rect = dragHandle(m.rect, handleMove, d, m.desktop)
+}
+
+// Done reports whether the selection was confirmed; Cancelled whether it was abandoned.
+func (m *selectionMachine) Done() bool      { return m.phase == phaseDone }
+func (m *selectionMachine) Cancelled() bool { return m.phase == phaseCancelled }
+
+// handleAt returns the handle of r under p: a corner, an edge within tolerance pixels of it,
+// the inside (move), or none.
+func handleAt(p image.Point, r image.Rectangle, tolerance int) handle {
+	if r.Empty() || !p.In(r.Inset(-tolerance)) {
+		return handleNone
+	}
+	near := func(v, edge int) bool { return abs(v-edge) <= tolerance }
+	n, s := near(p.Y, r.Min.Y), near(p.Y, r.Max.Y)
+	w, e := near(p.X, r.Min.X), near(p.X, r.Max.X)
+	switch {
+	case n && w:
+		return handleNW
+	case n && e:
+		return handleNE
+	case s && w:
+		return handleSW
+	case s && e:
+		return handleSE
+	case n:
+		return handleN
+	case s:
+		return handleS
+	case w:
+		return handleW
+	case e:
+		return handleE
+	}
+	return handleMove
+}
+
+// constrainHandle applies c to resized, the result of dragging handle h of from: the corner
+// or edge opposite h stays put and the dragged side drives the aspect ratio.
+func constrainHandle(from, resized image.Rectangle, h handle, c Constraint, desktop image.Rectangle) image.Rectangle {
+	anchor, far := from.Min, resized.Max
+	switch h {
+	case handleN, handleNE:
+		anchor.Y, far.Y = from.Max.Y, resized.Min.Y
+	case handleW, handleSW:
+		anchor.X, far.X = from.Max.X, resized.Min.X
+	case handleNW:
+		anchor, far = from.Max, resized.Min
+	}
+	axis := axisAuto
+	switch h {
+	case handleN, handleS:
+		axis = axisY
+	case handleE, handleW:
+		axis = axisX
+	}
+	return constrainRect(anchor, far, c, axis, desktop)
+}
+
+// dragHandle returns r with handle h moved by d. Moving keeps the size and slides the
+// rectangle back inside desktop; resizing keeps at least one pixel and clamps to desktop.
+func dragHandle(r image.Rectangle, h handle, d image.Point, desktop image.Rectangle) image.Rectangle {
+	if h == handleMove {
+		moved := r.Add(d)
+		// Slide back inside rather than shrink, so nudging against an edge keeps the size.
+		if moved.Min.X < desktop.Min.X {
+			moved = moved.Add(image.Pt(desktop.Min.X-moved.Min.X, 0))
+		}
+		if moved.Max.X > desktop.Max.X {
+			moved = moved.Add(image.Pt(desktop.Max.X-moved.Max.X, 0))
+		}
+		if moved.Min.Y < desktop.Min.Y {
+			moved = moved.Add(image.Pt(0, desktop.Min.Y-moved.Min.Y))
+		}
+		if moved.Max.Y > desktop.Max.Y {
+			moved = moved.Add(image.Pt(0, desktop.Max.Y-moved.Max.Y))
+		}
+		return clampRect(moved, desktop)
+	}
+
+	out := r
+	switch h {
+	case handleN, handleNE, handleNW:
+		out.Min.Y = min(r.Min.Y+d.Y, r.Max.Y-1)
+	case handleS, handleSE, handleSW:
+		out.Max.Y = max(r.Max.Y+d.Y, r.Min.Y+1)
+	}
+	switch h {
+	case handleW, handleNW, handleSW:
+		out.Min.X = min(r.Min.X+d.X, r.Max.X-1)
+	case handleE, handleNE, handleSE:
+		out.Max.X = max(r.Max.X+d.X, r.Min.X+1)
+	}
+	clamped := clampRect(out, desktop)
+	if clamped.Empty() {
+		return r
+	}
+	return clamped
+}
+
+// handleSize is the side, in pixels, of the handles drawn on an adjusting selection;
+// a press within handleTolerance pixels of an edge grabs that edge.
+const (
+	handleSize      = 8
+	handleTolerance = 6
+)
+
+// handleRects returns the squares drawn for the eight resize handles of r: the corners
+// and the edge midpoints, each size pixels wide.
+func handleRects(r image.Rectangle, size int) []image.Rectangle {
+	if r.Empty() {
+		return nil
+	}
+	mx, my := (r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2
+	points := []image.Point{
+		{r.Min.X, r.Min.Y}, {mx, r.Min.Y}, {r.Max.X, r.Min.Y},
+		{r.Min.X, my}, {r.Max.X, my},
+		{r.Min.X, r.Max.Y}, {mx, r.Max.Y}, {r.Max.X, r.Max.Y},
+	}
+	half := size / 2
+	out := make([]image.Rectangle, len(points))
+	for i, p := range points {
+		out[i] = image.Rect(p.X-half, p.Y-half, p.X-half+size, p.Y-half+size)
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+package overlay
+
+import (
+	"image"
+	"testing"
+)
+
+func newTestMachine() *selectionMachine {
+	return &selectionMachine{desktop: image.Rect(0, 0, 100, 80)}
+}
+
+// dragSelect drags from a to b and releases, leaving m adjusting.
+func dragSelect(m *selectionMachine, a, b image.Point) {
+	m.Press(a, handleTolerance)
+	m.Drag(b)
+	m.Release(b)
+}
+
+func TestSelectionMachine_ReleaseEntersAdjustPhase(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	dragSelect(m, image.Pt(10, 10), image.Pt(40, 30))
+	if !m.Adjusting() || m.Done() {
+		t.Fatalf("after release: adjusting=%v done=%v want=true,false", m.Adjusting(), m.Done())
+	}
+	if got, want := m.Rect(), image.Rect(10, 10, 40, 30); got != want {
+		t.Fatalf("Rect() got=%v want=%v", got, want)
+	}
+
+	m.Key(keyReturn, false)
+	if !m.Done() {
+		t.Fatalf("Enter did not confirm the selection")
+	}
+	if got, want := m.Rect(), image.Rect(10, 10, 40, 30); got != want {
+		t.Fatalf("confirmed Rect() got=%v want=%v", got, want)
+	}
+}
+
+func TestSelectionMachine_ArrowsNudgeAndShiftResizes(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	dragSelect(m, image.Pt(10, 10), image.Pt(40, 30))
+
+	m.Key(keyRight, false)
+	m.Key(keyDown, false)
+	m.Key(keyDown, false)
+	if got, want := m.Rect(), image.Rect(11, 12, 41, 32); got != want {
+		t.Fatalf("after nudges got=%v want=%v", got, want)
+	}
+
+	m.Key(keyLeft, true)
+	m.Key(keyUp, true)
+	if got, want := m.Rect(), image.Rect(11, 12, 40, 31); got != want {
+		t.Fatalf("after Shift+arrows got=%v want=%v", got, want)
+	}
+
+	m.Key(keyEnter, false)
+	if !m.Done() {
+		t.Fatalf("keypad Enter did not confirm the selection")
+	}
+}
+
+func TestSelectionMachine_NudgeStaysOnDesktopAndKeepsSize(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	dragSelect(m, image.Pt(0, 0), image.Pt(20, 10))
+	m.Key(keyLeft, false)
+	m.Key(keyUp, false)
+	if got, want := m.Rect(), image.Rect(0, 0, 20, 10); got != want {
+		t.Fatalf("nudge past the corner got=%v want=%v", got, want)
+	}
+
+	// Shrinking never collapses the selection.
+	for i := 0; i < 30; i++ {
+		m.Key(keyLeft, true)
+	}
+	if got, want := m.Rect(), image.Rect(0, 0, 1, 10); got != want {
+		t.Fatalf("shrink to minimum got=%v want=%v", got, want)
+	}
+}
+
+func TestSelectionMachine_HandleDragResizes(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	dragSelect(m, image.Pt(10, 10), image.Pt(40, 30))
+
+	// Grab the bottom-right corner and pull it out.
+	m.Press(image.Pt(41, 29), handleTolerance)
+	if m.phase != phaseResizing || m.grab != handleSE {
+		t.Fatalf("press on corner: phase=%v grab=%v want=%v,%v", m.phase, m.grab, phaseResizing, handleSE)
+	}
+	m.Drag(image.Pt(51, 39))
+	m.Release(image.Pt(51, 39))
+	if got, want := m.Rect(), image.Rect(10, 10, 50, 40); got != want {
+		t.Fatalf("after corner drag got=%v want=%v", got, want)
+	}
+
+	// The top edge only moves vertically.
+	m.Press(image.Pt(25, 10), handleTolerance)
+	m.Drag(image.Pt(90, 5))
+	m.Release(image.Pt(90, 5))
+	if got, want := m.Rect(), image.Rect(10, 5, 50, 40); got != want {
+		t.Fatalf("after edge drag got=%v want=%v", got, want)
+	}
+
+	// Dragging the inside moves the selection.
+	m.Press(image.Pt(30, 20), handleTolerance)
+	m.Drag(image.Pt(35, 22))
+	m.Release(image.Pt(35, 22))
+	if got, want := m.Rect(), image.Rect(15, 7, 55, 42); got != want {
+		t.Fatalf("after move got=%v want=%v", got, want)
+	}
+	if !m.Adjusting() {
+		t.Fatalf("handle drags must stay in the adjust phase")
+	}
+}
+
+func TestSelectionMachine_PressOutsideStartsNewSelection(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	dragSelect(m, image.Pt(10, 10), image.Pt(20, 20))
+	dragSelect(m, image.Pt(60, 50), image.Pt(70, 70))
+	if got, want := m.Rect(), image.Rect(60, 50, 70, 70); got != want {
+		t.Fatalf("new selection got=%v want=%v", got, want)
+	}
+
+	// A click outside keeps the previous selection instead of discarding it.
+	dragSelect(m, image.Pt(5, 5), image.Pt(5, 5))
+	if got, want := m.Rect(), image.Rect(60, 50, 70, 70); got != want || !m.Adjusting() {
+		t.Fatalf("after click got=%v adjusting=%v want=%v,true", got, m.Adjusting(), want)
+	}
+}
+
+func TestSelectionMachine_EmptyDragReturnsToIdle(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	dragSelect(m, image.Pt(10, 10), image.Pt(10, 30))
+	if m.phase != phaseIdle || m.Active() {
+		t.Fatalf("zero-width drag: phase=%v active=%v want=%v,false", m.phase, m.Active(), phaseIdle)
+	}
+	m.Key(keyReturn, false)
+	if m.Done() {
+		t.Fatalf("Enter confirmed without a selection")
+	}
+}
+
+func TestSelectionMachine_EscapeCancelsFromAnyPhase(t *testing.T) {
+	t.Parallel()
+
+	for _, setup := range []func(m *selectionMachine){
+		func(m *selectionMachine) {},
+		func(m *selectionMachine) { m.Press(image.Pt(1, 1), handleTolerance); m.Drag(image.Pt(9, 9)) },
+		func(m *selectionMachine) { dragSelect(m, image.Pt(1, 1), image.Pt(9, 9)) },
+	} {
+		m := newTestMachine()
+		setup(m)
+		m.Key(keyEscape, false)
+		if !m.Cancelled() {
+			t.Fatalf("Escape in phase %v did not cancel", m.phase)
+		}
+	}
+}
+
+func TestSelectionMachine_SnapAppliesToDragOnly(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	m.snap = func(r image.Rectangle) image.Rectangle {
+		r.Min.X = 0
+		return r
+	}
+	dragSelect(m, image.Pt(3, 10), image.Pt(40, 30))
+	if got, want := m.Rect(), image.Rect(0, 10, 40, 30); got != want {
+		t.Fatalf("snapped drag got=%v want=%v", got, want)
+	}
+	m.Key(keyRight, false)
+	if got, want := m.Rect(), image.Rect(1, 10, 41, 30); got != want {
+		t.Fatalf("nudge after snap got=%v want=%v", got, want)
+	}
+}
+
+func TestSelectionMachine_SpanDrivesDragThenSnaps(t *testing.T) {
+	t.Parallel()
+
+	// The overlay converts the points it passes with the same scale as the span, so they only
+	// differ by rounding; the span is what gets selected.
+	m := newTestMachine()
+	drag := canvasDrag{
+		start:         CanvasPos{X: 1.6, Y: 5},
+		end:           CanvasPos{X: 20.4, Y: 15},
+		canvasSize:    CanvasSize{W: 50, H: 40},
+		displayBounds: m.desktop,
+		desktop:       m.desktop,
+	}
+	m.span = drag.rect
+	m.snap = func(r image.Rectangle) image.Rectangle {
+		r.Max.Y = 31
+		return r
+	}
+	dragSelect(m, image.Pt(3, 10), image.Pt(40, 30))
+	if got, want := m.Rect(), image.Rect(3, 10, 41, 31); got != want {
+		t.Fatalf("span drag got=%v want=%v", got, want)
+	}
+}
+
+func TestHandleAt(t *testing.T) {
+	t.Parallel()
+
+	r := image.Rect(10, 10, 50, 40)
+	cases := []struct {
+		p    image.Point
+		want handle
+	}{
+		{p: image.Pt(10, 10), want: handleNW},
+		{p: image.Pt(52, 8), want: handleNE},
+		{p: image.Pt(9, 41), want: handleSW},
+		{p: image.Pt(50, 40), want: handleSE},
+		{p: image.Pt(30, 12), want: handleN},
+		{p: image.Pt(30, 39), want: handleS},
+		{p: image.Pt(11, 25), want: handleW},
+		{p: image.Pt(48, 25), want: handleE},
+		{p: image.Pt(30, 25), want: handleMove},
+		{p: image.Pt(30, 2), want: handleNone},
+		{p: image.Pt(70, 25), want: handleNone},
+	}
+	for _, tc := range cases {
+		if got := handleAt(tc.p, r, 3); got != tc.want {
+			t.Fatalf("handleAt(%v) got=%v want=%v", tc.p, got, tc.want)
+		}
+	}
+	if got := handleAt(image.Pt(0, 0), image.Rectangle{}, 3); got != handleNone {
+		t.Fatalf("handleAt(empty rect) got=%v want=%v", got, handleNone)
+	}
+}
+
+func TestHandleRects_CornersAndMidpoints(t *testing.T) {
+	t.Parallel()
+
+	got := handleRects(image.Rect(10, 20, 30, 60), 4)
+	if len(got) != 8 {
+		t.Fatalf("len(handleRects)=%d want=8", len(got))
+	}
+	if want := image.Rect(8, 18, 12, 22); got[0] != want {
+		t.Fatalf("top-left handle got=%v want=%v", got[0], want)
+	}
+	if want := image.Rect(18, 58, 22, 62); got[6] != want {
+		t.Fatalf("bottom-middle handle got=%v want=%v", got[6], want)
+	}
+	if handleRects(image.Rectangle{}, 4) != nil {
+		t.Fatalf("handleRects(empty) want nil")
+	}
+}
+
+func TestSelectionMachine_ConstrainedDragAndFreeModifier(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	m.constraint = Constraint{Aspect: image.Pt(2, 1)}
+	m.snap = func(r image.Rectangle) image.Rectangle { return image.Rect(0, 0, 1, 1) }
+
+	m.Press(image.Pt(10, 10), handleTolerance)
+	m.Drag(image.Pt(50, 12))
+	if got, want := m.Rect(), image.Rect(10, 10, 50, 30); got != want {
+		t.Fatalf("constrained drag got=%v want=%v", got, want)
+	}
+
+	// Shift lifts the constraint (snapping applies again).
+	m.free = true
+	if got, want := m.Rect(), image.Rect(0, 0, 1, 1); got != want {
+		t.Fatalf("free drag got=%v want=%v", got, want)
+	}
+	m.free = false
+	m.Release(image.Pt(50, 12))
+
+	// A corner handle keeps the ratio around the opposite corner.
+	m.Press(image.Pt(50, 30), handleTolerance)
+	m.Drag(image.Pt(70, 31))
+	m.Release(image.Pt(70, 31))
+	if got, want := m.Rect(), image.Rect(10, 10, 70, 40); got != want {
+		t.Fatalf("constrained corner drag got=%v want=%v", got, want)
+	}
+
+	// An edge handle drives the other side.
+	m.Press(image.Pt(10, 25), handleTolerance)
+	m.Drag(image.Pt(30, 25))
+	m.Release(image.Pt(30, 25))
+	if got, want := m.Rect(), image.Rect(30, 10, 70, 30); got != want {
+		t.Fatalf("constrained edge drag got=%v want=%v", got, want)
+	}
+}
+
+func TestSelectionMachine_FixedSizeClickPlaces(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMachine()
+	m.constraint = Constraint{Size: image.Pt(30, 20)}
+	dragSelect(m, image.Pt(5, 5), image.Pt(5, 5))
+	if got, want := m.Rect(), image.Rect(5, 5, 35, 25); got != want || !m.Adjusting() {
+		t.Fatalf("click got=%v adjusting=%v want=%v,true", got, m.Adjusting(), want)
+	}
+
+	// Handles move a fixed-size selection instead of resizing it.
+	m.Press(image.Pt(35, 25), handleTolerance)
+	m.Drag(image.Pt(45, 30))
+	m.Release(image.Pt(45, 30))
+	if got, want := m.Rect(), image.Rect(15, 10, 45, 30); got != want {
+		t.Fatalf("handle drag got=%v want=%v", got, want)
+	}
+}
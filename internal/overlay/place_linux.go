@@ -0,0 +1,37 @@
+//go:build fyne
+// +build fyne
+
+package overlay
+
+import (
+	"image"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// moveToScreenPoint moves w's native window so its top-left corner is at p (virtual-desktop
+// coordinates). Fyne has no window positioning API, and fullscreen uses whichever monitor the
+// window is on, so this is how an overlay is pinned to a specific display.
+func moveToScreenPoint(w fyne.Window, p image.Point) {
+	nw, ok := w.(driver.NativeWindow)
+	if !ok {
+		return
+	}
+	nw.RunNative(func(ctx any) {
+		xc, ok := ctx.(driver.X11WindowContext)
+		if !ok || xc.WindowHandle == 0 {
+			return
+		}
+		c, err := xgb.NewConn()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_ = xproto.ConfigureWindowChecked(c, xproto.Window(xc.WindowHandle),
+			xproto.ConfigWindowX|xproto.ConfigWindowY,
+			[]uint32{uint32(int32(p.X)), uint32(int32(p.Y))}).Check()
+	})
+}
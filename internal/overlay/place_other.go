@@ -0,0 +1,14 @@
+//go:build fyne && !windows && !linux
+// +build fyne,!windows,!linux
+
+package overlay
+
+import (
+	"image"
+
+	"fyne.io/fyne/v2"
+)
+
+// moveToScreenPoint is a no-op on this platform; overlays open on the window manager's
+// choice of display.
+func moveToScreenPoint(w fyne.Window, p image.Point) {}
@@ -0,0 +1,30 @@
+//go:build fyne
+// +build fyne
+
+package overlay
+
+import (
+	"image"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver"
+	"github.com/lxn/win"
+)
+
+// moveToScreenPoint moves w's native window so its top-left corner is at p (virtual-desktop
+// coordinates). Fyne has no window positioning API, and fullscreen uses whichever monitor the
+// window is on, so this is how an overlay is pinned to a specific display.
+func moveToScreenPoint(w fyne.Window, p image.Point) {
+	nw, ok := w.(driver.NativeWindow)
+	if !ok {
+		return
+	}
+	nw.RunNative(func(ctx any) {
+		wc, ok := ctx.(driver.WindowsWindowContext)
+		if !ok || wc.HWND == 0 {
+			return
+		}
+		win.SetWindowPos(win.HWND(wc.HWND), 0, int32(p.X), int32(p.Y), 0, 0,
+			win.SWP_NOSIZE|win.SWP_NOZORDER|win.SWP_NOACTIVATE)
+	})
+}
@@ -7,13 +7,16 @@ import (
 	"errors"
 	"image"
 	"image/color"
+	"strings"
 	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
-	"github.com/kbinani/screenshot"
+
+	"go-snip/internal/capture"
 )
 
 type selectionResult struct {
@@ -22,12 +25,40 @@ type selectionResult struct {
 	err       error
 }
 
-// SelectArea displays a fullscreen overlay (primary display only) and lets the user
-// drag to select an area.
+// SelectArea displays a fullscreen overlay on every active display and lets the user
+// drag to select an area. All overlays share one selection, so a drag may start on one
+// display and end on another.
+//
+// Releasing the mouse enters an adjust phase: arrows nudge the selection by a pixel,
+// Shift+arrows resize it, the handles on its corners and edges can be dragged, and Enter
+// confirms it.
 //
-// The returned rectangle is in screen coordinates compatible with screenshot.CaptureRect.
-// If the user cancels (Esc or closing the window), cancelled is true.
-func SelectArea() (rect image.Rectangle, cancelled bool, err error) {
+// A non-zero opts.Constraint locks the aspect ratio, fixes the size (a click places the
+// selection) or sets a minimum size; holding Shift while dragging selects freely. With
+// opts.SaveRegion, N in the adjust phase asks for a name and saves the selection under it.
+//
+// The returned rectangle is in virtual-desktop screen coordinates compatible with
+// screenshot.CaptureRect, and may span several displays.
+// The frozen backgrounds and display geometry come from src.
+// If the user cancels (Esc or closing a window), cancelled is true.
+func SelectArea(src capture.Source, opts AreaOptions) (rect image.Rectangle, cancelled bool, err error) {
+	return runSelection(src, &selectionState{constraint: opts.Constraint, saveRegion: opts.SaveRegion})
+}
+
+// SelectWindow shows the same overlay as SelectArea, but instead of dragging the user picks one
+// of windows (topmost first, listed before the overlay opened): the window under the cursor is
+// highlighted and a click selects it. The returned rectangle is the window's frame if
+// includeDecorations is set, else its client area, clipped to the desktop.
+func SelectWindow(src capture.Source, windows []capture.Window, includeDecorations bool) (rect image.Rectangle, cancelled bool, err error) {
+	if len(windows) == 0 {
+		return image.Rectangle{}, false, ErrNoWindows
+	}
+	return runSelection(src, &selectionState{windows: windows, includeDecorations: includeDecorations})
+}
+
+// runSelection opens an overlay on every display of src that shares state, and waits for
+// a selection or cancellation.
+func runSelection(src capture.Source, state *selectionState) (rect image.Rectangle, cancelled bool, err error) {
 	a := fyne.CurrentApp()
 	if a == nil {
 		return image.Rectangle{}, false, ErrSelectionUnavailable
@@ -36,15 +67,20 @@ func SelectArea() (rect image.Rectangle, cancelled bool, err error) {
 		return image.Rectangle{}, false, errors.New("overlay: fyne driver unavailable (app not running?)")
 	}
 
-	n := screenshot.NumActiveDisplays()
+	n := src.NumDisplays()
 	if n <= 0 {
 		return image.Rectangle{}, false, ErrNoActiveDisplays
 	}
 
-	displayBounds := screenshot.GetDisplayBounds(0) // primary-only for v1
-	bgImg, err := screenshot.CaptureRect(displayBounds)
-	if err != nil {
-		return image.Rectangle{}, false, err
+	displays := make([]image.Rectangle, n)
+	bgImgs := make([]image.Image, n)
+	for i := range displays {
+		displays[i] = src.DisplayBounds(i)
+		bgImg, err := src.CaptureRect(displays[i])
+		if err != nil {
+			return image.Rectangle{}, false, err
+		}
+		bgImgs[i] = bgImg
 	}
 
 	done := make(chan selectionResult, 1)
@@ -58,120 +94,374 @@ func SelectArea() (rect image.Rectangle, cancelled bool, err error) {
 		})
 	}
 
+	state.desktop = capture.UnionBounds(displays)
+	state.sel = selectionMachine{desktop: state.desktop, snap: state.snap, span: state.drag.rect, constraint: state.constraint}
+	if !state.pickingWindow() {
+		state.edges = newLumaMap(capture.Composite(bgImgs, displays), state.desktop.Min)
+		if wl, ok := src.(capture.WindowLister); ok {
+			// Best effort: without window bounds, the selection still snaps to image edges.
+			if windows, err := wl.Windows(); err == nil {
+				for _, w := range windows {
+					state.guides = append(state.guides, w.Frame, w.Client)
+				}
+			}
+		}
+	}
+
 	// Important: Fyne UI must be mutated on the main/UI goroutine. Using fyne.DoAndWait
 	// keeps us compatible with Fyne's thread-safety checks (and avoids window lifecycle hangs).
+	var windows []fyne.Window
 	fyne.DoAndWait(func() {
-		w := a.NewWindow("go-snip: select area")
-		w.SetPadded(false)
-		w.SetFullScreen(true)
+		closing := false
+		closeAll := func() {
+			if closing {
+				return
+			}
+			closing = true
+			for _, w := range windows {
+				w.Close()
+			}
+		}
 
 		finish := func(r image.Rectangle, cancelled bool) {
 			send(selectionResult{rect: r, cancelled: cancelled})
-			w.Close()
+			closeAll()
 		}
 
-		selector := newSelectionWidget(bgImg, displayBounds, finish)
-		w.SetContent(selector)
-
-		// Escape cancels selection.
-		w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
-			if ev == nil {
-				return
+		for i, displayBounds := range displays {
+			title := "go-snip: select area"
+			if state.windows != nil {
+				title = "go-snip: select window"
 			}
-			if ev.Name == fyne.KeyEscape {
-				finish(image.Rectangle{}, true)
+			w := a.NewWindow(title)
+			w.SetPadded(false)
+
+			selector := newSelectionWidget(state, bgImgs[i], displayBounds, finish)
+			state.widgets = append(state.widgets, selector)
+			w.SetContent(selector)
+
+			// Holding Ctrl turns snapping off while dragging; Shift lifts the constraint
+			// and makes arrows resize.
+			if dc, ok := w.Canvas().(desktop.Canvas); ok {
+				dc.SetOnKeyDown(func(ev *fyne.KeyEvent) {
+					if ev != nil {
+						state.modifierKey(ev.Name, true)
+					}
+				})
+				dc.SetOnKeyUp(func(ev *fyne.KeyEvent) {
+					if ev != nil {
+						state.modifierKey(ev.Name, false)
+					}
+				})
 			}
-		})
 
-		w.SetOnClosed(func() {
-			send(selectionResult{cancelled: true})
-		})
+			// Escape cancels selection; arrows and Enter refine and confirm an area.
+			w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+				if ev == nil || state.naming {
+					return
+				}
+				if ev.Name == fyne.KeyEscape {
+					finish(image.Rectangle{}, true)
+					return
+				}
+				if state.pickingWindow() {
+					return
+				}
+				if ev.Name == fyne.KeyN && state.saveRegion != nil && state.sel.Adjusting() {
+					state.askRegionName(w)
+					return
+				}
+				state.sel.Key(string(ev.Name), state.shift)
+				if state.sel.Done() {
+					finish(state.rect(), false)
+					return
+				}
+				state.refreshAll()
+			})
+
+			// Closing any overlay cancels the whole selection.
+			w.SetOnClosed(func() {
+				finish(image.Rectangle{}, true)
+			})
 
-		w.Show()
+			windows = append(windows, w)
+			w.Show()
+			moveToScreenPoint(w, displayBounds.Min)
+		}
+	})
+	// Go fullscreen in a separate UI pass so the driver has processed the moves and picks
+	// the monitor each window now sits on.
+	fyne.Do(func() {
+		for _, w := range windows {
+			w.SetFullScreen(true)
+		}
 	})
 
 	res := <-done
 	return res.rect, res.cancelled, res.err
 }
 
+// selectionState is the selection shared by the overlays of all displays.
+// Points are in virtual-desktop pixels so each overlay can apply its own display scale.
+type selectionState struct {
+	desktop image.Rectangle
+
+	// sel is the area selection; pointer is the last press or drag point, for DragEnd.
+	sel     selectionMachine
+	pointer image.Point
+	// drag is the press and latest pointer position on the overlay that received the press;
+	// it is the selection machine's span hook.
+	drag canvasDrag
+	// pressed is set between press and release while picking a window.
+	pressed bool
+	shift   bool
+	// constraint shapes area selections while Shift is up.
+	constraint Constraint
+	// saveRegion saves the adjusted selection under a name; naming is set while asking for it.
+	saveRegion func(name string, rect image.Rectangle) error
+	naming     bool
+
+	// edges and guides (known window bounds) are what the drag snaps to, unless snapOff.
+	edges   *lumaMap
+	guides  []image.Rectangle
+	snapOff bool
+
+	// windows, when non-nil, switches to window picking: hover tracks the window under the cursor.
+	windows            []capture.Window
+	includeDecorations bool
+	hover              image.Rectangle
+
+	widgets []*selectionWidget
+}
+
+func (s *selectionState) pickingWindow() bool {
+	return s.windows != nil
+}
+
+// rect is the selection the overlay shows, reads out under the loupe and finishes with.
+func (s *selectionState) rect() image.Rectangle {
+	return selectedRect(&s.sel, s.pickingWindow(), s.hover)
+}
+
+// snap is the selection machine's snap hook for fresh drags.
+func (s *selectionState) snap(r image.Rectangle) image.Rectangle {
+	if s.snapOff || (s.edges == nil && len(s.guides) == 0) {
+		return r
+	}
+	return snapRect(r, s.edges, s.guides, snapRadius)
+}
+
+// modifierKey tracks Ctrl (snapping off) and Shift (free selection, arrows resize) going
+// down or up.
+func (s *selectionState) modifierKey(k fyne.KeyName, down bool) {
+	switch k {
+	case desktop.KeyShiftLeft, desktop.KeyShiftRight:
+		s.setShift(down)
+	case desktop.KeyControlLeft, desktop.KeyControlRight:
+		if s.snapOff == down {
+			return
+		}
+		s.snapOff = down
+		if s.sel.phase == phaseDragging {
+			s.refreshAll()
+		}
+	}
+}
+
+// askRegionName asks for a name in a dialog on w and saves the adjusted selection under it.
+func (s *selectionState) askRegionName(w fyne.Window) {
+	s.naming = true
+	rect := s.sel.Rect()
+	name := widget.NewEntry()
+	name.SetPlaceHolder("e.g. grafana-panel")
+	items := []*widget.FormItem{widget.NewFormItem("Name", name)}
+	d := dialog.NewForm("Save region", "Save", "Cancel", items, func(ok bool) {
+		s.naming = false
+		if !ok {
+			return
+		}
+		if err := s.saveRegion(strings.TrimSpace(name.Text), rect); err != nil {
+			dialog.ShowError(err, w)
+		}
+	}, w)
+	d.Show()
+	w.Canvas().Focus(name)
+}
+
+func (s *selectionState) setShift(down bool) {
+	if s.shift == down {
+		return
+	}
+	s.shift = down
+	s.sel.free = down
+	if s.sel.phase == phaseDragging || s.sel.phase == phaseResizing {
+		s.sel.Drag(s.pointer)
+		s.refreshAll()
+	}
+}
+
+// visible reports whether there is a rectangle to highlight.
+func (s *selectionState) visible() bool {
+	if s.pickingWindow() {
+		return !s.hover.Empty()
+	}
+	return s.sel.Active()
+}
+
+// hoverAt highlights the window under p, if any.
+func (s *selectionState) hoverAt(p image.Point) {
+	s.hover = windowSelection(p, s.windows, s.includeDecorations, s.desktop)
+	s.refreshAll()
+}
+
+func (s *selectionState) refreshAll() {
+	for _, w := range s.widgets {
+		w.Refresh()
+	}
+}
+
 type selectionWidget struct {
 	widget.BaseWidget
 
+	state         *selectionState
 	bgImg         image.Image
 	displayBounds image.Rectangle
 
-	start    fyne.Position
-	current  fyne.Position
-	hasStart bool
+	// hover is the last pointer position on this display's canvas; the loupe follows it.
+	hover    fyne.Position
+	hovering bool
+	// renderer is set by CreateRenderer; pointer moves update only its loupe.
+	renderer *selectionRenderer
 
 	finish func(rect image.Rectangle, cancelled bool)
 }
 
-func newSelectionWidget(bgImg image.Image, displayBounds image.Rectangle, finish func(rect image.Rectangle, cancelled bool)) *selectionWidget {
+func newSelectionWidget(state *selectionState, bgImg image.Image, displayBounds image.Rectangle, finish func(rect image.Rectangle, cancelled bool)) *selectionWidget {
 	w := &selectionWidget{
+		state:         state,
 		bgImg:         bgImg,
 		displayBounds: displayBounds,
-		current:       fyne.NewPos(0, 0),
 		finish:        finish,
 	}
 	w.ExtendBaseWidget(w)
 	return w
 }
 
-// MouseDown starts a selection.
-func (w *selectionWidget) MouseDown(ev *desktop.MouseEvent) {
+func (w *selectionWidget) toScreen(p fyne.Position) image.Point {
+	return canvasToScreenPoint(w.canvasPos(p), w.canvasSize(), w.displayBounds)
+}
+
+func (w *selectionWidget) canvasPos(p fyne.Position) CanvasPos {
+	return CanvasPos{X: p.X, Y: p.Y}
+}
+
+func (w *selectionWidget) canvasSize() CanvasSize {
+	sz := w.Size()
+	return CanvasSize{W: sz.Width, H: sz.Height}
+}
+
+// MouseIn and MouseMoved track the window under the cursor when picking a window.
+func (w *selectionWidget) MouseIn(ev *desktop.MouseEvent) {
+	w.MouseMoved(ev)
+}
+
+func (w *selectionWidget) MouseMoved(ev *desktop.MouseEvent) {
 	if ev == nil {
 		return
 	}
-	w.start = ev.Position
-	w.current = ev.Position
-	w.hasStart = true
-	w.Refresh()
+	w.trackPointer(ev.Position)
+	if w.state.pickingWindow() {
+		w.state.hoverAt(w.toScreen(ev.Position))
+		return
+	}
+	w.refreshLoupe()
 }
 
-// MouseUp finalizes a selection if one is active.
-func (w *selectionWidget) MouseUp(ev *desktop.MouseEvent) {
-	if !w.hasStart {
+func (w *selectionWidget) MouseOut() {
+	w.hovering = false
+	w.refreshLoupe()
+}
+
+// refreshLoupe moves the loupe, crosshair and readout to the pointer without refreshing the
+// rest of the overlay, so hovering doesn't re-upload the frozen screenshot.
+func (w *selectionWidget) refreshLoupe() {
+	if w.renderer == nil {
 		return
 	}
+	w.renderer.layoutLoupe(w.Size())
+}
+
+// trackPointer moves the loupe to p, hiding it when a drag carries the pointer off this canvas.
+func (w *selectionWidget) trackPointer(p fyne.Position) {
+	sz := w.Size()
+	w.hover = p
+	w.hovering = p.X >= 0 && p.Y >= 0 && p.X < sz.Width && p.Y < sz.Height
+}
+
+// MouseDown starts a selection, or grabs a handle of the adjusting one.
+func (w *selectionWidget) MouseDown(ev *desktop.MouseEvent) {
+	if ev == nil {
+		return
+	}
+	p := w.toScreen(ev.Position)
+	w.state.snapOff = ev.Modifier&fyne.KeyModifierControl != 0
+	w.state.setShift(ev.Modifier&fyne.KeyModifierShift != 0)
+	if w.state.pickingWindow() {
+		w.state.hoverAt(p)
+		w.state.pressed = true
+		return
+	}
+	w.state.pointer = p
+	w.state.drag = canvasDrag{
+		start:         w.canvasPos(ev.Position),
+		end:           w.canvasPos(ev.Position),
+		canvasSize:    w.canvasSize(),
+		displayBounds: w.displayBounds,
+		desktop:       w.state.desktop,
+	}
+	w.state.sel.Press(p, handleTolerance)
+	w.state.refreshAll()
+}
+
+// MouseUp ends a drag. An area selection then waits for Enter; a window pick finishes.
+func (w *selectionWidget) MouseUp(ev *desktop.MouseEvent) {
 	if ev != nil {
-		w.current = ev.Position
+		w.state.pointer = w.toScreen(ev.Position)
+		w.state.drag.end = w.canvasPos(ev.Position)
 	}
-	w.finalize()
+	w.release()
 }
 
 func (w *selectionWidget) Dragged(ev *fyne.DragEvent) {
-	if !w.hasStart || ev == nil {
+	if ev == nil || w.state.pickingWindow() {
 		return
 	}
-	w.current = ev.Position
-	w.Refresh()
+	w.trackPointer(ev.Position)
+	w.state.pointer = w.toScreen(ev.Position)
+	w.state.drag.end = w.canvasPos(ev.Position)
+	w.state.sel.Drag(w.state.pointer)
+	w.state.refreshAll()
 }
 
 func (w *selectionWidget) DragEnd() {
-	if !w.hasStart {
-		return
-	}
-	w.finalize()
+	w.release()
 }
 
-func (w *selectionWidget) finalize() {
-	defer func() {
-		w.hasStart = false
-	}()
-
-	sz := w.Size()
-	r := canvasRectToScreenRect(
-		CanvasPos{X: w.start.X, Y: w.start.Y},
-		CanvasPos{X: w.current.X, Y: w.current.Y},
-		CanvasSize{W: sz.Width, H: sz.Height},
-		w.displayBounds,
-	)
-	if r.Dx() <= 0 || r.Dy() <= 0 {
-		w.finish(image.Rectangle{}, true)
+// release is shared by MouseUp and DragEnd; whichever comes second is a no-op.
+func (w *selectionWidget) release() {
+	if !w.state.pickingWindow() {
+		w.state.sel.Release(w.state.pointer)
+		w.state.refreshAll()
+		return
+	}
+	if !w.state.pressed {
 		return
 	}
-	w.finish(r, false)
+	w.state.pressed = false
+	// A click on the bare desktop keeps picking.
+	if r := w.state.rect(); !r.Empty() {
+		w.finish(r, false)
+	}
 }
 
 func (w *selectionWidget) CreateRenderer() fyne.WidgetRenderer {
@@ -185,17 +475,60 @@ func (w *selectionWidget) CreateRenderer() fyne.WidgetRenderer {
 	sel.StrokeWidth = 2
 	sel.Hide()
 
-	return &selectionRenderer{
-		w:   w,
-		bg:  bg,
-		dim: dim,
-		sel: sel,
+	guide := color.NRGBA{R: 255, G: 255, B: 255, A: 90}
+	hGuide := canvas.NewLine(guide)
+	vGuide := canvas.NewLine(guide)
+
+	loupe := canvas.NewImageFromImage(image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	loupe.FillMode = canvas.ImageFillStretch
+	loupe.ScaleMode = canvas.ImageScalePixels
+	loupeFrame := canvas.NewRectangle(color.Transparent)
+	loupeFrame.StrokeColor = color.NRGBA{R: 255, G: 255, B: 255, A: 220}
+	loupeFrame.StrokeWidth = 1
+
+	readoutBg := canvas.NewRectangle(color.NRGBA{A: 200})
+	readout := canvas.NewText("", color.White)
+	readout.TextSize = 12
+	readout.Alignment = fyne.TextAlignCenter
+
+	handles := make([]*canvas.Rectangle, 8)
+	for i := range handles {
+		handles[i] = canvas.NewRectangle(color.White)
+		handles[i].StrokeColor = color.NRGBA{R: 0, G: 120, B: 255, A: 255}
+		handles[i].StrokeWidth = 1
+		handles[i].Hide()
+	}
+
+	r := &selectionRenderer{
+		w:          w,
+		handles:    handles,
+		bg:         bg,
+		dim:        dim,
+		sel:        sel,
+		hGuide:     hGuide,
+		vGuide:     vGuide,
+		loupe:      loupe,
+		loupeFrame: loupeFrame,
+		readoutBg:  readoutBg,
+		readout:    readout,
 		objects: []fyne.CanvasObject{
 			bg,
 			dim,
 			sel,
+			hGuide,
+			vGuide,
+			loupe,
+			loupeFrame,
+			readoutBg,
+			readout,
 		},
 	}
+	for _, h := range handles {
+		r.objects = append(r.objects, h)
+	}
+	r.hideLoupe()
+	w.renderer = r
+	return r
 }
 
 type selectionRenderer struct {
@@ -204,10 +537,61 @@ type selectionRenderer struct {
 	bg  *canvas.Image
 	dim *canvas.Rectangle
 	sel *canvas.Rectangle
+	// handles mark the corners and edges of an adjusting selection.
+	handles []*canvas.Rectangle
+
+	// Crosshair guides through the pointer and the magnifier loupe with its readout.
+	hGuide, vGuide *canvas.Line
+	loupe          *canvas.Image
+	loupeFrame     *canvas.Rectangle
+	readoutBg      *canvas.Rectangle
+	readout        *canvas.Text
 
 	objects []fyne.CanvasObject
 }
 
+func (r *selectionRenderer) hideLoupe() {
+	for _, o := range []fyne.CanvasObject{r.hGuide, r.vGuide, r.loupe, r.loupeFrame, r.readoutBg, r.readout} {
+		o.Hide()
+	}
+}
+
+// layoutLoupe places the guides and loupe at the pointer and refreshes their contents.
+func (r *selectionRenderer) layoutLoupe(size fyne.Size) {
+	w := r.w
+	if !w.hovering {
+		r.hideLoupe()
+		return
+	}
+	p := w.hover
+	r.hGuide.Position1, r.hGuide.Position2 = fyne.NewPos(0, p.Y), fyne.NewPos(size.Width, p.Y)
+	r.vGuide.Position1, r.vGuide.Position2 = fyne.NewPos(p.X, 0), fyne.NewPos(p.X, size.Height)
+
+	screen := w.toScreen(p)
+	center := screen.Sub(w.displayBounds.Min).Add(w.bgImg.Bounds().Min)
+	r.loupe.Image = loupeImage(w.bgImg, center, loupePixels, loupeZoom)
+	r.readout.Text = cursorReadout(screen, w.displayBounds, w.state.rect(), w.state.visible())
+
+	loupeSize := fyne.NewSize(loupePixels*loupeZoom, loupePixels*loupeZoom)
+	readoutSize := fyne.NewSize(loupeSize.Width, r.readout.MinSize().Height+4)
+	total := CanvasSize{W: loupeSize.Width, H: loupeSize.Height + readoutSize.Height}
+	pos := loupePosition(CanvasPos{X: p.X, Y: p.Y}, CanvasSize{W: size.Width, H: size.Height}, total)
+
+	r.loupe.Move(fyne.NewPos(pos.X, pos.Y))
+	r.loupe.Resize(loupeSize)
+	r.loupeFrame.Move(fyne.NewPos(pos.X, pos.Y))
+	r.loupeFrame.Resize(loupeSize)
+	r.readoutBg.Move(fyne.NewPos(pos.X, pos.Y+loupeSize.Height))
+	r.readoutBg.Resize(readoutSize)
+	r.readout.Move(fyne.NewPos(pos.X, pos.Y+loupeSize.Height+2))
+	r.readout.Resize(fyne.NewSize(readoutSize.Width, readoutSize.Height-4))
+
+	for _, o := range []fyne.CanvasObject{r.hGuide, r.vGuide, r.loupe, r.loupeFrame, r.readoutBg, r.readout} {
+		o.Show()
+		o.Refresh()
+	}
+}
+
 func (r *selectionRenderer) Layout(size fyne.Size) {
 	r.bg.Move(fyne.NewPos(0, 0))
 	r.bg.Resize(size)
@@ -215,29 +599,56 @@ func (r *selectionRenderer) Layout(size fyne.Size) {
 	r.dim.Move(fyne.NewPos(0, 0))
 	r.dim.Resize(size)
 
-	if !r.w.hasStart {
+	r.layoutLoupe(size)
+	r.layoutHandles(size)
+
+	if !r.w.state.visible() {
 		r.sel.Hide()
 		return
 	}
 
-	minX := min32(r.w.start.X, r.w.current.X)
-	minY := min32(r.w.start.Y, r.w.current.Y)
-	maxX := max32(r.w.start.X, r.w.current.X)
-	maxY := max32(r.w.start.Y, r.w.current.Y)
-
-	r.sel.Move(fyne.NewPos(minX, minY))
-	r.sel.Resize(fyne.NewSize(maxX-minX, maxY-minY))
+	// Show only the part of the shared selection that lies on this display.
+	pos, sz, ok := screenRectToCanvasRect(r.w.state.rect(), CanvasSize{W: size.Width, H: size.Height}, r.w.displayBounds)
+	if !ok {
+		r.sel.Hide()
+		return
+	}
+	r.sel.Move(fyne.NewPos(pos.X, pos.Y))
+	r.sel.Resize(fyne.NewSize(sz.W, sz.H))
 	r.sel.Show()
 }
 
+// layoutHandles places the resize handles that fall on this display while adjusting.
+func (r *selectionRenderer) layoutHandles(size fyne.Size) {
+	var rects []image.Rectangle
+	if !r.w.state.pickingWindow() && r.w.state.sel.Adjusting() {
+		rects = handleRects(r.w.state.sel.Rect(), handleSize)
+	}
+	for i, h := range r.handles {
+		if i >= len(rects) {
+			h.Hide()
+			continue
+		}
+		pos, sz, ok := screenRectToCanvasRect(rects[i], CanvasSize{W: size.Width, H: size.Height}, r.w.displayBounds)
+		if !ok {
+			h.Hide()
+			continue
+		}
+		h.Move(fyne.NewPos(pos.X, pos.Y))
+		h.Resize(fyne.NewSize(sz.W, sz.H))
+		h.Show()
+		h.Refresh()
+	}
+}
+
 func (r *selectionRenderer) MinSize() fyne.Size {
 	return fyne.NewSize(10, 10)
 }
 
+// Refresh lays out the selection, handles and loupe again. The background and dim layer never
+// change, so they aren't refreshed: that would re-upload the full-size screenshot.
 func (r *selectionRenderer) Refresh() {
 	r.Layout(r.w.Size())
-	r.bg.Refresh()
-	r.dim.Refresh()
 	r.sel.Refresh()
 }
 
@@ -246,17 +657,3 @@ func (r *selectionRenderer) Objects() []fyne.CanvasObject {
 }
 
 func (r *selectionRenderer) Destroy() {}
-
-func min32(a, b float32) float32 {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func max32(a, b float32) float32 {
-	if a > b {
-		return a
-	}
-	return b
-}
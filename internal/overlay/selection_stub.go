@@ -4,9 +4,16 @@ package overlay
 
 import (
 	"image"
+
+	"go-snip/internal/capture"
 )
 
 // SelectArea is unavailable unless built with the `fyne` build tag.
-func SelectArea() (rect image.Rectangle, cancelled bool, err error) {
+func SelectArea(src capture.Source, opts AreaOptions) (rect image.Rectangle, cancelled bool, err error) {
+	return image.Rectangle{}, false, ErrSelectionUnavailable
+}
+
+// SelectWindow is unavailable unless built with the `fyne` build tag.
+func SelectWindow(src capture.Source, windows []capture.Window, includeDecorations bool) (rect image.Rectangle, cancelled bool, err error) {
 	return image.Rectangle{}, false, ErrSelectionUnavailable
 }
@@ -1,8 +1,14 @@
 package overlay
 
 import (
+	"errors"
+	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"testing"
+
+	"go-snip/internal/capture"
 )
 
 func TestOverlayPackageBuilds(t *testing.T) {
@@ -78,3 +84,377 @@ func TestCanvasRectToScreenRect_ZeroSize(t *testing.T) {
 		t.Fatalf("expected empty rect, got=%v", got)
 	}
 }
+
+func TestCanvasToScreenPoint_PerDisplayScale(t *testing.T) {
+	t.Parallel()
+
+	// A 1x display at the origin and a 2x (HiDPI) display to its left, both showing a
+	// 1280x720 canvas.
+	primary := image.Rect(0, 0, 1280, 720)
+	hidpi := image.Rect(-2560, 0, 0, 1440)
+	canvasSize := CanvasSize{W: 1280, H: 720}
+
+	if got, want := canvasToScreenPoint(CanvasPos{X: 100, Y: 50}, canvasSize, primary), image.Pt(100, 50); got != want {
+		t.Fatalf("primary: got=%v want=%v", got, want)
+	}
+	if got, want := canvasToScreenPoint(CanvasPos{X: 100, Y: 50}, canvasSize, hidpi), image.Pt(-2360, 100); got != want {
+		t.Fatalf("hidpi: got=%v want=%v", got, want)
+	}
+	// Positions past the canvas edge (drag continuing onto the next display) are not clamped.
+	if got, want := canvasToScreenPoint(CanvasPos{X: 1300, Y: 10}, canvasSize, hidpi), image.Pt(40, 20); got != want {
+		t.Fatalf("hidpi overflow: got=%v want=%v", got, want)
+	}
+}
+
+func TestCanvasRectToScreenRect_FractionalScale(t *testing.T) {
+	t.Parallel()
+
+	display := image.Rect(1920, 0, 4800, 1620) // 2880x1620 shown at 1.5x
+	canvasSize := CanvasSize{W: 1920, H: 1080}
+
+	got := canvasRectToScreenRect(CanvasPos{X: 10, Y: 20}, CanvasPos{X: 111, Y: 121}, canvasSize, display)
+	want := image.Rect(1935, 30, 2087, 182) // 166.5 and 181.5 round away from zero
+	if got != want {
+		t.Fatalf("canvasRectToScreenRect(1.5x): got=%v want=%v", got, want)
+	}
+}
+
+func TestScreenRectToCanvasRect_SpansTwoDisplays(t *testing.T) {
+	t.Parallel()
+
+	primary := image.Rect(0, 0, 1280, 720)
+	hidpi := image.Rect(-2560, 0, 0, 1440)
+	canvasSize := CanvasSize{W: 1280, H: 720}
+	selection := image.Rect(-200, 100, 300, 400)
+
+	pos, size, ok := screenRectToCanvasRect(selection, canvasSize, hidpi)
+	if !ok || pos != (CanvasPos{X: 1180, Y: 50}) || size != (CanvasSize{W: 100, H: 150}) {
+		t.Fatalf("hidpi part: ok=%v pos=%v size=%v", ok, pos, size)
+	}
+	pos, size, ok = screenRectToCanvasRect(selection, canvasSize, primary)
+	if !ok || pos != (CanvasPos{X: 0, Y: 100}) || size != (CanvasSize{W: 300, H: 300}) {
+		t.Fatalf("primary part: ok=%v pos=%v size=%v", ok, pos, size)
+	}
+	if _, _, ok := screenRectToCanvasRect(image.Rect(5000, 0, 5100, 100), canvasSize, primary); ok {
+		t.Fatalf("expected no intersection for off-display rect")
+	}
+}
+
+func TestScreenSelection_ClampsToDesktop(t *testing.T) {
+	t.Parallel()
+
+	desktop := capture.UnionBounds([]image.Rectangle{image.Rect(0, 0, 100, 100), image.Rect(-50, 0, 0, 80)})
+	got := screenSelection(image.Pt(90, 120), image.Pt(-70, 10), desktop)
+	want := image.Rect(-50, 10, 90, 100)
+	if got != want {
+		t.Fatalf("screenSelection: got=%v want=%v", got, want)
+	}
+}
+
+func TestCanvasRectToScreenRect_WithinDesktop(t *testing.T) {
+	t.Parallel()
+
+	primary := image.Rect(0, 0, 1280, 720)
+	hidpi := image.Rect(-2560, 0, 0, 1440)
+	desktop := capture.UnionBounds([]image.Rectangle{primary, hidpi})
+	canvasSize := CanvasSize{W: 1280, H: 720}
+
+	// Pressed on the 2x display and dragged past its right edge onto the primary one.
+	start, end := CanvasPos{X: 1180, Y: 50}, CanvasPos{X: 1430, Y: 200}
+	if got, want := canvasRectToScreenRect(start, end, canvasSize, hidpi, desktop), image.Rect(-200, 100, 300, 400); got != want {
+		t.Fatalf("within desktop: got=%v want=%v", got, want)
+	}
+	if got, want := canvasRectToScreenRect(start, end, canvasSize, hidpi), image.Rect(-200, 100, 0, 400); got != want {
+		t.Fatalf("display only: got=%v want=%v", got, want)
+	}
+}
+
+func TestWindowSelection_DecorationsAndClamp(t *testing.T) {
+	t.Parallel()
+
+	desktop := image.Rect(0, 0, 100, 100)
+	windows := []capture.Window{
+		{ID: 1, Frame: image.Rect(80, 10, 140, 60), Client: image.Rect(82, 30, 138, 58)},
+		{ID: 2, Frame: image.Rect(0, 0, 50, 50), Client: image.Rect(2, 20, 48, 48)},
+	}
+
+	if got, want := windowSelection(image.Pt(90, 20), windows, true, desktop), image.Rect(80, 10, 100, 60); got != want {
+		t.Fatalf("frame got=%v want=%v", got, want)
+	}
+	if got, want := windowSelection(image.Pt(10, 30), windows, false, desktop), image.Rect(2, 20, 48, 48); got != want {
+		t.Fatalf("client got=%v want=%v", got, want)
+	}
+	if got := windowSelection(image.Pt(60, 90), windows, true, desktop); !got.Empty() {
+		t.Fatalf("no window got=%v want empty", got)
+	}
+}
+
+// panelImage returns a w x h white image with a dark panel filling panel.
+func panelImage(w, h int, panel image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, panel, image.NewUniform(color.RGBA{R: 40, G: 40, B: 60, A: 255}), image.Point{}, draw.Src)
+	return img
+}
+
+func TestLumaMap_EdgeStrength(t *testing.T) {
+	t.Parallel()
+
+	// The map sits at desktop (100, 50); the panel's left border is at image x=20, desktop x=120.
+	m := newLumaMap(panelImage(60, 40, image.Rect(20, 10, 50, 30)), image.Pt(100, 50))
+
+	if got := m.columnEdge(120, 60, 80); got < 150 {
+		t.Fatalf("columnEdge(panel border)=%v want a strong edge", got)
+	}
+	if got := m.columnEdge(110, 60, 80); got != 0 {
+		t.Fatalf("columnEdge(flat area)=%v want 0", got)
+	}
+	if got := m.rowEdge(60, 120, 150); got < 150 {
+		t.Fatalf("rowEdge(panel top)=%v want a strong edge", got)
+	}
+	if got := m.columnEdge(100, 50, 90); got != 0 {
+		t.Fatalf("columnEdge(map edge)=%v want 0", got)
+	}
+}
+
+func TestSnapRect_SnapsToPanelEdges(t *testing.T) {
+	t.Parallel()
+
+	m := newLumaMap(panelImage(100, 80, image.Rect(20, 10, 70, 60)), image.Point{})
+
+	got := snapRect(image.Rect(24, 6, 66, 63), m, nil, snapRadius)
+	if want := image.Rect(20, 10, 70, 60); got != want {
+		t.Fatalf("snapRect()=%v want=%v", got, want)
+	}
+
+	// Edges farther than the radius stay where they were.
+	got = snapRect(image.Rect(5, 10, 70, 60), m, nil, snapRadius)
+	if want := image.Rect(5, 10, 70, 60); got != want {
+		t.Fatalf("snapRect(out of range)=%v want=%v", got, want)
+	}
+}
+
+func TestSnapRect_IgnoresWeakEdgesAndPrefersGuides(t *testing.T) {
+	t.Parallel()
+
+	// A faint panel (luma step well below the threshold) doesn't attract the selection.
+	faint := image.NewRGBA(image.Rect(0, 0, 100, 80))
+	draw.Draw(faint, faint.Bounds(), image.NewUniform(color.Gray{Y: 200}), image.Point{}, draw.Src)
+	draw.Draw(faint, image.Rect(20, 10, 70, 60), image.NewUniform(color.Gray{Y: 195}), image.Point{}, draw.Src)
+	r := image.Rect(24, 14, 66, 56)
+	if got := snapRect(r, newLumaMap(faint, image.Point{}), nil, snapRadius); got != r {
+		t.Fatalf("snapRect(faint)=%v want unchanged %v", got, r)
+	}
+
+	// A window guide wins over a stronger image edge at a different position.
+	m := newLumaMap(panelImage(100, 80, image.Rect(20, 10, 70, 60)), image.Point{})
+	guide := image.Rect(26, 12, 64, 58)
+	if got := snapRect(image.Rect(24, 14, 66, 56), m, []image.Rectangle{guide}, snapRadius); got != guide {
+		t.Fatalf("snapRect(guide)=%v want=%v", got, guide)
+	}
+}
+
+func TestSnapRect_NeverCollapses(t *testing.T) {
+	t.Parallel()
+
+	r := image.Rect(10, 10, 13, 13)
+	guides := []image.Rectangle{image.Rect(11, 11, 11, 11)} // both edges would land on 11
+	if got := snapRect(r, nil, guides, snapRadius); got != r {
+		t.Fatalf("snapRect()=%v want unchanged %v", got, r)
+	}
+}
+
+func TestLoupeImage_MagnifiesAndMarksCentre(t *testing.T) {
+	t.Parallel()
+
+	bg := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	bg.SetRGBA(10, 10, color.RGBA{G: 255, A: 255})
+	bg.SetRGBA(12, 10, color.RGBA{B: 255, A: 255})
+
+	out := loupeImage(bg, image.Pt(10, 10), 5, 4)
+	if out.Bounds() != image.Rect(0, 0, 20, 20) {
+		t.Fatalf("bounds=%v want 20x20", out.Bounds())
+	}
+	// The centre pixel is the middle cell; its interior keeps the source colour.
+	if got := out.RGBAAt(9, 9); got != (color.RGBA{G: 255, A: 255}) {
+		t.Fatalf("centre cell=%v want green", got)
+	}
+	// (12,10) is two cells right of the centre: x 16..19, away from the guide through y=10.
+	if got := out.RGBAAt(17, 8); got != (color.RGBA{B: 255, A: 255}) {
+		t.Fatalf("neighbour cell=%v want blue", got)
+	}
+	if got := out.RGBAAt(8, 8); got != loupeCrosshair {
+		t.Fatalf("centre outline=%v want crosshair colour", got)
+	}
+	if got := out.RGBAAt(0, 10); got != loupeCrosshair {
+		t.Fatalf("horizontal guide=%v want crosshair colour", got)
+	}
+}
+
+func TestLoupeImage_OutsideIsDark(t *testing.T) {
+	t.Parallel()
+
+	bg := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	out := loupeImage(bg, image.Pt(0, 0), 5, 2)
+	if got := out.RGBAAt(0, 0); got != loupeOutside {
+		t.Fatalf("outside cell=%v want=%v", got, loupeOutside)
+	}
+}
+
+func TestLoupePosition_FlipsAtEdges(t *testing.T) {
+	t.Parallel()
+
+	canvas := CanvasSize{W: 800, H: 600}
+	loupe := CanvasSize{W: 120, H: 140}
+
+	if got := loupePosition(CanvasPos{X: 100, Y: 100}, canvas, loupe); got != (CanvasPos{X: 124, Y: 124}) {
+		t.Fatalf("middle got=%v", got)
+	}
+	if got := loupePosition(CanvasPos{X: 750, Y: 550}, canvas, loupe); got != (CanvasPos{X: 606, Y: 386}) {
+		t.Fatalf("bottom-right got=%v", got)
+	}
+}
+
+func TestCursorReadout_MatchesSavedRect(t *testing.T) {
+	t.Parallel()
+
+	// A 2x display whose window canvas is half its pixel size.
+	display := image.Rect(1920, 0, 1920+2560, 1440)
+	canvasSize := CanvasSize{W: 1280, H: 720}
+	start, end := CanvasPos{X: 100.25, Y: 50}, CanvasPos{X: 400.5, Y: 300.75}
+
+	// Drive the selection as the overlay does: its drags span canvasRectToScreenRect, the loupe
+	// reads out selectedRect while dragging and the overlay finishes with selectedRect after Enter.
+	drag := canvasDrag{start: start, end: end, canvasSize: canvasSize, displayBounds: display, desktop: display}
+	m := &selectionMachine{desktop: display, span: drag.rect}
+	m.Press(canvasToScreenPoint(start, canvasSize, display), handleTolerance)
+	cursor := canvasToScreenPoint(end, canvasSize, display)
+	m.Drag(cursor)
+	got := cursorReadout(cursor, display, selectedRect(m, false, image.Rectangle{}), m.Active())
+
+	m.Release(cursor)
+	m.Key(keyReturn, false)
+	if !m.Done() {
+		t.Fatalf("selection not done after Enter")
+	}
+	saved := selectedRect(m, false, image.Rectangle{})
+	if want := fmt.Sprintf("801, 602   %d×%d", saved.Dx(), saved.Dy()); got != want {
+		t.Fatalf("readout=%q want=%q", got, want)
+	}
+	if want := canvasRectToScreenRect(start, end, canvasSize, display); saved != want || saved != image.Rect(2121, 100, 2721, 602) {
+		t.Fatalf("saved=%v want=%v", saved, want)
+	}
+	if got := cursorReadout(cursor, display, image.Rectangle{}, false); got != "801, 602" {
+		t.Fatalf("hover readout=%q", got)
+	}
+
+	// While picking a window, the readout and the result are the hovered window.
+	hover := image.Rect(2000, 10, 2300, 210)
+	if got := selectedRect(m, true, hover); got != hover {
+		t.Fatalf("window selection=%v want=%v", got, hover)
+	}
+}
+
+func TestParseConstraint(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseConstraint(" 16:9 ", "1280X720", "64x0")
+	if err != nil {
+		t.Fatalf("ParseConstraint() error: %v", err)
+	}
+	want := Constraint{Aspect: image.Pt(16, 9), Size: image.Pt(1280, 720), Min: image.Pt(64, 0)}
+	if got != want {
+		t.Fatalf("ParseConstraint() got=%v want=%v", got, want)
+	}
+
+	if c, err := ParseConstraint("", "", ""); err != nil || !c.IsZero() {
+		t.Fatalf("ParseConstraint(empty) got=%v err=%v want zero, nil", c, err)
+	}
+	for _, in := range [][3]string{{"16x9", "", ""}, {"0:9", "", ""}, {"", "1280", ""}, {"", "0x720", ""}, {"", "", "-1x5"}} {
+		if _, err := ParseConstraint(in[0], in[1], in[2]); !errors.Is(err, ErrInvalidConstraint) {
+			t.Fatalf("ParseConstraint(%q) error=%v want=%v", in, err, ErrInvalidConstraint)
+		}
+	}
+}
+
+func TestConstrainRect_AspectFollowsLongerSide(t *testing.T) {
+	t.Parallel()
+
+	bounds := image.Rect(0, 0, 1000, 800)
+	c := Constraint{Aspect: image.Pt(16, 9)}
+	cases := []struct {
+		anchor, p image.Point
+		want      image.Rectangle
+	}{
+		{anchor: image.Pt(100, 100), p: image.Pt(260, 110), want: image.Rect(100, 100, 260, 190)},
+		{anchor: image.Pt(100, 100), p: image.Pt(110, 190), want: image.Rect(100, 100, 260, 190)},
+		// Dragging up-left grows towards the pointer.
+		{anchor: image.Pt(500, 500), p: image.Pt(340, 490), want: image.Rect(340, 410, 500, 500)},
+	}
+	for _, tc := range cases {
+		got := constrainRect(tc.anchor, tc.p, c, axisAuto, bounds)
+		if got != tc.want {
+			t.Fatalf("constrainRect(%v, %v) got=%v want=%v", tc.anchor, tc.p, got, tc.want)
+		}
+	}
+}
+
+func TestConstrainRect_ClampsToBoundsKeepingAspect(t *testing.T) {
+	t.Parallel()
+
+	bounds := image.Rect(-1920, 0, 0, 1080)
+	c := Constraint{Aspect: image.Pt(16, 9)}
+	// Only 160 px of room to the right: the height follows the clamped width.
+	got := constrainRect(image.Pt(-160, 100), image.Pt(400, 150), c, axisAuto, bounds)
+	if want := image.Rect(-160, 100, 0, 190); got != want {
+		t.Fatalf("clamped width got=%v want=%v", got, want)
+	}
+	// Only 90 px of room below: the width follows the clamped height.
+	got = constrainRect(image.Pt(-1000, 990), image.Pt(-1000, 1400), c, axisAuto, bounds)
+	if want := image.Rect(-1000, 990, -840, 1080); got != want {
+		t.Fatalf("clamped height got=%v want=%v", got, want)
+	}
+	if !got.In(bounds) {
+		t.Fatalf("constrainRect() %v outside bounds %v", got, bounds)
+	}
+}
+
+func TestConstrainRect_MinSize(t *testing.T) {
+	t.Parallel()
+
+	bounds := image.Rect(0, 0, 1000, 800)
+	got := constrainRect(image.Pt(100, 100), image.Pt(110, 300), Constraint{Min: image.Pt(64, 64)}, axisAuto, bounds)
+	if want := image.Rect(100, 100, 164, 300); got != want {
+		t.Fatalf("min size got=%v want=%v", got, want)
+	}
+
+	// With a locked ratio the minimum grows both sides.
+	got = constrainRect(image.Pt(100, 100), image.Pt(100, 100), Constraint{Aspect: image.Pt(16, 9), Min: image.Pt(0, 90)}, axisAuto, bounds)
+	if want := image.Rect(100, 100, 260, 190); got != want {
+		t.Fatalf("min size with aspect got=%v want=%v", got, want)
+	}
+
+	// Near the edge the bounds win over the minimum.
+	got = constrainRect(image.Pt(980, 100), image.Pt(985, 110), Constraint{Min: image.Pt(64, 64)}, axisAuto, bounds)
+	if want := image.Rect(980, 100, 1000, 164); got != want {
+		t.Fatalf("min size at edge got=%v want=%v", got, want)
+	}
+}
+
+func TestConstrainRect_FixedSizeSlidesInside(t *testing.T) {
+	t.Parallel()
+
+	bounds := image.Rect(0, 0, 1920, 1080)
+	c := Constraint{Size: image.Pt(1280, 720), Aspect: image.Pt(1, 1)}
+	if got, want := constrainRect(image.Pt(5, 5), image.Pt(100, 50), c, axisAuto, bounds), image.Rect(100, 50, 1380, 770); got != want {
+		t.Fatalf("fixed size got=%v want=%v", got, want)
+	}
+	if got, want := constrainRect(image.Pt(0, 0), image.Pt(1900, 1000), c, axisAuto, bounds), image.Rect(640, 360, 1920, 1080); got != want {
+		t.Fatalf("fixed size at corner got=%v want=%v", got, want)
+	}
+	// Larger than the desktop: clamped rather than placed off-screen.
+	big := Constraint{Size: image.Pt(4000, 100)}
+	if got, want := constrainRect(image.Pt(0, 0), image.Pt(50, 50), big, axisAuto, bounds), image.Rect(0, 50, 1920, 150); got != want {
+		t.Fatalf("oversized fixed size got=%v want=%v", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"time"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// apngEncoder builds an APNG from frames encoded by image/png: the first frame's IDAT chunks
+// become the default image and later frames are rewritten as fdAT chunks. Frames are flattened
+// onto black so every frame gets the same (RGB) IHDR, and kept compressed until finish, which
+// needs the frame count up front.
+type apngEncoder struct {
+	ihdr   []byte
+	frames []apngFrame
+}
+
+type apngFrame struct {
+	width, height int
+	data          [][]byte // IDAT payloads
+}
+
+func (e *apngEncoder) add(img image.Image) error {
+	b := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.Black, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, flat); err != nil {
+		return err
+	}
+	chunks, err := readChunks(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	f := apngFrame{width: b.Dx(), height: b.Dy()}
+	for _, c := range chunks {
+		switch c.typ {
+		case "IHDR":
+			if e.ihdr == nil {
+				e.ihdr = c.data
+			} else if !bytes.Equal(e.ihdr[8:], c.data[8:]) {
+				return errors.New("record: apng frames differ in pixel format")
+			}
+		case "IDAT":
+			f.data = append(f.data, c.data)
+		}
+	}
+	if len(e.frames) > 0 && (f.width != e.frames[0].width || f.height != e.frames[0].height) {
+		return fmt.Errorf("record: apng frame size %dx%d differs from %dx%d", f.width, f.height, e.frames[0].width, e.frames[0].height)
+	}
+	e.frames = append(e.frames, f)
+	return nil
+}
+
+func (e *apngEncoder) finish(w io.Writer, delays []time.Duration) error {
+	if len(e.frames) == 0 {
+		return ErrNoFrames
+	}
+	cw := &chunkWriter{w: w}
+	cw.raw(pngSignature)
+	cw.chunk("IHDR", e.ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(e.frames)))
+	binary.BigEndian.PutUint32(actl[4:], 0) // loop forever
+	cw.chunk("acTL", actl)
+
+	var seq uint32
+	for i, f := range e.frames {
+		cw.chunk("fcTL", frameControl(seq, f, delays[i]))
+		seq++
+		for _, data := range f.data {
+			if i == 0 {
+				cw.chunk("IDAT", data)
+				continue
+			}
+			fdat := make([]byte, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			copy(fdat[4:], data)
+			cw.chunk("fdAT", fdat)
+			seq++
+		}
+	}
+	cw.chunk("IEND", nil)
+	return cw.err
+}
+
+// frameControl returns an fcTL payload for a full-canvas frame shown for delay (in ms).
+func frameControl(seq uint32, f apngFrame, delay time.Duration) []byte {
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[0:], seq)
+	binary.BigEndian.PutUint32(fctl[4:], uint32(f.width))
+	binary.BigEndian.PutUint32(fctl[8:], uint32(f.height))
+	// x and y offsets stay 0.
+	ms := delay.Milliseconds()
+	if ms > 0xffff {
+		ms = 0xffff
+	}
+	binary.BigEndian.PutUint16(fctl[20:], uint16(ms))
+	binary.BigEndian.PutUint16(fctl[22:], 1000)
+	// dispose_op APNG_DISPOSE_OP_NONE, blend_op APNG_BLEND_OP_SOURCE.
+	fctl[24], fctl[25] = 0, 0
+	return fctl
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// readChunks splits a PNG stream into its chunks, checking the signature but not the CRCs.
+func readChunks(b []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil, errors.New("record: not a png stream")
+	}
+	b = b[len(pngSignature):]
+	var chunks []pngChunk
+	for len(b) >= 12 {
+		n := binary.BigEndian.Uint32(b)
+		if uint64(n)+12 > uint64(len(b)) {
+			return nil, errors.New("record: truncated png chunk")
+		}
+		chunks = append(chunks, pngChunk{typ: string(b[4:8]), data: b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	return chunks, nil
+}
+
+// chunkWriter writes PNG chunks, keeping the first error.
+type chunkWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (cw *chunkWriter) raw(b []byte) {
+	if cw.err == nil {
+		_, cw.err = cw.w.Write(b)
+	}
+}
+
+func (cw *chunkWriter) chunk(typ string, data []byte) {
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint32(head, uint32(len(data)))
+	copy(head[4:], typ)
+	crc := crc32.NewIEEE()
+	crc.Write(head[4:])
+	crc.Write(data)
+	tail := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+
+	cw.raw(head)
+	cw.raw(data)
+	cw.raw(tail)
+}
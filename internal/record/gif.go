@@ -0,0 +1,45 @@
+package record
+
+import (
+	"image"
+	"image/gif"
+	"io"
+	"time"
+
+	"go-snip/internal/utils"
+)
+
+// gifEncoder quantizes every frame to its own 256-colour palette (median cut) and collects
+// them for gif.EncodeAll. Paletted frames take one byte per pixel, so a recording stays in
+// memory until finish.
+type gifEncoder struct {
+	anim gif.GIF
+}
+
+func (e *gifEncoder) add(img image.Image) error {
+	e.anim.Image = append(e.anim.Image, utils.Quantize(img, 256))
+	return nil
+}
+
+func (e *gifEncoder) finish(w io.Writer, delays []time.Duration) error {
+	e.anim.Delay = gifDelays(delays)
+	return gif.EncodeAll(w, &e.anim)
+}
+
+// gifDelays converts delays to GIF's 1/100 s units, carrying rounding remainders into the next
+// frame so the total length stays right.
+func gifDelays(delays []time.Duration) []int {
+	const unit = 10 * time.Millisecond
+	out := make([]int, len(delays))
+	var carry time.Duration
+	for i, d := range delays {
+		d += carry
+		n := int(d / unit)
+		if n < 2 {
+			n = 2 // most viewers treat smaller delays as 10
+		}
+		carry = d - time.Duration(n)*unit
+		out[i] = n
+	}
+	return out
+}
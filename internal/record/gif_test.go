@@ -0,0 +1,21 @@
+package record
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGIFDelays_CarriesRemainders(t *testing.T) {
+	t.Parallel()
+
+	ms33 := 33 * time.Millisecond
+	got := gifDelays([]time.Duration{ms33, ms33, ms33, ms33, time.Millisecond})
+	// 33ms is 3.3 units: the remainders add up to an extra unit on the fourth frame; tiny
+	// delays are raised to the 2-unit minimum.
+	want := []int{3, 3, 3, 4, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("gifDelays() got=%v want=%v", got, want)
+		}
+	}
+}
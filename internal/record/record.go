@@ -0,0 +1,248 @@
+// Package record captures a screen region at a fixed frame rate and encodes it as an animated
+// GIF or APNG. Capture and encoding run on separate goroutines joined by a bounded buffer, so
+// a slow encoder makes the recorder drop (and count) frames instead of silently slowing down.
+package record
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+	"time"
+)
+
+// Output formats for Options.Format.
+const (
+	FormatGIF  = "gif"
+	FormatAPNG = "apng"
+)
+
+// Defaults used when the corresponding Options field is zero.
+const (
+	DefaultFPS         = 10
+	DefaultMaxDuration = 30 * time.Second
+	// MaxFPS is the highest frame rate GIF delays (in 1/100 s) can represent reliably.
+	MaxFPS = 50
+)
+
+var (
+	ErrUnknownFormat = errors.New("record: unknown format")
+	ErrNoFrames      = errors.New("record: no frames captured")
+)
+
+// Options configure a recording. Zero values mean "use the default".
+type Options struct {
+	// FPS is the capture rate, 1..MaxFPS (default DefaultFPS).
+	FPS int
+	// Format is FormatGIF (default) or FormatAPNG.
+	Format string
+	// Buffer is how many captured frames may wait for the encoder (default: one second's worth).
+	Buffer int
+}
+
+// Stats reports what a recording did.
+type Stats struct {
+	// Frames were encoded; Dropped ones were captured while the buffer was full; Failed ones
+	// couldn't be captured.
+	Frames, Dropped, Failed int
+	// Duration is the time from the first to the end of the last encoded frame.
+	Duration time.Duration
+}
+
+func (s Stats) String() string {
+	out := fmt.Sprintf("%d frames, %.1fs", s.Frames, s.Duration.Seconds())
+	if s.Dropped > 0 {
+		out += fmt.Sprintf(", %d dropped (encoder too slow)", s.Dropped)
+	}
+	if s.Failed > 0 {
+		out += fmt.Sprintf(", %d failed", s.Failed)
+	}
+	return out
+}
+
+// ParseFormat returns the canonical format name for s; an empty string is GIF.
+func ParseFormat(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "gif":
+		return FormatGIF, nil
+	case "apng", "png":
+		return FormatAPNG, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, s)
+}
+
+// Extension returns the file extension for format: ".gif", or ".png" for APNG, which
+// non-animating viewers still open as its first frame.
+func Extension(format string) string {
+	if f, _ := ParseFormat(format); f == FormatAPNG {
+		return ".png"
+	}
+	return ".gif"
+}
+
+// Interval returns the time between frames for o.FPS.
+func (o Options) Interval() time.Duration {
+	return time.Second / time.Duration(o.fps())
+}
+
+func (o Options) fps() int {
+	if o.FPS == 0 {
+		return DefaultFPS
+	}
+	return o.FPS
+}
+
+// Validate reports an unknown format or an out-of-range frame rate or buffer.
+func (o Options) Validate() error {
+	var errs []error
+	if _, err := ParseFormat(o.Format); err != nil {
+		errs = append(errs, err)
+	}
+	if o.FPS != 0 && (o.FPS < 1 || o.FPS > MaxFPS) {
+		errs = append(errs, fmt.Errorf("record: fps %d out of range 1..%d", o.FPS, MaxFPS))
+	}
+	if o.Buffer < 0 {
+		errs = append(errs, fmt.Errorf("record: negative buffer %d", o.Buffer))
+	}
+	return errors.Join(errs...)
+}
+
+// Record captures a frame on every tick until ctx is done or ticks is closed, then writes the
+// animation to w. Tick times become the frame timestamps, so pass Ticks(ctx, o.Interval()) or
+// a time.Ticker's channel. It fails with ErrNoFrames if nothing was captured.
+func Record(ctx context.Context, grab func() (image.Image, error), ticks <-chan time.Time, w io.Writer, o Options) (Stats, error) {
+	if err := o.Validate(); err != nil {
+		return Stats{}, err
+	}
+	format, _ := ParseFormat(o.Format)
+	var enc encoder = &gifEncoder{}
+	if format == FormatAPNG {
+		enc = &apngEncoder{}
+	}
+	buffer := o.Buffer
+	if buffer == 0 {
+		buffer = o.fps()
+	}
+
+	st, delays, err := pipeline(ctx, grab, ticks, enc, buffer, o.Interval())
+	if err != nil {
+		return st, err
+	}
+	if st.Frames == 0 {
+		return st, ErrNoFrames
+	}
+	return st, enc.finish(w, delays)
+}
+
+// Ticks returns a channel that delivers the current time right away and then every interval
+// until ctx is done, when it is closed. Unlike a bare time.Ticker it doesn't make a recording
+// wait one interval for its first frame.
+func Ticks(ctx context.Context, interval time.Duration) <-chan time.Time {
+	ticks := make(chan time.Time, 1)
+	ticks <- time.Now()
+	go func() {
+		defer close(ticks)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-ticker.C:
+				select {
+				case ticks <- t:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ticks
+}
+
+// encoder turns frames into an animation.
+type encoder interface {
+	// add appends a frame.
+	add(img image.Image) error
+	// finish writes the animation to w, showing frame i for delays[i].
+	finish(w io.Writer, delays []time.Duration) error
+}
+
+type frame struct {
+	img image.Image
+	at  time.Time
+}
+
+// pipeline runs the capture loop on the calling goroutine and the encoder on another, joined by
+// a channel holding up to buffer frames. A frame captured while the channel is full is dropped.
+// It returns how long to show each encoded frame: until the next one's timestamp, so drops keep
+// the playback speed right, and lastDelay for the last frame.
+func pipeline(ctx context.Context, grab func() (image.Image, error), ticks <-chan time.Time, enc encoder, buffer int, lastDelay time.Duration) (Stats, []time.Duration, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	frames := make(chan frame, buffer)
+	type result struct {
+		times []time.Time
+		err   error
+	}
+	encoded := make(chan result, 1)
+	go func() {
+		var res result
+		for f := range frames {
+			if res.err != nil {
+				continue // keep draining so the capture loop never blocks
+			}
+			if res.err = enc.add(f.img); res.err != nil {
+				cancel()
+				continue
+			}
+			res.times = append(res.times, f.at)
+		}
+		encoded <- res
+	}()
+
+	var st Stats
+	func() {
+		defer close(frames)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case at, ok := <-ticks:
+				if !ok || ctx.Err() != nil {
+					return
+				}
+				img, err := grab()
+				if err != nil {
+					st.Failed++
+					continue
+				}
+				select {
+				case frames <- frame{img: img, at: at}:
+				default:
+					st.Dropped++
+				}
+			}
+		}
+	}()
+
+	res := <-encoded
+	if res.err != nil {
+		return st, nil, res.err
+	}
+	delays := make([]time.Duration, len(res.times))
+	for i, at := range res.times {
+		delays[i] = lastDelay
+		if i+1 < len(res.times) && res.times[i+1].After(at) {
+			delays[i] = res.times[i+1].Sub(at)
+		}
+	}
+	st.Frames = len(res.times)
+	if st.Frames > 0 {
+		st.Duration = res.times[st.Frames-1].Sub(res.times[0]) + lastDelay
+	}
+	return st, delays, nil
+}
@@ -0,0 +1,301 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// solid returns a w×h image filled with c.
+func solid(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+// tickAt returns a closed channel holding n ticks spaced by step.
+func tickAt(n int, step time.Duration) <-chan time.Time {
+	ticks := make(chan time.Time, n)
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		ticks <- start.Add(time.Duration(i) * step)
+	}
+	close(ticks)
+	return ticks
+}
+
+// grabber returns a capture func cycling through red, green and blue frames.
+func grabber() func() (image.Image, error) {
+	colors := []color.RGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}}
+	n := 0
+	return func() (image.Image, error) {
+		c := colors[n%len(colors)]
+		n++
+		return solid(4, 3, c), nil
+	}
+}
+
+func TestRecord_GIF(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	st, err := Record(context.Background(), grabber(), tickAt(3, 100*time.Millisecond), &buf, Options{FPS: 10})
+	if err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	if st.Frames != 3 || st.Dropped != 0 || st.Duration != 300*time.Millisecond {
+		t.Fatalf("Record() stats got=%+v", st)
+	}
+
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() error: %v", err)
+	}
+	if len(anim.Image) != 3 {
+		t.Fatalf("frames got=%d want=3", len(anim.Image))
+	}
+	for i, d := range anim.Delay {
+		if d != 10 {
+			t.Fatalf("delay[%d] got=%d want=10", i, d)
+		}
+	}
+	r, g, b, _ := anim.Image[1].At(2, 1).RGBA()
+	if r != 0 || g>>8 != 255 || b != 0 {
+		t.Fatalf("frame 1 pixel got=(%d,%d,%d) want green", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestRecord_APNG(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	st, err := Record(context.Background(), grabber(), tickAt(3, 50*time.Millisecond), &buf, Options{FPS: 20, Format: "apng"})
+	if err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	if st.Frames != 3 {
+		t.Fatalf("Record() frames got=%d want=3", st.Frames)
+	}
+
+	// Viewers without APNG support show the first frame.
+	first, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("png.Decode() error: %v", err)
+	}
+	if got := color.RGBAModel.Convert(first.At(0, 0)); got != (color.RGBA{R: 255, A: 255}) {
+		t.Fatalf("first frame pixel got=%v want red", got)
+	}
+
+	chunks, err := readChunks(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readChunks() error: %v", err)
+	}
+	var types []string
+	var seqs []uint32
+	for _, c := range chunks {
+		types = append(types, c.typ)
+		switch c.typ {
+		case "acTL":
+			if n := binary.BigEndian.Uint32(c.data); n != 3 {
+				t.Fatalf("acTL frames got=%d want=3", n)
+			}
+		case "fcTL":
+			seqs = append(seqs, binary.BigEndian.Uint32(c.data))
+			if num, den := binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:]); num != 50 || den != 1000 {
+				t.Fatalf("fcTL delay got=%d/%d want=50/1000", num, den)
+			}
+		case "fdAT":
+			seqs = append(seqs, binary.BigEndian.Uint32(c.data))
+		}
+	}
+	if types[0] != "IHDR" || types[1] != "acTL" || types[2] != "fcTL" || types[3] != "IDAT" || types[len(types)-1] != "IEND" {
+		t.Fatalf("chunk order got=%v", types)
+	}
+	for i, s := range seqs {
+		if s != uint32(i) {
+			t.Fatalf("sequence numbers got=%v, want 0,1,2,...", seqs)
+		}
+	}
+}
+
+func TestRecord_NoFramesAndInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	failing := func() (image.Image, error) { return nil, errors.New("boom") }
+	st, err := Record(context.Background(), failing, tickAt(2, time.Second), io.Discard, Options{})
+	if !errors.Is(err, ErrNoFrames) || st.Failed != 2 {
+		t.Fatalf("Record() got=%+v,%v want 2 failed and ErrNoFrames", st, err)
+	}
+
+	for _, o := range []Options{{Format: "webm"}, {FPS: 60}, {FPS: -1}, {Buffer: -2}} {
+		if _, err := Record(context.Background(), grabber(), tickAt(1, time.Second), io.Discard, o); err == nil {
+			t.Fatalf("Record(%+v) error=nil", o)
+		}
+	}
+}
+
+func TestRecord_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	done := make(chan error, 1)
+	var buf bytes.Buffer
+	go func() {
+		_, err := Record(ctx, grabber(), ticks, &buf, Options{})
+		done <- err
+	}()
+	ticks <- time.Now()
+	ticks <- time.Now().Add(100 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	if _, err := gif.DecodeAll(&buf); err != nil {
+		t.Fatalf("gif.DecodeAll() error: %v", err)
+	}
+}
+
+// blockingEncoder blocks its first add until release is closed.
+type blockingEncoder struct {
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (e *blockingEncoder) add(image.Image) error {
+	e.once.Do(func() {
+		close(e.entered)
+		<-e.release
+	})
+	return nil
+}
+
+func (e *blockingEncoder) finish(io.Writer, []time.Duration) error { return nil }
+
+func TestPipeline_DropsFramesWhenEncoderLags(t *testing.T) {
+	t.Parallel()
+
+	enc := &blockingEncoder{entered: make(chan struct{}), release: make(chan struct{})}
+	ticks := make(chan time.Time)
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tick := func(i int) { ticks <- start.Add(time.Duration(i) * 100 * time.Millisecond) }
+
+	type result struct {
+		st     Stats
+		delays []time.Duration
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		// The fifth capture fails, so its tick only serves to wait for the fourth to be handled.
+		grab, n := grabber(), 0
+		failFifth := func() (image.Image, error) {
+			if n++; n == 5 {
+				return nil, errors.New("boom")
+			}
+			return grab()
+		}
+		st, delays, err := pipeline(context.Background(), failFifth, ticks, enc, 1, 100*time.Millisecond)
+		done <- result{st, delays, err}
+	}()
+
+	tick(0)
+	<-enc.entered // encoding frame 0 blocks
+	tick(1)       // fills the one-frame buffer
+	tick(2)       // dropped
+	tick(3)       // dropped
+	tick(4)
+	close(ticks)
+	close(enc.release)
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("pipeline() error: %v", res.err)
+	}
+	if res.st.Frames != 2 || res.st.Dropped != 2 || res.st.Failed != 1 {
+		t.Fatalf("pipeline() stats got=%+v want 2 frames, 2 dropped, 1 failed", res.st)
+	}
+	if len(res.delays) != 2 || res.delays[0] != 100*time.Millisecond || res.delays[1] != 100*time.Millisecond {
+		t.Fatalf("delays got=%v want=[100ms 100ms]", res.delays)
+	}
+}
+
+func TestPipeline_DelaysSpanDroppedFrames(t *testing.T) {
+	t.Parallel()
+
+	enc := &gifEncoder{}
+	ticks := make(chan time.Time, 3)
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	// A gap in the ticks (as left by dropped frames) keeps the previous frame up longer.
+	for _, ms := range []int{0, 100, 400} {
+		ticks <- start.Add(time.Duration(ms) * time.Millisecond)
+	}
+	close(ticks)
+
+	st, delays, err := pipeline(context.Background(), grabber(), ticks, enc, 3, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("pipeline() error: %v", err)
+	}
+	want := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 100 * time.Millisecond}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("delays got=%v want=%v", delays, want)
+		}
+	}
+	if st.Duration != 500*time.Millisecond {
+		t.Fatalf("duration got=%v want=500ms", st.Duration)
+	}
+}
+
+func TestParseFormatAndExtension(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in, want, ext string
+	}{
+		{in: "", want: FormatGIF, ext: ".gif"},
+		{in: "GIF", want: FormatGIF, ext: ".gif"},
+		{in: "apng", want: FormatAPNG, ext: ".png"},
+		{in: "png", want: FormatAPNG, ext: ".png"},
+	}
+	for _, tc := range cases {
+		got, err := ParseFormat(tc.in)
+		if err != nil || got != tc.want {
+			t.Fatalf("ParseFormat(%q) got=%q,%v want=%q", tc.in, got, err, tc.want)
+		}
+		if ext := Extension(tc.in); ext != tc.ext {
+			t.Fatalf("Extension(%q) got=%q want=%q", tc.in, ext, tc.ext)
+		}
+	}
+	if _, err := ParseFormat("mp4"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("ParseFormat(mp4) error=%v want=%v", err, ErrUnknownFormat)
+	}
+}
+
+func TestTicks_FirstRightAwayAndClosedOnCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := Ticks(ctx, time.Hour)
+	select {
+	case <-ticks:
+	default:
+		t.Fatalf("Ticks() has no tick right away")
+	}
+	cancel()
+	if _, ok := <-ticks; ok {
+		t.Fatalf("Ticks() delivered a tick after cancel")
+	}
+}
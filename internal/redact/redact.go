@@ -0,0 +1,267 @@
+// Package redact hides parts of a capture before it is saved or shared.
+//
+// Every mode is irreversible: the output inside a region is computed only from coarse block
+// averages (or a constant), so no original pixel value survives and the detail that was there
+// can't be recovered by sharpening or deconvolution.
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// Redaction modes for Region.Mode.
+const (
+	// ModePixelate replaces each block of the region with its average colour.
+	ModePixelate = "pixelate"
+	// ModeBlur pixelates the region, then smooths the blocks with a Gaussian blur.
+	ModeBlur = "blur"
+	// ModeFill paints the region with an opaque solid colour.
+	ModeFill = "fill"
+)
+
+const (
+	// DefaultBlockSize is the pixelation block edge in pixels when Region.Strength is zero.
+	DefaultBlockSize = 12
+	// MinBlockSize is the smallest block used; smaller blocks leave text readable.
+	MinBlockSize = 4
+	// DefaultSigma is the Gaussian blur sigma in pixels when Region.Strength is zero.
+	DefaultSigma = 8
+)
+
+var ErrUnknownMode = errors.New("redact: unknown mode")
+
+// Region is one redacted rectangle in the pixel coordinates of the image.
+type Region struct {
+	Rect image.Rectangle
+	Mode string
+	// Strength is the block size for ModePixelate or the blur sigma for ModeBlur, in pixels.
+	// Zero uses the default.
+	Strength int
+	// Color is the fill colour for ModeFill; its alpha is ignored so the fill is always opaque.
+	Color color.Color
+}
+
+// Validate reports an unknown mode.
+func (r Region) Validate() error {
+	switch strings.ToLower(strings.TrimSpace(r.Mode)) {
+	case ModePixelate, ModeBlur, ModeFill:
+		return nil
+	}
+	return fmt.Errorf("%w %q", ErrUnknownMode, r.Mode)
+}
+
+// Apply returns a copy of img with every region redacted, in order. Regions are clipped to
+// img's bounds; the copy has img's bounds.
+func Apply(img image.Image, regions []Region) (*image.RGBA, error) {
+	for _, r := range regions {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	for _, r := range regions {
+		ApplyRegion(dst, r)
+	}
+	return dst, nil
+}
+
+// ApplyRegion redacts r in place. Unknown modes are ignored (see Region.Validate).
+func ApplyRegion(dst *image.RGBA, r Region) {
+	switch strings.ToLower(strings.TrimSpace(r.Mode)) {
+	case ModePixelate:
+		Pixelate(dst, r.Rect, r.Strength)
+	case ModeBlur:
+		Blur(dst, r.Rect, r.Strength)
+	case ModeFill:
+		c := r.Color
+		if c == nil {
+			c = color.Black
+		}
+		Fill(dst, r.Rect, c)
+	}
+}
+
+// Fill paints rect with c made fully opaque.
+func Fill(dst *image.RGBA, rect image.Rectangle, c color.Color) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = 255
+	draw.Draw(dst, rect.Intersect(dst.Bounds()), image.NewUniform(n), image.Point{}, draw.Src)
+}
+
+// Pixelate replaces each cell of rect with the cell's average colour. Cells are block x block
+// (aligned to rect.Min), except that a leftover strip narrower than block at the right or
+// bottom edge is merged into the cell before it, so no cell is smaller than block unless rect
+// itself is. Blocks smaller than MinBlockSize are enlarged to it; 0 uses DefaultBlockSize.
+//
+// A side of rect shorter than MinBlockSize is grown to it around its centre (within dst), so
+// a tiny region is averaged with its surroundings instead of with itself. If dst is too small
+// for that in both directions, rect is filled with black.
+func Pixelate(dst *image.RGBA, rect image.Rectangle, block int) {
+	if block == 0 {
+		block = DefaultBlockSize
+	}
+	block = max(block, MinBlockSize)
+	b := dst.Bounds()
+	rect = rect.Intersect(b)
+	if rect.Empty() {
+		return
+	}
+	rect.Min.X, rect.Max.X = growSpan(rect.Min.X, rect.Max.X, MinBlockSize, b.Min.X, b.Max.X)
+	rect.Min.Y, rect.Max.Y = growSpan(rect.Min.Y, rect.Max.Y, MinBlockSize, b.Min.Y, b.Max.Y)
+	if rect.Dx() < MinBlockSize && rect.Dy() < MinBlockSize {
+		Fill(dst, rect, color.Black)
+		return
+	}
+
+	ys := cellEdges(rect.Min.Y, rect.Max.Y, block)
+	xs := cellEdges(rect.Min.X, rect.Max.X, block)
+	for j := 1; j < len(ys); j++ {
+		for i := 1; i < len(xs); i++ {
+			cell := image.Rect(xs[i-1], ys[j-1], xs[i], ys[j])
+			avg := average(dst, cell)
+			draw.Draw(dst, cell, image.NewUniform(avg), image.Point{}, draw.Src)
+		}
+	}
+}
+
+// growSpan widens [lo, hi) to size around its centre, shifted to stay within [minV, maxV).
+// A span that is already that wide is returned unchanged; one that can't fit is clipped.
+func growSpan(lo, hi, size, minV, maxV int) (int, int) {
+	if hi-lo >= size {
+		return lo, hi
+	}
+	lo -= (size - (hi - lo)) / 2
+	hi = lo + size
+	if lo < minV {
+		lo, hi = minV, hi+minV-lo
+	}
+	if hi > maxV {
+		lo, hi = lo-(hi-maxV), maxV
+	}
+	return max(lo, minV), hi
+}
+
+// cellEdges splits [lo, hi) into cells of block pixels and returns their edges, lo and hi
+// included. The remainder goes to the last cell, which is therefore at most 2*block-1 wide.
+func cellEdges(lo, hi, block int) []int {
+	if hi <= lo {
+		return nil
+	}
+	edges := []int{lo}
+	for x := lo + block; x+block <= hi; x += block {
+		edges = append(edges, x)
+	}
+	return append(edges, hi)
+}
+
+// Blur pixelates rect with blocks of about sigma pixels, then applies a Gaussian blur of sigma
+// to the result, clamped at the edges of rect so pixels outside it neither leak in nor change.
+// Blurring the blocks rather than the original is what makes the blur irreversible.
+// A sigma of 0 uses DefaultSigma.
+func Blur(dst *image.RGBA, rect image.Rectangle, sigma int) {
+	if sigma <= 0 {
+		sigma = DefaultSigma
+	}
+	rect = rect.Intersect(dst.Bounds())
+	if rect.Empty() {
+		return
+	}
+	Pixelate(dst, rect, sigma)
+
+	kernel := gaussianKernel(float64(sigma))
+	w, h := rect.Dx(), rect.Dy()
+	buf := make([][4]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := dst.RGBAAt(rect.Min.X+x, rect.Min.Y+y)
+			buf[y*w+x] = [4]float64{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+		}
+	}
+	buf = convolve(buf, w, h, kernel, true)
+	buf = convolve(buf, w, h, kernel, false)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := buf[y*w+x]
+			dst.SetRGBA(rect.Min.X+x, rect.Min.Y+y, color.RGBA{
+				R: clamp8(v[0]), G: clamp8(v[1]), B: clamp8(v[2]), A: clamp8(v[3]),
+			})
+		}
+	}
+}
+
+// average returns the mean (premultiplied) colour of cell.
+func average(img *image.RGBA, cell image.Rectangle) color.RGBA {
+	var sum [4]int
+	for y := cell.Min.Y; y < cell.Max.Y; y++ {
+		for x := cell.Min.X; x < cell.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			sum[0] += int(c.R)
+			sum[1] += int(c.G)
+			sum[2] += int(c.B)
+			sum[3] += int(c.A)
+		}
+	}
+	n := cell.Dx() * cell.Dy()
+	if n == 0 {
+		return color.RGBA{}
+	}
+	return color.RGBA{
+		R: uint8((sum[0] + n/2) / n),
+		G: uint8((sum[1] + n/2) / n),
+		B: uint8((sum[2] + n/2) / n),
+		A: uint8((sum[3] + n/2) / n),
+	}
+}
+
+// gaussianKernel returns a normalized 1-D kernel covering three sigmas either side.
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	k := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range k {
+		d := float64(i - radius)
+		k[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += k[i]
+	}
+	for i := range k {
+		k[i] /= sum
+	}
+	return k
+}
+
+// convolve runs kernel along rows (horizontal) or columns of a w x h buffer, clamping at the edges.
+func convolve(src [][4]float64, w, h int, kernel []float64, horizontal bool) [][4]float64 {
+	radius := len(kernel) / 2
+	out := make([][4]float64, len(src))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var acc [4]float64
+			for i, kv := range kernel {
+				sx, sy := x, y
+				if horizontal {
+					sx = min(max(x+i-radius, 0), w-1)
+				} else {
+					sy = min(max(y+i-radius, 0), h-1)
+				}
+				v := src[sy*w+sx]
+				for c := range acc {
+					acc[c] += v[c] * kv
+				}
+			}
+			out[y*w+x] = acc
+		}
+	}
+	return out
+}
+
+func clamp8(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
@@ -0,0 +1,239 @@
+package redact
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// checkerboard returns a w x h image of alternating black and white pixels.
+func checkerboard(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(0)
+			if (x+y)%2 == 0 {
+				v = 255
+			}
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func flatGray(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+		if i%4 == 3 {
+			img.Pix[i] = 255
+		}
+	}
+	return img
+}
+
+func TestApply_NoOriginalPixelsSurvive(t *testing.T) {
+	t.Parallel()
+
+	rect := image.Rect(4, 4, 36, 28)
+	for _, mode := range []string{ModePixelate, ModeBlur, ModeFill} {
+		src := checkerboard(40, 32)
+		out, err := Apply(src, []Region{{Rect: rect, Mode: mode, Color: color.White}})
+		if err != nil {
+			t.Fatalf("%s: Apply() error: %v", mode, err)
+		}
+		if mode == ModeFill {
+			if got := out.RGBAAt(10, 10); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+				t.Fatalf("fill pixel=%v want white", got)
+			}
+			continue
+		}
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				if c := out.RGBAAt(x, y); c.R == 0 || c.R == 255 {
+					t.Fatalf("%s: pixel (%d,%d)=%v is an original black/white value", mode, x, y, c)
+				}
+			}
+		}
+		if out.RGBAAt(0, 0) != src.RGBAAt(0, 0) || out.RGBAAt(39, 31) != src.RGBAAt(39, 31) {
+			t.Fatalf("%s: pixels outside the region changed", mode)
+		}
+	}
+}
+
+func TestApply_DetailIsUnrecoverable(t *testing.T) {
+	t.Parallel()
+
+	// A checkerboard and flat gray have the same block averages, so after redaction they must
+	// be indistinguishable: nothing of the pattern is left to recover.
+	rect := image.Rect(0, 0, 24, 24)
+	for _, mode := range []string{ModePixelate, ModeBlur} {
+		a, _ := Apply(checkerboard(24, 24), []Region{{Rect: rect, Mode: mode}})
+		b, _ := Apply(flatGray(24, 24), []Region{{Rect: rect, Mode: mode}})
+		for y := 0; y < 24; y++ {
+			for x := 0; x < 24; x++ {
+				ca, cb := a.RGBAAt(x, y), b.RGBAAt(x, y)
+				if absDiff(ca.R, cb.R) > 1 {
+					t.Fatalf("%s: pixel (%d,%d) differs: %v vs %v", mode, x, y, ca, cb)
+				}
+			}
+		}
+	}
+}
+
+func TestApply_SourceUntouchedAndClipped(t *testing.T) {
+	t.Parallel()
+
+	src := checkerboard(10, 10)
+	out, err := Apply(src, []Region{{Rect: image.Rect(-50, -50, 5, 5), Mode: ModeBlur}})
+	if err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	if src.RGBAAt(0, 0).R != 255 {
+		t.Fatalf("source was modified")
+	}
+	if out.Bounds() != src.Bounds() {
+		t.Fatalf("bounds=%v want=%v", out.Bounds(), src.Bounds())
+	}
+}
+
+func TestApply_UnknownMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := Apply(checkerboard(4, 4), []Region{{Rect: image.Rect(0, 0, 2, 2), Mode: "swirl"}})
+	if !errors.Is(err, ErrUnknownMode) {
+		t.Fatalf("Apply() error=%v want=%v", err, ErrUnknownMode)
+	}
+}
+
+func TestFill_IsOpaque(t *testing.T) {
+	t.Parallel()
+
+	img := checkerboard(4, 4)
+	Fill(img, img.Bounds(), color.NRGBA{R: 10, A: 0})
+	if got := img.RGBAAt(1, 1); got != (color.RGBA{R: 10, A: 255}) {
+		t.Fatalf("pixel=%v want opaque fill", got)
+	}
+}
+
+func TestApply_NoEdgeCellKeepsOriginalPixels(t *testing.T) {
+	t.Parallel()
+
+	// Sizes one pixel past a whole number of blocks used to leave 1px edge cells, which are
+	// their own average and so kept the original pixels.
+	for _, tc := range []struct {
+		mode     string
+		strength int
+		size     int
+	}{
+		{ModePixelate, 0, DefaultBlockSize + 1},
+		{ModePixelate, 0, 2*DefaultBlockSize + 1},
+		{ModePixelate, MinBlockSize, 2*MinBlockSize + 1},
+		{ModePixelate, 5, 3*5 + 1},
+		{ModeBlur, 0, DefaultSigma + 1},
+		{ModeBlur, 0, 3*DefaultSigma + 1},
+		{ModeBlur, MinBlockSize, 2*MinBlockSize + 1},
+	} {
+		src := checkerboard(tc.size+2, tc.size+3)
+		rect := image.Rect(1, 2, 1+tc.size, 2+tc.size)
+		out, err := Apply(src, []Region{{Rect: rect, Mode: tc.mode, Strength: tc.strength}})
+		if err != nil {
+			t.Fatalf("%s/%d: Apply() error: %v", tc.mode, tc.size, err)
+		}
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				if got := out.RGBAAt(x, y); got == src.RGBAAt(x, y) {
+					t.Fatalf("%s strength=%d size=%d: pixel (%d,%d)=%v kept its original value", tc.mode, tc.strength, tc.size, x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestPixelate_TinyRegionLosesOriginalPixel(t *testing.T) {
+	t.Parallel()
+
+	// A 1x1 region is its own average; it has to be pixelated with its neighbours.
+	src := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: uint8(x * 16), G: uint8(y * 16), B: 7, A: 255})
+		}
+	}
+	for _, pt := range []image.Point{{7, 9}, {0, 0}, {15, 15}} {
+		for _, mode := range []string{ModePixelate, ModeBlur} {
+			out, err := Apply(src, []Region{{Rect: image.Rectangle{Min: pt, Max: pt.Add(image.Pt(1, 1))}, Mode: mode}})
+			if err != nil {
+				t.Fatalf("%s %v: Apply() error: %v", mode, pt, err)
+			}
+			if got := out.RGBAAt(pt.X, pt.Y); got == src.RGBAAt(pt.X, pt.Y) {
+				t.Fatalf("%s %v: pixel kept its original value %v", mode, pt, got)
+			}
+		}
+	}
+
+	// An image smaller than a block can't be pixelated; the region is filled instead.
+	tiny := checkerboard(2, 2)
+	Pixelate(tiny, image.Rect(0, 0, 1, 1), 0)
+	if got := tiny.RGBAAt(0, 0); got != (color.RGBA{A: 255}) {
+		t.Fatalf("tiny image pixel=%v want opaque black", got)
+	}
+}
+
+func TestGrowSpan(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		lo, hi, size, minV, maxV int
+		wantLo, wantHi           int
+	}{
+		{7, 8, 4, 0, 16, 6, 10},
+		{0, 1, 4, 0, 16, 0, 4},
+		{15, 16, 4, 0, 16, 12, 16},
+		{2, 10, 4, 0, 16, 2, 10},
+		{0, 1, 4, 0, 2, 0, 2},
+	} {
+		lo, hi := growSpan(tc.lo, tc.hi, tc.size, tc.minV, tc.maxV)
+		if lo != tc.wantLo || hi != tc.wantHi {
+			t.Fatalf("growSpan(%d, %d, %d, %d, %d) got=[%d,%d) want=[%d,%d)", tc.lo, tc.hi, tc.size, tc.minV, tc.maxV, lo, hi, tc.wantLo, tc.wantHi)
+		}
+	}
+}
+
+func TestCellEdges(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		lo, hi, block int
+		want          []int
+	}{
+		{0, 24, 12, []int{0, 12, 24}},
+		{0, 25, 12, []int{0, 12, 25}},
+		{3, 9, 4, []int{3, 9}},
+		{0, 3, 4, []int{0, 3}},
+		{5, 5, 4, nil},
+	} {
+		if got := cellEdges(tc.lo, tc.hi, tc.block); !slices.Equal(got, tc.want) {
+			t.Fatalf("cellEdges(%d, %d, %d) got=%v want=%v", tc.lo, tc.hi, tc.block, got, tc.want)
+		}
+	}
+}
+
+func TestPixelate_EnforcesMinimumBlock(t *testing.T) {
+	t.Parallel()
+
+	img := checkerboard(8, 8)
+	Pixelate(img, img.Bounds(), 1)
+	if got := img.RGBAAt(0, 0); got.R != 128 {
+		t.Fatalf("pixel=%v want the 4x4 block average", got)
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
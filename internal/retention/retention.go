@@ -0,0 +1,219 @@
+// Package retention finds and deletes old captures in the output directory according to
+// age, total size and count limits.
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Policy limits what is kept. Zero fields disable their limit.
+type Policy struct {
+	// MaxAge deletes files modified longer ago.
+	MaxAge time.Duration
+	// MaxBytes and MaxFiles keep the newest files within that total size and count.
+	MaxBytes int64
+	MaxFiles int
+}
+
+// IsZero reports whether p has no limits.
+func (p Policy) IsZero() bool {
+	return p.MaxAge <= 0 && p.MaxBytes <= 0 && p.MaxFiles <= 0
+}
+
+// Validate reports negative limits.
+func (p Policy) Validate() error {
+	var errs []error
+	if p.MaxAge < 0 {
+		errs = append(errs, fmt.Errorf("retention: negative max age %v", p.MaxAge))
+	}
+	if p.MaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("retention: negative max size %d", p.MaxBytes))
+	}
+	if p.MaxFiles < 0 {
+		errs = append(errs, fmt.Errorf("retention: negative max files %d", p.MaxFiles))
+	}
+	return errors.Join(errs...)
+}
+
+// File is a capture found by Scan.
+type File struct {
+	// Path is the file path, Rel the slash-separated path relative to the scanned root.
+	Path, Rel string
+	Size      int64
+	ModTime   time.Time
+}
+
+// Matcher reports whether a slash-separated path relative to the output directory was named
+// by go-snip.
+type Matcher func(rel string) bool
+
+// dateDir is the optional outDir/YYYY/MM/DD prefix of date folders.
+var dateDir = regexp.MustCompile(`^\d{4}/\d{2}/\d{2}/`)
+
+// generated matches the names go-snip picks without a filename template: timestamped captures
+// and recordings (YYYYMMDD_HHMMSS_mmm[_001][ - name][ - 001].ext) and timelapse frames
+// (timelapse_YYYYMMDD_HHMMSS[_001]/frame-00001.ext).
+var generated = regexp.MustCompile(`^(?:\d{8}_\d{6}_\d{3}(?:_\d{3,})?(?: - [^/]+)?|timelapse_\d{8}_\d{6}(?:_\d{3,})?/frame-\d{5,})\.[0-9A-Za-z]+$`)
+
+// extensions are the file types go-snip writes.
+var extensions = map[string]bool{".png": true, ".jpg": true, ".gif": true, ".bmp": true, ".tiff": true}
+
+// NamingScheme returns the Matcher for go-snip's file names: the timestamped default names,
+// timelapse frames and, if template is non-nil, paths it matches (see
+// utils.FilenameTemplate.Pattern), each optionally inside a YYYY/MM/DD date folder. Only image
+// and animation extensions go-snip writes are matched.
+func NamingScheme(template *regexp.Regexp) Matcher {
+	match := func(rel string) bool {
+		return generated.MatchString(rel) || template != nil && template.MatchString(rel)
+	}
+	return func(rel string) bool {
+		if !extensions[strings.ToLower(filepath.Ext(rel))] {
+			return false
+		}
+		if match(rel) {
+			return true
+		}
+		return dateDir.MatchString(rel) && match(dateDir.ReplaceAllString(rel, ""))
+	}
+}
+
+// Scan returns the regular files below root that match, newest first. A missing root has no
+// files.
+func Scan(root string, match Matcher) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !match(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, File{Path: path, Rel: rel, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].ModTime.Equal(files[j].ModTime) {
+			return files[i].ModTime.After(files[j].ModTime)
+		}
+		return files[i].Rel > files[j].Rel
+	})
+	return files, err
+}
+
+// Expired returns the files p doesn't keep, oldest first. files must be newest first, as Scan
+// returns them: the newest are kept until one is too old or the count or size limit is reached.
+func Expired(files []File, p Policy, now time.Time) []File {
+	var total int64
+	for i, f := range files {
+		total += f.Size
+		tooOld := p.MaxAge > 0 && now.Sub(f.ModTime) > p.MaxAge
+		tooMany := p.MaxFiles > 0 && i >= p.MaxFiles
+		tooBig := p.MaxBytes > 0 && total > p.MaxBytes
+		if tooOld || tooMany || tooBig {
+			expired := append([]File(nil), files[i:]...)
+			for l, r := 0, len(expired)-1; l < r; l, r = l+1, r-1 {
+				expired[l], expired[r] = expired[r], expired[l]
+			}
+			return expired
+		}
+	}
+	return nil
+}
+
+// Result counts the files and bytes a Remove deleted (or, in a dry run, would delete).
+type Result struct {
+	Files int
+	Bytes int64
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d files, %s", r.Files, FormatBytes(r.Bytes))
+}
+
+// Remove deletes files and then the folders below root they leave empty (date and timelapse
+// folders). It keeps going after a failure and returns what was deleted with the errors.
+func Remove(root string, files []File) (Result, error) {
+	var res Result
+	var errs []error
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		if err := os.Remove(f.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		res.Files++
+		res.Bytes += f.Size
+		for dir := filepath.Dir(f.Path); IsBelow(root, dir); dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	// Deepest first, so parents are empty by the time they are tried. Removing a folder that
+	// still has files fails, which is how other content is left alone.
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, dir := range sorted {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			_ = os.Remove(dir)
+		}
+	}
+	return res, errors.Join(errs...)
+}
+
+// IsBelow reports whether dir is strictly inside root.
+func IsBelow(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Enforce deletes what p doesn't keep of the matching files below root.
+func Enforce(root string, match Matcher, p Policy, now time.Time) (Result, error) {
+	if p.IsZero() {
+		return Result{}, nil
+	}
+	files, err := Scan(root, match)
+	if err != nil {
+		return Result{}, err
+	}
+	return Remove(root, Expired(files, p, now))
+}
+
+// FormatBytes formats n with a binary unit, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,155 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// write creates root/rel with size bytes, modified age ago.
+func write(t *testing.T, root, rel string, size int, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatalf("write %q: %v", rel, err)
+	}
+	if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+		t.Fatalf("chtimes %q: %v", rel, err)
+	}
+}
+
+func rels(files []File) []string {
+	var out []string
+	for _, f := range files {
+		out = append(out, f.Rel)
+	}
+	return out
+}
+
+func TestNamingScheme(t *testing.T) {
+	t.Parallel()
+
+	match := NamingScheme(regexp.MustCompile(`^shots/[a-z]+_\d{3,}\.[0-9A-Za-z]+$`))
+	cases := map[string]bool{
+		"20250102_030405_000.png":                              true,
+		"20250102_030405_000_001.jpg":                          true,
+		"20250102_030405_000 - login bug.png":                  true,
+		"20250102_030405_000 - recording - 002.gif":            true,
+		"2026/10/16/20250102_030405_000.tiff":                  true,
+		"timelapse_20250102_030405/frame-00012.png":            true,
+		"timelapse_20250102_030405_001/frame-00001.png":        true,
+		"2026/10/16/timelapse_20250102_030405/frame-00001.bmp": true,
+		"shots/area_001.png":                                   true,
+		"2026/10/16/shots/area_001.png":                        true,
+		"holiday.png":                                          false,
+		"20250102_030405_000.txt":                              false,
+		"20250102_030405_000.png.bak":                          false,
+		"notes/20250102_030405_000 - x.psd":                    false,
+		"timelapse_20250102_030405/other.png":                  false,
+		"2026/10/holiday.png":                                  false,
+		"shots/area_001.txt":                                   false,
+	}
+	for rel, want := range cases {
+		if got := match(rel); got != want {
+			t.Fatalf("match(%q) got=%v want=%v", rel, got, want)
+		}
+	}
+	if NamingScheme(nil)("shots/area_001.png") {
+		t.Fatalf("match without a template accepted a template name")
+	}
+}
+
+func TestExpired(t *testing.T) {
+	t.Parallel()
+
+	files := []File{
+		{Rel: "a", Size: 10, ModTime: now.Add(-time.Hour)},
+		{Rel: "b", Size: 10, ModTime: now.Add(-2 * time.Hour)},
+		{Rel: "c", Size: 10, ModTime: now.Add(-48 * time.Hour)},
+		{Rel: "d", Size: 10, ModTime: now.Add(-72 * time.Hour)},
+	}
+	cases := []struct {
+		policy Policy
+		want   []string
+	}{
+		{policy: Policy{}, want: nil},
+		{policy: Policy{MaxAge: 24 * time.Hour}, want: []string{"d", "c"}},
+		{policy: Policy{MaxFiles: 3}, want: []string{"d"}},
+		{policy: Policy{MaxBytes: 25}, want: []string{"d", "c"}},
+		{policy: Policy{MaxBytes: 5}, want: []string{"d", "c", "b", "a"}},
+		{policy: Policy{MaxAge: 50 * time.Hour, MaxFiles: 1}, want: []string{"d", "c", "b"}},
+	}
+	for _, tc := range cases {
+		if got := rels(Expired(files, tc.policy, now)); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("Expired(%+v) got=%v want=%v", tc.policy, got, tc.want)
+		}
+	}
+}
+
+func TestEnforce_OnlyTouchesGeneratedFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	write(t, root, "20250102_030405_000.png", 100, 40*24*time.Hour)
+	write(t, root, "2025/01/02/20250102_030406_000.png", 100, 40*24*time.Hour)
+	write(t, root, "timelapse_20250102_030405/frame-00001.png", 100, 40*24*time.Hour)
+	write(t, root, "timelapse_20250102_030405/frame-00002.png", 100, 40*24*time.Hour)
+	write(t, root, "holiday.png", 100, 400*24*time.Hour)
+	write(t, root, "2025/01/notes.txt", 100, 400*24*time.Hour)
+	write(t, root, "20260101_000000_000 - keep.png", 100, time.Hour)
+
+	res, err := Enforce(root, NamingScheme(nil), Policy{MaxAge: 30 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatalf("Enforce() error: %v", err)
+	}
+	if res != (Result{Files: 4, Bytes: 400}) {
+		t.Fatalf("Enforce() got=%+v want 4 files, 400 bytes", res)
+	}
+
+	left, err := Scan(root, func(string) bool { return true })
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+	want := []string{"20260101_000000_000 - keep.png", "holiday.png", "2025/01/notes.txt"}
+	if got := rels(left); !reflect.DeepEqual(got, want) {
+		t.Fatalf("left got=%v want=%v", got, want)
+	}
+	for _, dir := range []string{"timelapse_20250102_030405", "2025/01/02"} {
+		if _, err := os.Stat(filepath.Join(root, dir)); !os.IsNotExist(err) {
+			t.Fatalf("emptied folder %q not removed: %v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "2025", "01")); err != nil {
+		t.Fatalf("folder with other files removed: %v", err)
+	}
+}
+
+func TestScan_MissingRoot(t *testing.T) {
+	t.Parallel()
+
+	files, err := Scan(filepath.Join(t.TempDir(), "missing"), NamingScheme(nil))
+	if err != nil || len(files) != 0 {
+		t.Fatalf("Scan(missing) got=%v err=%v", files, err)
+	}
+}
+
+func TestPolicyValidateAndFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	if err := (Policy{MaxAge: -1, MaxFiles: -1}).Validate(); err == nil {
+		t.Fatalf("Validate() accepted negative limits")
+	}
+	for n, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := FormatBytes(n); got != want {
+			t.Fatalf("FormatBytes(%d) got=%q want=%q", n, got, want)
+		}
+	}
+}
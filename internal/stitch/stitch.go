@@ -0,0 +1,184 @@
+// Package stitch joins frames of a vertically scrolling region into one tall image. The scroll
+// offset between two frames is found by matching whole pixel rows, so the result is
+// deterministic for a given frame sequence.
+package stitch
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/draw"
+)
+
+// DefaultMinOverlap is the fewest rows two frames must share to be stitched.
+const DefaultMinOverlap = 8
+
+var (
+	// ErrNoOverlap means a frame shares no rows with the previous one, e.g. because the page
+	// was scrolled by more than a frame or scrolled back up.
+	ErrNoOverlap = errors.New("stitch: no overlap with the previous frame")
+	// ErrSizeChanged means a frame's size differs from the first frame's.
+	ErrSizeChanged = errors.New("stitch: frame size changed")
+)
+
+// Stitcher accumulates frames of a region scrolled downwards. Rows that stay put in
+// consecutive frames at the top (sticky headers) and bottom (footers, horizontal scrollbars)
+// are kept once, the header from the first frame and the footer from the last.
+type Stitcher struct {
+	// MinOverlap is the fewest matching rows accepted as overlap (default DefaultMinOverlap).
+	MinOverlap int
+
+	out  *image.RGBA
+	prev []uint64 // row hashes of the last accepted frame
+	size image.Point
+
+	// Frames were added, Unchanged ones equalled the previous frame and Unmatched ones had
+	// no overlap with it.
+	Frames, Unchanged, Unmatched int
+}
+
+// Add appends the rows of frame that scrolled into view since the previous frame and returns
+// how many rows the image grew by. A frame identical to the previous one adds nothing; a frame
+// without enough overlap is rejected with ErrNoOverlap and doesn't become the reference for
+// the next one.
+func (s *Stitcher) Add(frame image.Image) (int, error) {
+	rgba := toRGBA(frame)
+	hashes := rowHashes(rgba)
+	if s.out == nil {
+		s.size = rgba.Rect.Size()
+		s.out = rgba
+		s.prev = hashes
+		s.Frames++
+		return s.size.Y, nil
+	}
+	if rgba.Rect.Size() != s.size {
+		return 0, fmt.Errorf("%w: %v, want %v", ErrSizeChanged, rgba.Rect.Size(), s.size)
+	}
+
+	top, bottom, same := fixedRows(s.prev, hashes)
+	if same {
+		s.Unchanged++
+		return 0, nil
+	}
+	k, ok := overlap(s.prev[top:len(hashes)-bottom], hashes[top:len(hashes)-bottom], s.minOverlap())
+	if !ok {
+		s.Unmatched++
+		return 0, ErrNoOverlap
+	}
+
+	// The previous frame's footer is at the end of out; the new rows and the footer again
+	// follow the overlapping part of the band.
+	before := s.out.Rect.Dy()
+	s.truncate(before - bottom)
+	s.appendRows(rgba, top+k)
+	s.prev = hashes
+	s.Frames++
+	return s.out.Rect.Dy() - before, nil
+}
+
+// Image returns the stitched image so far (nil before the first frame). It shares memory with
+// the Stitcher, so copy it before adding more frames if it must not change.
+func (s *Stitcher) Image() *image.RGBA {
+	return s.out
+}
+
+// Stitch stitches frames in order, skipping those without overlap. It fails if no frame could be
+// used or the frames differ in size.
+func Stitch(frames []image.Image, minOverlap int) (*image.RGBA, error) {
+	s := &Stitcher{MinOverlap: minOverlap}
+	for _, f := range frames {
+		if _, err := s.Add(f); err != nil && !errors.Is(err, ErrNoOverlap) {
+			return nil, err
+		}
+	}
+	if s.out == nil {
+		return nil, errors.New("stitch: no frames")
+	}
+	return s.out, nil
+}
+
+func (s *Stitcher) minOverlap() int {
+	if s.MinOverlap <= 0 {
+		return DefaultMinOverlap
+	}
+	return s.MinOverlap
+}
+
+func (s *Stitcher) truncate(rows int) {
+	s.out.Pix = s.out.Pix[:rows*s.out.Stride]
+	s.out.Rect.Max.Y = s.out.Rect.Min.Y + rows
+}
+
+// appendRows appends the rows of frame from row from to its end.
+func (s *Stitcher) appendRows(frame *image.RGBA, from int) {
+	for y := from; y < frame.Rect.Dy(); y++ {
+		i := y * frame.Stride
+		s.out.Pix = append(s.out.Pix, frame.Pix[i:i+4*frame.Rect.Dx()]...)
+	}
+	s.out.Rect.Max.Y = s.out.Rect.Min.Y + len(s.out.Pix)/s.out.Stride
+}
+
+// fixedRows returns how many rows at the top and bottom are identical at the same position in
+// both frames, leaving at least one row between them, and whether the frames are identical.
+func fixedRows(prev, next []uint64) (top, bottom int, same bool) {
+	n := len(next)
+	for top < n && prev[top] == next[top] {
+		top++
+	}
+	if top == n {
+		return 0, 0, true
+	}
+	for bottom < n-top-1 && prev[n-1-bottom] == next[n-1-bottom] {
+		bottom++
+	}
+	return top, bottom, false
+}
+
+// overlap returns the largest k >= min such that the last k rows of prev equal the first k rows
+// of next, i.e. the smallest downward scroll consistent with both frames. Overlaps whose rows
+// are all the same (blank areas) are ambiguous and rejected.
+func overlap(prev, next []uint64, min int) (int, bool) {
+	m := len(next)
+	for k := m - 1; k >= min; k-- {
+		if !equalRows(prev[m-k:], next[:k]) {
+			continue
+		}
+		for i := 1; i < k; i++ {
+			if next[i] != next[0] {
+				return k, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func equalRows(a, b []uint64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rowHashes returns an FNV-1a hash of each row's pixels.
+func rowHashes(img *image.RGBA) []uint64 {
+	w := 4 * img.Rect.Dx()
+	out := make([]uint64, img.Rect.Dy())
+	for y := range out {
+		h := fnv.New64a()
+		h.Write(img.Pix[y*img.Stride : y*img.Stride+w])
+		out[y] = h.Sum64()
+	}
+	return out
+}
+
+// toRGBA returns a copy of img as a tightly packed *image.RGBA with bounds at (0,0), which
+// Add may keep and grow.
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,166 @@
+package stitch
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// page returns a w×h image whose rows are all different, like a long document.
+func page(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint32(y*7919 + x*104729)
+			img.SetRGBA(x, y, color.RGBA{R: uint8(v), G: uint8(v >> 8), B: uint8(y), A: 255})
+		}
+	}
+	return img
+}
+
+// view returns the h rows of src starting at y, as a frame with bounds at (0,0).
+func view(src *image.RGBA, y, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, src.Rect.Dx(), h))
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(0, y), draw.Src)
+	return dst
+}
+
+// withBars paints a fixed header of top rows and a footer of bottom rows over frame.
+func withBars(frame *image.RGBA, top, bottom int) *image.RGBA {
+	out := image.NewRGBA(frame.Rect)
+	draw.Draw(out, out.Rect, frame, image.Point{}, draw.Src)
+	h := out.Rect.Dy()
+	for y := 0; y < out.Rect.Dy(); y++ {
+		for x := 0; x < out.Rect.Dx(); x++ {
+			switch {
+			case y < top:
+				out.SetRGBA(x, y, color.RGBA{R: 200, G: uint8(y), B: uint8(x), A: 255})
+			case y >= h-bottom:
+				out.SetRGBA(x, y, color.RGBA{G: 200, R: uint8(y - (h - bottom)), B: uint8(x), A: 255})
+			}
+		}
+	}
+	return out
+}
+
+func sameImage(a, b *image.RGBA) bool {
+	if a.Rect.Size() != b.Rect.Size() {
+		return false
+	}
+	for y := 0; y < a.Rect.Dy(); y++ {
+		for x := 0; x < a.Rect.Dx(); x++ {
+			if a.RGBAAt(a.Rect.Min.X+x, a.Rect.Min.Y+y) != b.RGBAAt(b.Rect.Min.X+x, b.Rect.Min.Y+y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestStitcher_ReassemblesScrolledPage(t *testing.T) {
+	t.Parallel()
+
+	src := page(24, 400)
+	s := &Stitcher{}
+	// Uneven scroll steps, plus a repeated frame while the user pauses.
+	for _, y := range []int{0, 30, 85, 85, 140, 141, 230} {
+		if _, err := s.Add(view(src, y, 100)); err != nil {
+			t.Fatalf("Add(view at %d) error: %v", y, err)
+		}
+	}
+	if s.Frames != 6 || s.Unchanged != 1 || s.Unmatched != 0 {
+		t.Fatalf("counts got frames=%d unchanged=%d unmatched=%d want 6,1,0", s.Frames, s.Unchanged, s.Unmatched)
+	}
+	if want := view(src, 0, 330); !sameImage(s.Image(), want) {
+		t.Fatalf("stitched image %v does not match the page's first 330 rows", s.Image().Rect)
+	}
+}
+
+func TestStitcher_KeepsStickyHeaderAndFooterOnce(t *testing.T) {
+	t.Parallel()
+
+	src := page(16, 300)
+	const top, bottom = 10, 6
+	frames := []int{0, 40, 90}
+	s := &Stitcher{}
+	for _, y := range frames {
+		if _, err := s.Add(withBars(view(src, y, 80), top, bottom)); err != nil {
+			t.Fatalf("Add(view at %d) error: %v", y, err)
+		}
+	}
+	// Header, the page content scrolled past (rows top..90+80-bottom), footer.
+	want := withBars(view(src, 0, 90+80), top, bottom)
+	if !sameImage(s.Image(), want) {
+		t.Fatalf("stitched image %v does not match header + content + footer %v", s.Image().Rect, want.Rect)
+	}
+}
+
+func TestStitcher_RejectsFramesWithoutOverlap(t *testing.T) {
+	t.Parallel()
+
+	src := page(8, 400)
+	s := &Stitcher{}
+	mustAdd := func(y int) {
+		t.Helper()
+		if _, err := s.Add(view(src, y, 50)); err != nil {
+			t.Fatalf("Add(view at %d) error: %v", y, err)
+		}
+	}
+	mustAdd(0)
+	// Scrolled too far: the next frame is compared with the last accepted one again.
+	if n, err := s.Add(view(src, 60, 50)); !errors.Is(err, ErrNoOverlap) || n != 0 {
+		t.Fatalf("Add(too far) got=%d,%v want=0,%v", n, err, ErrNoOverlap)
+	}
+	// Scrolled back up.
+	mustAdd(30)
+	if _, err := s.Add(view(src, 10, 50)); !errors.Is(err, ErrNoOverlap) {
+		t.Fatalf("Add(scrolled up) error=%v want=%v", err, ErrNoOverlap)
+	}
+	// Only 5 shared rows, below MinOverlap.
+	if _, err := s.Add(view(src, 75, 50)); !errors.Is(err, ErrNoOverlap) {
+		t.Fatalf("Add(small overlap) error=%v want=%v", err, ErrNoOverlap)
+	}
+	mustAdd(70)
+	if s.Unmatched != 3 || !sameImage(s.Image(), view(src, 0, 120)) {
+		t.Fatalf("unmatched=%d image=%v want 3 and the first 120 rows", s.Unmatched, s.Image().Rect)
+	}
+
+	if _, err := s.Add(view(src, 0, 40)); !errors.Is(err, ErrSizeChanged) {
+		t.Fatalf("Add(smaller frame) error=%v want=%v", err, ErrSizeChanged)
+	}
+}
+
+func TestStitcher_BlankFramesAreAmbiguous(t *testing.T) {
+	t.Parallel()
+
+	blank := image.NewRGBA(image.Rect(0, 0, 10, 30))
+	// Only the last row differs from the first frame, so the overlap would be all blank rows.
+	next := image.NewRGBA(blank.Rect)
+	next.SetRGBA(3, 29, color.RGBA{R: 1, A: 255})
+
+	s := &Stitcher{}
+	if _, err := s.Add(blank); err != nil {
+		t.Fatalf("Add(first) error: %v", err)
+	}
+	if _, err := s.Add(next); !errors.Is(err, ErrNoOverlap) {
+		t.Fatalf("Add(blank) error=%v want=%v", err, ErrNoOverlap)
+	}
+}
+
+func TestStitch(t *testing.T) {
+	t.Parallel()
+
+	src := page(12, 200)
+	got, err := Stitch([]image.Image{view(src, 0, 60), view(src, 150, 60), view(src, 45, 60)}, 10)
+	if err != nil {
+		t.Fatalf("Stitch() error: %v", err)
+	}
+	if !sameImage(got, view(src, 0, 105)) {
+		t.Fatalf("Stitch() image %v does not match the first 105 rows", got.Rect)
+	}
+	if _, err := Stitch(nil, 0); err == nil {
+		t.Fatalf("Stitch(nil) error=nil")
+	}
+}
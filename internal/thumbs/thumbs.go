@@ -0,0 +1,245 @@
+// Package thumbs makes small PNG previews of captures, cached on disk and generated in the
+// background by a pool of workers. It has no UI dependencies.
+package thumbs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/image/draw"
+
+	"go-snip/internal/utils"
+)
+
+// DefaultSize is the longest side of a thumbnail when the Cache doesn't set one.
+const DefaultSize = 256
+
+// ErrNoImage is returned for folders without an image to preview.
+var ErrNoImage = errors.New("thumbs: no image to preview")
+
+// Cache generates thumbnails into Dir. A thumbnail is keyed by the source path, size and
+// modification time, so a changed or replaced file gets a new one and stale ones are never
+// served.
+type Cache struct {
+	Dir string
+	// Size is the longest side of a thumbnail (default DefaultSize). Smaller images keep theirs.
+	Size int
+}
+
+// DefaultDir returns the per-user thumbnail cache directory:
+// <UserCacheDir>/go-snip/thumbs
+func DefaultDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "go-snip", "thumbs"), nil
+}
+
+func (c Cache) size() int {
+	if c.Size <= 0 {
+		return DefaultSize
+	}
+	return c.Size
+}
+
+// Thumbnail returns the path of the thumbnail of src, generating it first if it isn't cached.
+// For a folder (a timelapse session) it previews the first image inside.
+func (c Cache) Thumbnail(src string) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		if src, err = firstImage(src); err != nil {
+			return "", err
+		}
+		if info, err = os.Stat(src); err != nil {
+			return "", err
+		}
+	}
+
+	key := fmt.Sprintf("%s\x00%d\x00%d\x00%d", src, info.Size(), info.ModTime().UnixNano(), c.size())
+	sum := sha256.Sum256([]byte(key))
+	dst := filepath.Join(c.Dir, hex.EncodeToString(sum[:16])+".png")
+	if _, err := os.Stat(dst); err == nil {
+		return dst, nil
+	}
+
+	img, err := decode(src)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.EnsureDir(c.Dir); err != nil {
+		return "", err
+	}
+	// Write to a temp file first so a concurrent reader never sees half a thumbnail.
+	tmp, err := os.CreateTemp(c.Dir, "thumb-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	encodeErr := enc.Encode(tmp, Scale(img, c.size()))
+	if err := errors.Join(encodeErr, tmp.Close()); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return dst, nil
+}
+
+// Scale returns img shrunk to fit a size x size square, keeping its aspect ratio. Images that
+// already fit are returned as they are.
+func Scale(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	if w >= h {
+		w, h = size, max(1, h*size/w)
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// decode reads the image at path (the first frame of animations).
+func decode(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %q: %w", path, err)
+	}
+	return img, nil
+}
+
+// firstImage returns the first file in dir, by name, with an image extension go-snip writes.
+func firstImage(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ext := filepath.Ext(name); ext != "" {
+			if _, err := utils.ParseFormat(ext); err == nil {
+				return filepath.Join(dir, name), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w in %q", ErrNoImage, dir)
+}
+
+// Pool generates thumbnails on a fixed number of worker goroutines. Requests for the same
+// source are coalesced, and Reset drops the queued ones (e.g. when the visible page changes),
+// so only what is on screen gets generated.
+type Pool struct {
+	cache Cache
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []string
+	waiting map[string][]func(path string, err error)
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewPool starts workers goroutines (at least one) generating thumbnails with cache.
+func NewPool(cache Cache, workers int) *Pool {
+	p := &Pool{cache: cache, waiting: make(map[string][]func(string, error))}
+	p.cond = sync.NewCond(&p.mu)
+	for i := 0; i < max(1, workers); i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Request queues src and calls done with the thumbnail path or error on a worker goroutine.
+// done isn't called if the request is dropped by Reset or Close first.
+func (p *Pool) Request(src string, done func(path string, err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	if _, ok := p.waiting[src]; !ok {
+		p.queue = append(p.queue, src)
+	}
+	p.waiting[src] = append(p.waiting[src], done)
+	p.cond.Signal()
+}
+
+// Reset drops every queued request that no worker has started yet.
+func (p *Pool) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, src := range p.queue {
+		delete(p.waiting, src)
+	}
+	p.queue = nil
+}
+
+// Close drops the queued requests and waits for the workers to finish their current ones.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	for _, src := range p.queue {
+		delete(p.waiting, src)
+	}
+	p.queue = nil
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+		src := p.queue[0]
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+
+		path, err := p.cache.Thumbnail(src)
+
+		p.mu.Lock()
+		callbacks := p.waiting[src]
+		delete(p.waiting, src)
+		p.mu.Unlock()
+		for _, done := range callbacks {
+			done(path, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package thumbs
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writePNG(t *testing.T, path string, w, h int, c color.RGBA) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+}
+
+func decodeFile(t *testing.T, path string) image.Image {
+	t.Helper()
+	img, err := decode(path)
+	if err != nil {
+		t.Fatalf("decode %q: %v", path, err)
+	}
+	return img
+}
+
+func TestScale(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		w, h, size int
+		want       image.Point
+	}{
+		{w: 1000, h: 500, size: 100, want: image.Pt(100, 50)},
+		{w: 300, h: 1200, size: 100, want: image.Pt(25, 100)},
+		{w: 5000, h: 2, size: 100, want: image.Pt(100, 1)},
+		{w: 80, h: 60, size: 100, want: image.Pt(80, 60)},
+	}
+	for _, tc := range cases {
+		got := Scale(image.NewRGBA(image.Rect(0, 0, tc.w, tc.h)), tc.size).Bounds().Size()
+		if got != tc.want {
+			t.Fatalf("Scale(%dx%d, %d) got=%v want=%v", tc.w, tc.h, tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestCache_GeneratesOnceAndFollowsChanges(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "shot.png")
+	writePNG(t, src, 400, 200, color.RGBA{R: 200, A: 255})
+	c := Cache{Dir: filepath.Join(dir, "cache"), Size: 64}
+
+	first, err := c.Thumbnail(src)
+	if err != nil {
+		t.Fatalf("Thumbnail() error: %v", err)
+	}
+	img := decodeFile(t, first)
+	if got := img.Bounds().Size(); got != image.Pt(64, 32) {
+		t.Fatalf("thumbnail size got=%v want 64x32", got)
+	}
+	if r, _, _, _ := img.At(10, 10).RGBA(); r>>8 != 200 {
+		t.Fatalf("thumbnail pixel got=%v", img.At(10, 10))
+	}
+
+	// A cache hit doesn't touch the thumbnail.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(first, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	again, err := c.Thumbnail(src)
+	if err != nil || again != first {
+		t.Fatalf("Thumbnail() again got=%q err=%v want=%q", again, err, first)
+	}
+	if info, _ := os.Stat(first); !info.ModTime().Equal(old) {
+		t.Fatalf("cached thumbnail was regenerated")
+	}
+
+	// A replaced source gets a new thumbnail.
+	writePNG(t, src, 100, 300, color.RGBA{B: 200, A: 255})
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(src, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	changed, err := c.Thumbnail(src)
+	if err != nil || changed == first {
+		t.Fatalf("Thumbnail() after change got=%q err=%v", changed, err)
+	}
+	if got := decodeFile(t, changed).Bounds().Size(); got != image.Pt(21, 64) {
+		t.Fatalf("new thumbnail size got=%v want 21x64", got)
+	}
+}
+
+func TestCache_FolderPreviewsFirstImage(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	session := filepath.Join(dir, "timelapse_20250102_030405")
+	writePNG(t, filepath.Join(session, "frame-00002.png"), 10, 10, color.RGBA{G: 255, A: 255})
+	writePNG(t, filepath.Join(session, "frame-00001.png"), 20, 10, color.RGBA{R: 255, A: 255})
+	c := Cache{Dir: filepath.Join(dir, "cache")}
+
+	path, err := c.Thumbnail(session)
+	if err != nil {
+		t.Fatalf("Thumbnail(folder) error: %v", err)
+	}
+	if got := decodeFile(t, path).Bounds().Size(); got != image.Pt(20, 10) {
+		t.Fatalf("folder thumbnail size got=%v want the first frame's 20x10", got)
+	}
+
+	if _, err := c.Thumbnail(t.TempDir()); err == nil {
+		t.Fatalf("Thumbnail(empty folder) succeeded")
+	}
+}
+
+func TestPool_CoalescesAndResets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	var srcs []string
+	for i := 0; i < 8; i++ {
+		src := filepath.Join(dir, "shots", string(rune('a'+i))+".png")
+		writePNG(t, src, 50, 50, color.RGBA{R: uint8(i * 20), A: 255})
+		srcs = append(srcs, src)
+	}
+	p := NewPool(Cache{Dir: filepath.Join(dir, "cache"), Size: 16}, 3)
+
+	var mu sync.Mutex
+	got := make(map[string][]string)
+	var wg sync.WaitGroup
+	for _, src := range append(srcs, srcs...) {
+		wg.Add(1)
+		p.Request(src, func(path string, err error) {
+			defer wg.Done()
+			if err != nil {
+				t.Errorf("thumbnail of %q: %v", src, err)
+			}
+			mu.Lock()
+			got[src] = append(got[src], path)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	for _, src := range srcs {
+		if len(got[src]) != 2 || got[src][0] != got[src][1] {
+			t.Fatalf("callbacks for %q got=%v want the same path twice", src, got[src])
+		}
+	}
+
+	// Reset drops queued requests; Close waits for running ones and ignores new ones.
+	p.Reset()
+	p.Close()
+	p.Request(srcs[0], func(string, error) { t.Errorf("callback after Close") })
+	entries, err := os.ReadDir(filepath.Join(dir, "cache"))
+	if err != nil || len(entries) != len(srcs) {
+		t.Fatalf("cache has %d files (err=%v), want %d", len(entries), err, len(srcs))
+	}
+}
+
+func TestPool_ResetDropsQueued(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.png")
+	writePNG(t, src, 10, 10, color.RGBA{A: 255})
+	p := &Pool{cache: Cache{Dir: dir}, waiting: make(map[string][]func(string, error))}
+	p.cond = sync.NewCond(&p.mu)
+	// No workers yet: the request stays queued until Reset drops it.
+	p.Request(src, func(string, error) { t.Errorf("callback of a dropped request") })
+	p.Reset()
+	if len(p.queue) != 0 || len(p.waiting) != 0 {
+		t.Fatalf("Reset() left queue=%v waiting=%d", p.queue, len(p.waiting))
+	}
+	p.Close()
+}
@@ -0,0 +1,165 @@
+// Package timelapse captures frames at a fixed interval into a numbered image sequence.
+package timelapse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"path/filepath"
+	"time"
+
+	"go-snip/internal/utils"
+)
+
+// DefaultInterval is the time between frames when the config doesn't set one.
+const DefaultInterval = 5 * time.Second
+
+var ErrNoCapture = errors.New("timelapse: no capture function")
+
+// Session is one timelapse run. Frames are saved as frame-00001.<ext>, frame-00002.<ext>, ...
+// in Dir; the numbers count saved frames, so skipped frames leave no gaps.
+type Session struct {
+	// Dir is the session folder, created on the first frame (see SessionDir).
+	Dir string
+	// Capture grabs one frame.
+	Capture func() (image.Image, error)
+	// SkipIdentical drops frames whose pixels equal the previous frame's.
+	SkipIdentical bool
+	// Save selects the frame image format.
+	Save utils.SaveOptions
+}
+
+// Stats reports what a session did.
+type Stats struct {
+	Dir string
+	// Saved frames were written to Dir, Skipped ones were identical to their predecessor,
+	// and Failed ones couldn't be captured.
+	Saved, Skipped, Failed int
+	// LastErr is the most recent capture failure, if any.
+	LastErr error
+}
+
+func (s Stats) String() string {
+	out := fmt.Sprintf("saved %d frames to %s", s.Saved, s.Dir)
+	if s.Skipped > 0 {
+		out += fmt.Sprintf(", skipped %d identical", s.Skipped)
+	}
+	if s.Failed > 0 {
+		out += fmt.Sprintf(", %d failed (last: %v)", s.Failed, s.LastErr)
+	}
+	return out
+}
+
+// SessionDir returns the folder for a session started at t inside outDir, e.g.
+// outDir/timelapse_20250102_030405. If that folder already exists (another session started in
+// the same second), it appends a counter suffix: ..._001, ..._002, ...
+//
+// The exists function is injected for testability.
+func SessionDir(outDir string, t time.Time, exists func(path string) bool) string {
+	base := "timelapse_" + t.Local().Format("20060102_150405")
+	candidate := filepath.Join(outDir, base)
+	if !exists(candidate) {
+		return candidate
+	}
+	for i := 1; ; i++ {
+		candidate = filepath.Join(outDir, fmt.Sprintf("%s_%03d", base, i))
+		if !exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+// FrameName returns the file name of the n-th saved frame (from 1) with extension ext.
+func FrameName(n int, ext string) string {
+	return fmt.Sprintf("frame-%05d%s", n, ext)
+}
+
+// Run captures a frame immediately and then on every tick until ctx is done or ticks is
+// closed. Capture failures are counted and skipped; a frame that can't be saved ends the run
+// with an error. Cancelling ctx is the normal way to stop and returns a nil error.
+func (s *Session) Run(ctx context.Context, ticks <-chan time.Time) (Stats, error) {
+	st := Stats{Dir: s.Dir}
+	if s.Capture == nil {
+		return st, ErrNoCapture
+	}
+	if err := s.Save.Validate(); err != nil {
+		return st, err
+	}
+
+	var prev image.Image
+	frame := func() error {
+		img, err := s.Capture()
+		if err != nil {
+			st.Failed++
+			st.LastErr = err
+			return nil
+		}
+		if s.SkipIdentical && prev != nil && SameImage(prev, img) {
+			st.Skipped++
+			return nil
+		}
+		if st.Saved == 0 {
+			if err := utils.EnsureDir(s.Dir); err != nil {
+				return fmt.Errorf("create session dir %q: %w", s.Dir, err)
+			}
+		}
+		path := filepath.Join(s.Dir, FrameName(st.Saved+1, s.Save.Extension()))
+		if err := utils.SaveImage(img, path, s.Save); err != nil {
+			return err
+		}
+		st.Saved++
+		prev = img
+		return nil
+	}
+
+	if err := frame(); err != nil {
+		return st, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return st, nil
+		case _, ok := <-ticks:
+			if !ok {
+				return st, nil
+			}
+			// A tick may race with cancellation; don't take a frame after the stop.
+			if ctx.Err() != nil {
+				return st, nil
+			}
+			if err := frame(); err != nil {
+				return st, err
+			}
+		}
+	}
+}
+
+// SameImage reports whether a and b have the same bounds and pixels.
+func SameImage(a, b image.Image) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	ra, rb := toRGBA(a), toRGBA(b)
+	r := ra.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		rowA := ra.Pix[ra.PixOffset(r.Min.X, y) : ra.PixOffset(r.Min.X, y)+4*r.Dx()]
+		rowB := rb.Pix[rb.PixOffset(r.Min.X, y) : rb.PixOffset(r.Min.X, y)+4*r.Dx()]
+		if !bytes.Equal(rowA, rowB) {
+			return false
+		}
+	}
+	return true
+}
+
+// toRGBA returns img as *image.RGBA, converting only if needed (captures already are).
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	return out
+}
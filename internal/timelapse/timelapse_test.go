@@ -0,0 +1,208 @@
+package timelapse
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go-snip/internal/utils"
+)
+
+func solid(c uint8) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for i := range img.Pix {
+		img.Pix[i] = c
+	}
+	return img
+}
+
+// frames returns a Capture function serving imgs in order (nil entries fail).
+func frames(imgs ...image.Image) func() (image.Image, error) {
+	i := 0
+	return func() (image.Image, error) {
+		img := imgs[i%len(imgs)]
+		i++
+		if img == nil {
+			return nil, errors.New("capture failed")
+		}
+		return img, nil
+	}
+}
+
+// ticks returns a closed channel holding n ticks, so Run takes 1+n frames and returns.
+func ticks(n int) <-chan time.Time {
+	ch := make(chan time.Time, n)
+	for i := 0; i < n; i++ {
+		ch <- time.Time{}
+	}
+	close(ch)
+	return ch
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir(%q) error: %v", dir, err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRun_SavesNumberedFrames(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "session")
+	s := &Session{Dir: dir, Capture: frames(solid(1), solid(2), solid(3))}
+	st, err := s.Run(context.Background(), ticks(2))
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if st.Saved != 3 || st.Skipped != 0 || st.Failed != 0 || st.Dir != dir {
+		t.Fatalf("Run() stats=%+v", st)
+	}
+	want := []string{"frame-00001.png", "frame-00002.png", "frame-00003.png"}
+	if got := listDir(t, dir); !reflect.DeepEqual(got, want) {
+		t.Fatalf("files got=%v want=%v", got, want)
+	}
+}
+
+func TestRun_SkipIdenticalKeepsNumberingDense(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	s := &Session{
+		Dir:           dir,
+		Capture:       frames(solid(1), solid(1), solid(2), solid(2), solid(1)),
+		SkipIdentical: true,
+		Save:          utils.SaveOptions{Format: utils.FormatJPEG},
+	}
+	st, err := s.Run(context.Background(), ticks(4))
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if st.Saved != 3 || st.Skipped != 2 {
+		t.Fatalf("Run() stats=%+v want saved=3 skipped=2", st)
+	}
+	want := []string{"frame-00001.jpg", "frame-00002.jpg", "frame-00003.jpg"}
+	if got := listDir(t, dir); !reflect.DeepEqual(got, want) {
+		t.Fatalf("files got=%v want=%v", got, want)
+	}
+}
+
+func TestRun_CaptureFailuresAreCounted(t *testing.T) {
+	t.Parallel()
+
+	s := &Session{Dir: t.TempDir(), Capture: frames(solid(1), nil, solid(2))}
+	st, err := s.Run(context.Background(), ticks(2))
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if st.Saved != 2 || st.Failed != 1 || st.LastErr == nil {
+		t.Fatalf("Run() stats=%+v want saved=2 failed=1", st)
+	}
+}
+
+func TestRun_StopsWithContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	captured := 0
+	s := &Session{Dir: t.TempDir(), Capture: func() (image.Image, error) {
+		captured++
+		if captured == 2 {
+			cancel()
+		}
+		return solid(uint8(captured)), nil
+	}}
+	tick := make(chan time.Time, 5)
+	for i := 0; i < 5; i++ {
+		tick <- time.Time{}
+	}
+
+	st, err := s.Run(ctx, tick)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if st.Saved != 2 || captured != 2 {
+		t.Fatalf("Run() saved=%d captured=%d want 2, 2", st.Saved, captured)
+	}
+}
+
+func TestRun_NothingCapturedLeavesNoFolder(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "session")
+	s := &Session{Dir: dir, Capture: frames(nil)}
+	if _, err := s.Run(context.Background(), ticks(1)); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("session dir exists after no frames: %v", err)
+	}
+
+	if _, err := (&Session{Dir: dir}).Run(context.Background(), ticks(0)); !errors.Is(err, ErrNoCapture) {
+		t.Fatalf("Run() without Capture error=%v want=%v", err, ErrNoCapture)
+	}
+}
+
+func TestSameImage(t *testing.T) {
+	t.Parallel()
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+	for i := range nrgba.Pix {
+		nrgba.Pix[i] = 255
+	}
+	white := solid(255)
+
+	if !SameImage(solid(7), solid(7)) {
+		t.Fatalf("SameImage(equal) = false")
+	}
+	if SameImage(solid(7), solid(8)) {
+		t.Fatalf("SameImage(different) = true")
+	}
+	if !SameImage(white, nrgba) {
+		t.Fatalf("SameImage(RGBA, NRGBA of the same colour) = false")
+	}
+	other := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if SameImage(white, other) {
+		t.Fatalf("SameImage(different bounds) = true")
+	}
+	one := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	copy(one.Pix, white.(*image.RGBA).Pix)
+	one.SetRGBA(3, 2, color.RGBA{A: 255})
+	if SameImage(white, one) {
+		t.Fatalf("SameImage(one pixel differs) = true")
+	}
+}
+
+func TestSessionDirAndFrameName(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+	got := SessionDir("out", at, func(string) bool { return false })
+	if want := filepath.Join("out", "timelapse_20250102_030405"); got != want {
+		t.Fatalf("SessionDir() got=%q want=%q", got, want)
+	}
+	taken := map[string]bool{
+		filepath.Join("out", "timelapse_20250102_030405"):     true,
+		filepath.Join("out", "timelapse_20250102_030405_001"): true,
+	}
+	got = SessionDir("out", at, func(p string) bool { return taken[p] })
+	if want := filepath.Join("out", "timelapse_20250102_030405_002"); got != want {
+		t.Fatalf("SessionDir(taken) got=%q want=%q", got, want)
+	}
+	if got := FrameName(42, ".png"); got != "frame-00042.png" {
+		t.Fatalf("FrameName() got=%q", got)
+	}
+}
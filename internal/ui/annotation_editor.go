@@ -0,0 +1,174 @@
+//go:build fyne
+// +build fyne
+
+package ui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+
+	"go-snip/internal/annotate"
+)
+
+// annotationColors are the colours offered in the prompt, in menu order.
+var annotationColors = []struct {
+	name  string
+	color color.NRGBA
+}{
+	{"Red", color.NRGBA{R: 230, G: 30, B: 30, A: 255}},
+	{"Yellow", color.NRGBA{R: 255, G: 210, B: 0, A: 255}},
+	{"Green", color.NRGBA{R: 30, G: 170, B: 60, A: 255}},
+	{"Blue", color.NRGBA{R: 20, G: 110, B: 240, A: 255}},
+	{"Black", color.NRGBA{A: 255}},
+	{"White", color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+}
+
+// annotationEditor shows a capture and lets the user draw annotations on it with the
+// selected tool. The preview is rendered by package annotate, so it matches the saved result.
+type annotationEditor struct {
+	widget.BaseWidget
+
+	base  image.Image
+	stack annotate.Stack
+
+	tool  string
+	color color.NRGBA
+	// label returns the text placed by the text tool.
+	label func() string
+	// onChange is called after every change to the annotation stack.
+	onChange func()
+
+	current   *annotate.Annotation
+	committed *image.RGBA // base with every stacked annotation drawn
+	preview   *canvas.Image
+}
+
+func newAnnotationEditor(base image.Image, label func() string) *annotationEditor {
+	e := &annotationEditor{
+		base:  base,
+		tool:  annotate.ToolRect,
+		color: annotationColors[0].color,
+		label: label,
+	}
+	e.committed = annotate.Flatten(base, nil)
+	e.preview = canvas.NewImageFromImage(e.committed)
+	e.preview.FillMode = canvas.ImageFillContain
+	e.preview.SetMinSize(fyne.NewSize(520, 280))
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// Result returns the capture with the annotations flattened onto it, or the original image
+// if nothing was drawn.
+func (e *annotationEditor) Result() image.Image {
+	if e.stack.Len() == 0 {
+		return e.base
+	}
+	return annotate.Flatten(e.base, e.stack.Items())
+}
+
+func (e *annotationEditor) CanUndo() bool { return e.stack.CanUndo() }
+
+func (e *annotationEditor) CanRedo() bool { return e.stack.CanRedo() }
+
+func (e *annotationEditor) Undo() {
+	if e.stack.Undo() {
+		e.rebuild()
+	}
+}
+
+func (e *annotationEditor) Redo() {
+	if e.stack.Redo() {
+		e.rebuild()
+	}
+}
+
+func (e *annotationEditor) toImage(p fyne.Position) image.Point {
+	sz := e.Size()
+	return areaToImagePoint(p.X, p.Y, sz.Width, sz.Height, e.base.Bounds())
+}
+
+// Tapped places the click-to-place annotations (text labels and step markers).
+func (e *annotationEditor) Tapped(ev *fyne.PointEvent) {
+	if ev == nil {
+		return
+	}
+	a := annotate.Annotation{Tool: e.tool, Points: []image.Point{e.toImage(ev.Position)}, Color: e.color}
+	switch e.tool {
+	case annotate.ToolText:
+		a.Text = e.label()
+		if a.Text == "" {
+			return
+		}
+	case annotate.ToolStep:
+		a.Step = e.stack.NextStep()
+	default:
+		return
+	}
+	e.stack.Push(a)
+	e.rebuild()
+}
+
+func (e *annotationEditor) Dragged(ev *fyne.DragEvent) {
+	if ev == nil {
+		return
+	}
+	switch e.tool {
+	case annotate.ToolText, annotate.ToolStep:
+		return
+	}
+
+	p := e.toImage(ev.Position)
+	if e.current == nil {
+		start := e.toImage(ev.Position.Subtract(ev.Dragged))
+		e.current = &annotate.Annotation{Tool: e.tool, Points: []image.Point{start}, Color: e.color}
+	}
+	switch e.tool {
+	case annotate.ToolPen, annotate.ToolHighlighter:
+		if last := e.current.Points[len(e.current.Points)-1]; last != p {
+			e.current.Points = append(e.current.Points, p)
+		}
+	default:
+		e.current.Points = []image.Point{e.current.Points[0], p}
+	}
+
+	// Draw the shape in progress on a copy so the committed image stays reusable.
+	// Redaction boxes are only outlined until the drag ends; blurring on every move is too slow.
+	frame := image.NewRGBA(e.committed.Bounds())
+	draw.Draw(frame, frame.Bounds(), e.committed, frame.Bounds().Min, draw.Src)
+	inProgress := *e.current
+	if annotate.IsRedaction(inProgress.Tool) {
+		inProgress = annotate.Annotation{Tool: annotate.ToolRect, Points: inProgress.Points, Color: inProgress.Color, Width: 1}
+	}
+	annotate.Draw(frame, inProgress)
+	e.preview.Image = frame
+	e.preview.Refresh()
+}
+
+func (e *annotationEditor) DragEnd() {
+	if e.current == nil {
+		return
+	}
+	e.stack.Push(*e.current)
+	e.current = nil
+	e.rebuild()
+}
+
+// rebuild re-renders the committed image after the stack changed.
+func (e *annotationEditor) rebuild() {
+	e.committed = annotate.Flatten(e.base, e.stack.Items())
+	e.preview.Image = e.committed
+	e.preview.Refresh()
+	if e.onChange != nil {
+		e.onChange()
+	}
+}
+
+func (e *annotationEditor) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(e.preview)
+}
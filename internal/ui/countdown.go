@@ -0,0 +1,84 @@
+//go:build fyne
+// +build fyne
+
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// countdownSettle is how long Countdown waits after closing its window, so the window is gone
+// from the screen before the caller captures it.
+const countdownSettle = 150 * time.Millisecond
+
+// Countdown shows the seconds left of d and returns when d has elapsed and the indicator is
+// closed. It returns ctx.Err() as soon as ctx is cancelled.
+//
+// The indicator is an undecorated splash window (desktop.Driver.CreateSplashWindow) rather
+// than a normal window, so it doesn't come up as a focused application window and dismiss the
+// menus and tooltips the delay is for. If the driver can't create one, Countdown fails without showing anything
+// and the caller waits with only a log line.
+func Countdown(ctx context.Context, d time.Duration) error {
+	a := fyne.CurrentApp()
+	if a == nil {
+		return ErrCountdownUnavailable
+	}
+	if a.Driver() == nil {
+		return errors.New("ui: fyne driver unavailable (app not running?)")
+	}
+	drv, ok := a.Driver().(desktop.Driver)
+	if !ok {
+		return errors.New("ui: fyne driver can't create splash windows")
+	}
+
+	deadline := time.Now().Add(d)
+	secondsLeft := func() string {
+		return fmt.Sprintf("%d", int(math.Ceil(time.Until(deadline).Seconds())))
+	}
+
+	var w fyne.Window
+	var text *canvas.Text
+	fyne.DoAndWait(func() {
+		w = drv.CreateSplashWindow()
+		text = canvas.NewText(secondsLeft(), color.NRGBA{R: 230, G: 30, B: 30, A: 255})
+		text.TextSize = 48
+		text.TextStyle = fyne.TextStyle{Bold: true}
+		text.Alignment = fyne.TextAlignCenter
+		w.SetContent(container.NewPadded(text))
+		w.Resize(fyne.NewSize(160, 90))
+		w.Show()
+	})
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	closeWindow := func() { fyne.DoAndWait(w.Close) }
+	for {
+		select {
+		case <-ctx.Done():
+			closeWindow()
+			return ctx.Err()
+		case <-timer.C:
+			closeWindow()
+			time.Sleep(countdownSettle)
+			return nil
+		case <-ticker.C:
+			fyne.Do(func() {
+				text.Text = secondsLeft()
+				text.Refresh()
+			})
+		}
+	}
+}
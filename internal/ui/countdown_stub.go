@@ -0,0 +1,13 @@
+//go:build !fyne
+
+package ui
+
+import (
+	"context"
+	"time"
+)
+
+// Countdown is unavailable unless built with the `fyne` build tag.
+func Countdown(ctx context.Context, d time.Duration) error {
+	return ErrCountdownUnavailable
+}
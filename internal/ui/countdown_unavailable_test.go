@@ -0,0 +1,18 @@
+//go:build !fyne
+
+package ui
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCountdown_UnavailableWithoutFyne(t *testing.T) {
+	t.Parallel()
+
+	if err := Countdown(context.Background(), time.Second); !errors.Is(err, ErrCountdownUnavailable) {
+		t.Fatalf("expected ErrCountdownUnavailable, got=%v", err)
+	}
+}
@@ -11,3 +11,13 @@ var ErrSettingsUnavailable = errors.New("ui: settings UI unavailable (build with
 //
 // In this repo, the prompt UI is enabled by building with the `fyne` build tag.
 var ErrPromptUnavailable = errors.New("ui: prompt UI unavailable (build with -tags=fyne)")
+
+// ErrCountdownUnavailable indicates the countdown indicator is not available in the current build.
+//
+// In this repo, the countdown indicator is enabled by building with the `fyne` build tag.
+var ErrCountdownUnavailable = errors.New("ui: countdown indicator unavailable (build with -tags=fyne)")
+
+// ErrHistoryUnavailable indicates the history browser is not available in the current build.
+//
+// In this repo, the history browser is enabled by building with the `fyne` build tag.
+var ErrHistoryUnavailable = errors.New("ui: history browser unavailable (build with -tags=fyne)")
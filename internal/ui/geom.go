@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"image"
+	"math"
+)
+
+// containPlacement returns how an image with bounds img is drawn inside an areaW x areaH area
+// by canvas.ImageFillContain: scaled by scale (area units per pixel) and centred at offset.
+func containPlacement(areaW, areaH float32, img image.Rectangle) (scale, offX, offY float32) {
+	if areaW <= 0 || areaH <= 0 || img.Dx() <= 0 || img.Dy() <= 0 {
+		return 0, 0, 0
+	}
+	scale = float32(math.Min(float64(areaW)/float64(img.Dx()), float64(areaH)/float64(img.Dy())))
+	offX = (areaW - float32(img.Dx())*scale) / 2
+	offY = (areaH - float32(img.Dy())*scale) / 2
+	return scale, offX, offY
+}
+
+// areaToImagePoint maps a position in an area showing img with ImageFillContain to the image
+// pixel under it. Positions in the letterbox map outside img.Bounds(); callers clip when drawing.
+func areaToImagePoint(x, y, areaW, areaH float32, img image.Rectangle) image.Point {
+	scale, offX, offY := containPlacement(areaW, areaH, img)
+	if scale <= 0 {
+		return img.Min
+	}
+	px := math.Floor(float64((x - offX) / scale))
+	py := math.Floor(float64((y - offY) / scale))
+	return image.Pt(int(px)+img.Min.X, int(py)+img.Min.Y)
+}
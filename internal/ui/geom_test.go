@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"image"
+	"testing"
+)
+
+func TestAreaToImagePoint_Letterboxed(t *testing.T) {
+	t.Parallel()
+
+	// A 200x100 image in a 400x400 area is scaled 2x and centred vertically (100 units of bars).
+	img := image.Rect(0, 0, 200, 100)
+	cases := []struct {
+		x, y float32
+		want image.Point
+	}{
+		{x: 0, y: 100, want: image.Pt(0, 0)},
+		{x: 399, y: 299, want: image.Pt(199, 99)},
+		{x: 201, y: 201, want: image.Pt(100, 50)},
+		{x: 10, y: 50, want: image.Pt(5, -25)},
+	}
+	for _, tc := range cases {
+		if got := areaToImagePoint(tc.x, tc.y, 400, 400, img); got != tc.want {
+			t.Fatalf("areaToImagePoint(%v,%v)=%v want=%v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestAreaToImagePoint_OffsetBoundsAndEmptyArea(t *testing.T) {
+	t.Parallel()
+
+	img := image.Rect(10, 20, 110, 70)
+	if got := areaToImagePoint(50, 25, 100, 50, img); got != image.Pt(60, 45) {
+		t.Fatalf("got=%v want=(60,45)", got)
+	}
+	if got := areaToImagePoint(5, 5, 0, 0, img); got != img.Min {
+		t.Fatalf("empty area got=%v want=%v", got, img.Min)
+	}
+}
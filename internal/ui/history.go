@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"go-snip/internal/history"
+)
+
+// historyPageSize is the number of captures the history browser shows per page.
+const historyPageSize = 24
+
+// HistoryActions are the operations the history browser offers on an entry. They are
+// implemented by the caller so they can be tested without Fyne; a nil action hides its button.
+type HistoryActions struct {
+	// Thumbnail asks for the thumbnail of path; done is called, possibly on another goroutine,
+	// with the thumbnail file or an error.
+	Thumbnail func(path string, done func(thumb string, err error))
+	// ResetThumbnails drops the thumbnail requests that haven't started yet. It is called
+	// whenever the visible page changes.
+	ResetThumbnails func()
+
+	CopyImage func(e history.Entry) error
+	CopyPath  func(e history.Entry) error
+	// Rename gives e a new name and returns the entry as it is after the rename.
+	Rename     func(e history.Entry, name string) (history.Entry, error)
+	Delete     func(e history.Entry) error
+	OpenFolder func(e history.Entry) error
+}
+
+// historyPage returns the entries on page (0-based, clamped to the valid range) of those
+// matching query, along with the clamped page and the number of pages (at least one).
+func historyPage(entries []history.Entry, query string, page int) (items []history.Entry, clamped, pages int) {
+	filter := history.Filter{Query: query}
+	var matched []history.Entry
+	for _, e := range entries {
+		if filter.Match(e) {
+			matched = append(matched, e)
+		}
+	}
+
+	pages = (len(matched) + historyPageSize - 1) / historyPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	clamped = min(max(page, 0), pages-1)
+	start := clamped * historyPageSize
+	end := min(start+historyPageSize, len(matched))
+	return matched[start:end], clamped, pages
+}
+
+// historyCaption is the text under an entry's thumbnail: its name (or file name), capture time
+// and size.
+func historyCaption(e history.Entry) string {
+	name := e.Name
+	if name == "" {
+		name = filepath.Base(e.Path)
+	}
+	caption := name + "\n" + e.Time.Format("2006-01-02 15:04")
+	if e.Width > 0 && e.Height > 0 {
+		caption += fmt.Sprintf("  %dx%d", e.Width, e.Height)
+	}
+	return caption
+}
@@ -0,0 +1,239 @@
+//go:build fyne
+// +build fyne
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"go-snip/internal/history"
+)
+
+// historyBrowser is the state of one history window. It is only touched on the UI goroutine.
+type historyBrowser struct {
+	w       fyne.Window
+	actions HistoryActions
+	entries []history.Entry
+	query   string
+	page    int
+	// generation is bumped on every render, so thumbnails that arrive for a page that is no
+	// longer shown are dropped.
+	generation int
+
+	grid      *fyne.Container
+	scroll    *container.Scroll
+	pageLabel *widget.Label
+	prev      *widget.Button
+	next      *widget.Button
+}
+
+// ShowHistory opens the history browser on entries (newest first) and blocks until the window
+// is closed. Thumbnails are requested lazily for the visible page only.
+func ShowHistory(entries []history.Entry, actions HistoryActions) error {
+	a := fyne.CurrentApp()
+	if a == nil {
+		return ErrHistoryUnavailable
+	}
+	if a.Driver() == nil {
+		return errors.New("ui: fyne driver unavailable (app not running?)")
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	fyne.DoAndWait(func() {
+		b := &historyBrowser{
+			w:       a.NewWindow("go-snip: history"),
+			actions: actions,
+			entries: append([]history.Entry(nil), entries...),
+		}
+		b.w.Resize(fyne.NewSize(900, 680))
+
+		filter := widget.NewEntry()
+		filter.SetPlaceHolder("Filter by name, path, mode or tag")
+		filter.OnChanged = func(text string) {
+			b.query = text
+			b.page = 0
+			b.render()
+		}
+
+		b.grid = container.NewGridWrap(fyne.NewSize(200, 240))
+		b.scroll = container.NewVScroll(b.grid)
+		b.pageLabel = widget.NewLabel("")
+		b.prev = widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+			b.page--
+			b.render()
+		})
+		b.next = widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+			b.page++
+			b.render()
+		})
+
+		b.w.SetOnClosed(func() {
+			if b.actions.ResetThumbnails != nil {
+				b.actions.ResetThumbnails()
+			}
+			once.Do(func() { close(done) })
+		})
+
+		pager := container.NewHBox(layout.NewSpacer(), b.prev, b.pageLabel, b.next, layout.NewSpacer())
+		b.w.SetContent(container.NewBorder(container.NewPadded(filter), pager, nil, nil, b.scroll))
+		b.render()
+		b.w.Show()
+	})
+
+	<-done
+	return nil
+}
+
+// render rebuilds the grid for the current filter and page.
+func (b *historyBrowser) render() {
+	items, page, pages := historyPage(b.entries, b.query, b.page)
+	b.page = page
+	b.generation++
+	if b.actions.ResetThumbnails != nil {
+		b.actions.ResetThumbnails()
+	}
+
+	cards := make([]fyne.CanvasObject, 0, len(items))
+	for _, e := range items {
+		cards = append(cards, b.card(e))
+	}
+	b.grid.Objects = cards
+	b.grid.Refresh()
+	b.scroll.ScrollToTop()
+
+	b.pageLabel.SetText(fmt.Sprintf("Page %d of %d", page+1, pages))
+	if page > 0 {
+		b.prev.Enable()
+	} else {
+		b.prev.Disable()
+	}
+	if page < pages-1 {
+		b.next.Enable()
+	} else {
+		b.next.Disable()
+	}
+}
+
+// card is the thumbnail, caption and action buttons of one entry.
+func (b *historyBrowser) card(e history.Entry) fyne.CanvasObject {
+	thumb := canvas.NewImageFromResource(theme.FileImageIcon())
+	thumb.FillMode = canvas.ImageFillContain
+	thumb.SetMinSize(fyne.NewSize(190, 140))
+	if b.actions.Thumbnail != nil {
+		generation := b.generation
+		b.actions.Thumbnail(e.Path, func(path string, err error) {
+			if err != nil {
+				return
+			}
+			fyne.Do(func() {
+				if generation != b.generation {
+					return
+				}
+				thumb.Resource = nil
+				thumb.File = path
+				thumb.Refresh()
+			})
+		})
+	}
+
+	caption := widget.NewLabel(historyCaption(e))
+	caption.Truncation = fyne.TextTruncateEllipsis
+
+	buttons := container.NewHBox(layout.NewSpacer())
+	add := func(icon fyne.Resource, action func()) {
+		buttons.Add(widget.NewButtonWithIcon("", icon, action))
+	}
+	if f := b.actions.CopyImage; f != nil {
+		add(theme.ContentCopyIcon(), func() { b.run(func() error { return f(e) }, nil) })
+	}
+	if f := b.actions.CopyPath; f != nil {
+		add(theme.ContentPasteIcon(), func() { b.run(func() error { return f(e) }, nil) })
+	}
+	if b.actions.Rename != nil {
+		add(theme.DocumentCreateIcon(), func() { b.rename(e) })
+	}
+	if b.actions.Delete != nil {
+		add(theme.DeleteIcon(), func() { b.delete(e) })
+	}
+	if f := b.actions.OpenFolder; f != nil {
+		add(theme.FolderOpenIcon(), func() { b.run(func() error { return f(e) }, nil) })
+	}
+	buttons.Add(layout.NewSpacer())
+
+	return container.NewBorder(nil, container.NewVBox(caption, buttons), nil, nil, thumb)
+}
+
+// run calls action off the UI goroutine (actions touch files and the clipboard), then shows
+// its error or calls after back on the UI goroutine.
+func (b *historyBrowser) run(action func() error, after func()) {
+	go func() {
+		err := action()
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, b.w)
+				return
+			}
+			if after != nil {
+				after()
+			}
+		})
+	}()
+}
+
+func (b *historyBrowser) rename(e history.Entry) {
+	name := widget.NewEntry()
+	name.SetText(e.Name)
+	name.SetPlaceHolder("Name")
+	dialog.ShowForm("Rename capture", "Rename", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", name)}, func(ok bool) {
+		if !ok {
+			return
+		}
+		var renamed history.Entry
+		b.run(func() (err error) {
+			renamed, err = b.actions.Rename(e, name.Text)
+			return err
+		}, func() {
+			b.replace(e.Path, &renamed)
+		})
+	}, b.w)
+}
+
+func (b *historyBrowser) delete(e history.Entry) {
+	msg := fmt.Sprintf("Delete %s from disk?", filepath.Base(e.Path))
+	dialog.ShowConfirm("Delete capture", msg, func(ok bool) {
+		if !ok {
+			return
+		}
+		b.run(func() error { return b.actions.Delete(e) }, func() {
+			b.replace(e.Path, nil)
+		})
+	}, b.w)
+}
+
+// replace swaps the entry of oldPath for e (or removes it if e is nil) and re-renders.
+func (b *historyBrowser) replace(oldPath string, e *history.Entry) {
+	out := b.entries[:0]
+	for _, old := range b.entries {
+		switch {
+		case old.Path != oldPath:
+			out = append(out, old)
+		case e != nil:
+			out = append(out, *e)
+		}
+	}
+	b.entries = out
+	b.render()
+}
@@ -0,0 +1,10 @@
+//go:build !fyne
+
+package ui
+
+import "go-snip/internal/history"
+
+// ShowHistory is unavailable unless built with the `fyne` build tag.
+func ShowHistory(entries []history.Entry, actions HistoryActions) error {
+	return ErrHistoryUnavailable
+}
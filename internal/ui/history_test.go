@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go-snip/internal/history"
+)
+
+func TestHistoryPage_FiltersAndClamps(t *testing.T) {
+	t.Parallel()
+
+	var entries []history.Entry
+	for i := 0; i < 2*historyPageSize+5; i++ {
+		mode := "area"
+		if i%2 == 1 {
+			mode = "full"
+		}
+		entries = append(entries, history.Entry{Path: fmt.Sprintf("/shots/%03d.png", i), Mode: mode})
+	}
+
+	items, page, pages := historyPage(entries, "", 2)
+	if page != 2 || pages != 3 || len(items) != 5 || items[0].Path != "/shots/048.png" {
+		t.Fatalf("historyPage(page 2) got=%d items, page=%d pages=%d want=5 items, page=2 pages=3", len(items), page, pages)
+	}
+	items, page, pages = historyPage(entries, "full", 7)
+	if page != 1 || pages != 2 || len(items) != 2 || items[0].Path != "/shots/049.png" {
+		t.Fatalf("historyPage(full, page 7) got=%d items, page=%d pages=%d want=2 items, page=1 pages=2", len(items), page, pages)
+	}
+	items, page, pages = historyPage(entries, "nothing", -1)
+	if page != 0 || pages != 1 || len(items) != 0 {
+		t.Fatalf("historyPage(no match) got=%d items, page=%d pages=%d want=0 items, page=0 pages=1", len(items), page, pages)
+	}
+}
+
+func TestHistoryCaption(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		e    history.Entry
+		want string
+	}{
+		{e: history.Entry{Path: "/shots/a.png", Name: "bug", Time: at, Width: 640, Height: 480}, want: "bug\n2025-01-02 03:04  640x480"},
+		{e: history.Entry{Path: "/shots/timelapse_1", Time: at}, want: "timelapse_1\n2025-01-02 03:04"},
+	}
+	for _, tc := range cases {
+		if got := historyCaption(tc.e); got != tc.want {
+			t.Fatalf("historyCaption(%+v) got=%q want=%q", tc.e, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !fyne
+
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"go-snip/internal/history"
+)
+
+func TestShowHistory_UnavailableWithoutFyne(t *testing.T) {
+	t.Parallel()
+
+	err := ShowHistory([]history.Entry{{Path: "C:\\test\\a.png"}}, HistoryActions{})
+	if !errors.Is(err, ErrHistoryUnavailable) {
+		t.Fatalf("expected ErrHistoryUnavailable, got=%v", err)
+	}
+}
@@ -10,27 +10,31 @@ import (
 	"sync"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+
+	"go-snip/internal/annotate"
 )
 
 type promptResult struct {
+	img  image.Image
 	name string
 	save bool
 	err  error
 }
 
-// PromptSave shows a post-capture dialog with a small preview and a name field.
-// If the user clicks Save, save=true. If the user clicks Delete or closes the window, save=false.
-func PromptSave(img image.Image) (name string, save bool, err error) {
+// PromptSave shows a post-capture dialog with an annotation editor and a name field.
+// If the user clicks Save, save=true and edited is img with the annotations flattened onto it
+// (img itself if nothing was drawn). If the user clicks Delete or closes the window, save=false.
+func PromptSave(img image.Image) (edited image.Image, name string, save bool, err error) {
 	a := fyne.CurrentApp()
 	if a == nil {
-		return "", false, ErrPromptUnavailable
+		return nil, "", false, ErrPromptUnavailable
 	}
 	if a.Driver() == nil {
-		return "", false, errors.New("ui: fyne driver unavailable (app not running?)")
+		return nil, "", false, errors.New("ui: fyne driver unavailable (app not running?)")
 	}
 
 	done := make(chan promptResult, 1)
@@ -46,17 +50,68 @@ func PromptSave(img image.Image) (name string, save bool, err error) {
 
 	fyne.DoAndWait(func() {
 		w := a.NewWindow("go-snip: screenshot")
-		w.Resize(fyne.NewSize(560, 420))
+		w.Resize(fyne.NewSize(860, 640))
+
+		labelEntry := widget.NewEntry()
+		labelEntry.SetPlaceHolder("Label text (text tool)")
+
+		editor := newAnnotationEditor(img, func() string { return strings.TrimSpace(labelEntry.Text) })
 
-		preview := canvas.NewImageFromImage(img)
-		preview.FillMode = canvas.ImageFillContain
-		preview.SetMinSize(fyne.NewSize(520, 280))
+		tools := widget.NewRadioGroup(annotate.Tools(), func(tool string) {
+			if tool != "" {
+				editor.tool = tool
+			}
+		})
+		tools.Horizontal = true
+		tools.Required = true
+		tools.SetSelected(annotate.ToolRect)
+
+		colorNames := make([]string, len(annotationColors))
+		for i, c := range annotationColors {
+			colorNames[i] = c.name
+		}
+		colors := widget.NewSelect(colorNames, func(name string) {
+			for _, c := range annotationColors {
+				if c.name == name {
+					editor.color = c.color
+				}
+			}
+		})
+		colors.SetSelected(annotationColors[0].name)
+
+		undoBtn := widget.NewButton("Undo", editor.Undo)
+		redoBtn := widget.NewButton("Redo", editor.Redo)
+		updateHistory := func() {
+			if editor.CanUndo() {
+				undoBtn.Enable()
+			} else {
+				undoBtn.Disable()
+			}
+			if editor.CanRedo() {
+				redoBtn.Enable()
+			} else {
+				redoBtn.Disable()
+			}
+		}
+		editor.onChange = updateHistory
+		updateHistory()
+
+		// Ctrl+Z undoes; Ctrl+Y and Ctrl+Shift+Z redo (Cmd on macOS).
+		w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+			editor.Undo()
+		})
+		w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+			editor.Redo()
+		})
+		w.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift}, func(fyne.Shortcut) {
+			editor.Redo()
+		})
 
 		nameEntry := widget.NewEntry()
 		nameEntry.SetPlaceHolder("Optional name (appended to filename)")
 
 		doSave := func() {
-			send(promptResult{name: strings.TrimSpace(nameEntry.Text), save: true})
+			send(promptResult{img: editor.Result(), name: strings.TrimSpace(nameEntry.Text), save: true})
 			w.Close()
 		}
 
@@ -78,18 +133,21 @@ func PromptSave(img image.Image) (name string, save bool, err error) {
 			send(promptResult{save: false})
 		})
 
-		content := container.NewVBox(
-			widget.NewLabel("Preview"),
-			container.NewPadded(preview),
+		toolbar := container.NewVBox(
+			tools,
+			container.NewBorder(nil, nil, colors, container.NewHBox(undoBtn, redoBtn), labelEntry),
+		)
+		footer := container.NewVBox(
 			widget.NewSeparator(),
 			widget.NewLabel("Name (optional)"),
 			nameEntry,
 			container.NewHBox(layout.NewSpacer(), deleteBtn, saveBtn),
 		)
+		content := container.NewBorder(toolbar, footer, nil, nil, container.NewPadded(editor))
 		w.SetContent(container.NewPadded(content))
 		w.Show()
 	})
 
 	res := <-done
-	return res.name, res.save, res.err
+	return res.img, res.name, res.save, res.err
 }
@@ -5,6 +5,6 @@ package ui
 import "image"
 
 // PromptSave is unavailable unless built with the `fyne` build tag.
-func PromptSave(img image.Image) (name string, save bool, err error) {
-	return "", false, ErrPromptUnavailable
+func PromptSave(img image.Image) (edited image.Image, name string, save bool, err error) {
+	return nil, "", false, ErrPromptUnavailable
 }
@@ -12,7 +12,7 @@ func TestPromptSave_UnavailableWithoutFyne(t *testing.T) {
 	t.Parallel()
 
 	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
-	_, _, err := PromptSave(img)
+	_, _, _, err := PromptSave(img)
 	if !errors.Is(err, ErrPromptUnavailable) {
 		t.Fatalf("expected ErrPromptUnavailable, got=%v", err)
 	}
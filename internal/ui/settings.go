@@ -5,6 +5,7 @@ package ui
 
 import (
 	"errors"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -14,7 +15,12 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 
+	"go-snip/internal/capture"
+	"go-snip/internal/clipboard"
 	"go-snip/internal/config"
+	"go-snip/internal/hotkeys"
+	"go-snip/internal/overlay"
+	"go-snip/internal/utils"
 )
 
 type settingsResult struct {
@@ -23,9 +29,10 @@ type settingsResult struct {
 	err   error
 }
 
-// ShowSettings opens a settings window.
-// Closing the window returns saved=false unless the user clicks Save.
-func ShowSettings(initialOutDir string, initialPostCapturePrompt bool) (newCfg config.Config, saved bool, err error) {
+// ShowSettings opens a settings window pre-filled from initial.
+// Closing the window returns saved=false unless the user clicks Save; on Save, newCfg is
+// initial with the edited fields replaced.
+func ShowSettings(initial config.Config) (newCfg config.Config, saved bool, err error) {
 	a := fyne.CurrentApp()
 	if a == nil {
 		return config.Config{}, false, ErrSettingsUnavailable
@@ -49,14 +56,95 @@ func ShowSettings(initialOutDir string, initialPostCapturePrompt bool) (newCfg c
 	// keeps us compatible with Fyne's thread-safety checks (and avoids window lifecycle hangs).
 	fyne.DoAndWait(func() {
 		w := a.NewWindow("go-snip: settings")
-		w.Resize(fyne.NewSize(560, 240))
+		w.Resize(fyne.NewSize(560, 640))
 
 		outEntry := widget.NewEntry()
-		outEntry.SetText(initialOutDir)
+		outEntry.SetText(initial.OutputDir)
 		outEntry.SetPlaceHolder("Output directory (e.g. C:\\screenshots)")
 
+		filename := widget.NewEntry()
+		filename.SetPlaceHolder("Timestamp (e.g. {date}/{mode}_{w}x{h}{counter})")
+		filename.SetText(initial.FilenameTemplate)
+		dateFolders := widget.NewCheck("Save into YYYY/MM/DD subfolders", func(bool) {})
+		dateFolders.SetChecked(initial.DateFolders)
+		embedMetadata := widget.NewCheck("Embed capture details (time, displays, hostname, name) in PNG files", func(bool) {})
+		embedMetadata.SetChecked(!initial.StripMetadata)
+
 		postPrompt := widget.NewCheck("Ask for a name after capture (preview + Save/Delete)", func(bool) {})
-		postPrompt.SetChecked(initialPostCapturePrompt)
+		postPrompt.SetChecked(initial.PostCapturePrompt)
+
+		displayMode := widget.NewSelect([]string{
+			capture.DisplayPrimary,
+			capture.DisplayIndex,
+			capture.DisplayCursor,
+			capture.DisplayAll,
+		}, func(string) {})
+		displayMode.SetSelected(capture.DisplayPrimary)
+		if initial.Display != "" {
+			displayMode.SetSelected(initial.Display)
+		}
+		displayIndex := widget.NewEntry()
+		displayIndex.SetText(strconv.Itoa(initial.DisplayIndex))
+
+		format := widget.NewSelect([]string{
+			utils.FormatPNG,
+			utils.FormatJPEG,
+			utils.FormatGIF,
+			utils.FormatBMP,
+			utils.FormatTIFF,
+		}, func(string) {})
+		format.SetSelected(utils.FormatPNG)
+		if f, err := utils.ParseFormat(initial.Format); err == nil {
+			format.SetSelected(f)
+		}
+		jpegQuality := widget.NewEntry()
+		jpegQuality.SetPlaceHolder(strconv.Itoa(utils.DefaultJPEGQuality))
+		if initial.JPEGQuality != 0 {
+			jpegQuality.SetText(strconv.Itoa(initial.JPEGQuality))
+		}
+
+		delay := widget.NewEntry()
+		delay.SetPlaceHolder("3")
+		if initial.DelaySeconds != 0 {
+			delay.SetText(strconv.Itoa(initial.DelaySeconds))
+		}
+
+		aspect := widget.NewEntry()
+		aspect.SetPlaceHolder("Free (e.g. 16:9)")
+		aspect.SetText(initial.SelectionAspect)
+		fixedSize := widget.NewEntry()
+		fixedSize.SetPlaceHolder("Free (e.g. 1280x720)")
+		fixedSize.SetText(initial.SelectionSize)
+		minSize := widget.NewEntry()
+		minSize.SetPlaceHolder("None (e.g. 64x64)")
+		minSize.SetText(initial.SelectionMinSize)
+
+		destination := widget.NewSelect([]string{
+			clipboard.DestinationFile,
+			clipboard.DestinationClipboard,
+			clipboard.DestinationBoth,
+		}, func(string) {})
+		destination.SetSelected(clipboard.DestinationFile)
+		if initial.Destination != "" {
+			destination.SetSelected(initial.Destination)
+		}
+		copyPath := widget.NewCheck("Copy the saved path instead of the image", func(bool) {})
+		copyPath.SetChecked(initial.ClipboardContent == clipboard.ContentPath)
+
+		defaults := hotkeys.Defaults()
+		hotkeyEntries := make(map[string]*widget.Entry, len(hotkeys.Actions()))
+		hotkeyForm := widget.NewForm()
+		for _, action := range hotkeys.Actions() {
+			e := widget.NewEntry()
+			e.SetPlaceHolder("Disabled")
+			binding, ok := initial.Hotkeys[action]
+			if !ok {
+				binding = defaults[action]
+			}
+			e.SetText(binding)
+			hotkeyEntries[action] = e
+			hotkeyForm.Append(action, e)
+		}
 
 		browseBtn := widget.NewButton("Browse…", func() {
 			fd := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
@@ -74,13 +162,73 @@ func ShowSettings(initialOutDir string, initialPostCapturePrompt bool) (newCfg c
 		})
 
 		saveBtn := widget.NewButton("Save", func() {
-			send(settingsResult{
-				cfg: config.Config{
-					OutputDir:         strings.TrimSpace(outEntry.Text),
-					PostCapturePrompt: postPrompt.Checked,
-				},
-				saved: true,
-			})
+			bindings := hotkeyOverrides(hotkeyEntries, defaults, initial.Hotkeys)
+			if _, err := hotkeys.Resolve(bindings); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+
+			index, err := strconv.Atoi(strings.TrimSpace(displayIndex.Text))
+			if err != nil || index < 0 {
+				dialog.ShowError(errors.New("display index must be a non-negative number"), w)
+				return
+			}
+
+			quality := 0
+			if text := strings.TrimSpace(jpegQuality.Text); text != "" {
+				quality, err = strconv.Atoi(text)
+				if err != nil || quality < 1 || quality > 100 {
+					dialog.ShowError(errors.New("JPEG quality must be a number from 1 to 100"), w)
+					return
+				}
+			}
+
+			delaySeconds := 0
+			if text := strings.TrimSpace(delay.Text); text != "" {
+				delaySeconds, err = strconv.Atoi(text)
+				if err != nil || delaySeconds < 1 || delaySeconds > 60 {
+					dialog.ShowError(errors.New("delay must be a number of seconds from 1 to 60"), w)
+					return
+				}
+			}
+
+			if _, err := overlay.ParseConstraint(aspect.Text, fixedSize.Text, minSize.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+
+			if _, err := utils.ParseFilenameTemplate(filename.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+
+			content := clipboard.ContentImage
+			if copyPath.Checked {
+				content = clipboard.ContentPath
+			}
+			if err := (clipboard.Destination{Mode: destination.Selected, Content: content}).Validate(); err != nil {
+				dialog.ShowError(errors.New("copying the path needs the \"both\" destination"), w)
+				return
+			}
+
+			cfg := initial
+			cfg.DelaySeconds = delaySeconds
+			cfg.SelectionAspect = strings.TrimSpace(aspect.Text)
+			cfg.SelectionSize = strings.TrimSpace(fixedSize.Text)
+			cfg.SelectionMinSize = strings.TrimSpace(minSize.Text)
+			cfg.Destination = destination.Selected
+			cfg.ClipboardContent = content
+			cfg.Format = format.Selected
+			cfg.JPEGQuality = quality
+			cfg.Display = displayMode.Selected
+			cfg.DisplayIndex = index
+			cfg.OutputDir = strings.TrimSpace(outEntry.Text)
+			cfg.FilenameTemplate = strings.TrimSpace(filename.Text)
+			cfg.DateFolders = dateFolders.Checked
+			cfg.StripMetadata = !embedMetadata.Checked
+			cfg.PostCapturePrompt = postPrompt.Checked
+			cfg.Hotkeys = bindings
+			send(settingsResult{cfg: cfg, saved: true})
 			w.Close()
 		})
 
@@ -95,8 +243,31 @@ func ShowSettings(initialOutDir string, initialPostCapturePrompt bool) (newCfg c
 		form := container.NewVBox(
 			widget.NewLabel("Output directory"),
 			container.NewBorder(nil, nil, nil, browseBtn, outEntry),
+			widget.NewForm(widget.NewFormItem("File names", filename)),
+			dateFolders,
+			embedMetadata,
 			widget.NewSeparator(),
 			postPrompt,
+			widget.NewForm(widget.NewFormItem("Delayed capture (seconds)", delay)),
+			widget.NewSeparator(),
+			widget.NewLabel("Area selection (hold Shift in the overlay to select freely)"),
+			container.NewGridWithColumns(3,
+				widget.NewForm(widget.NewFormItem("Aspect", aspect)),
+				widget.NewForm(widget.NewFormItem("Size", fixedSize)),
+				widget.NewForm(widget.NewFormItem("Min", minSize)),
+			),
+			widget.NewSeparator(),
+			widget.NewLabel("Destination"),
+			container.NewGridWithColumns(2, destination, copyPath),
+			widget.NewSeparator(),
+			widget.NewLabel("Image format"),
+			container.NewGridWithColumns(2, format, widget.NewForm(widget.NewFormItem("JPEG quality", jpegQuality))),
+			widget.NewSeparator(),
+			widget.NewLabel("Capture display"),
+			container.NewGridWithColumns(2, displayMode, widget.NewForm(widget.NewFormItem("Index", displayIndex))),
+			widget.NewSeparator(),
+			widget.NewLabel("Hotkeys (e.g. Ctrl+Alt+P; leave empty to disable)"),
+			hotkeyForm,
 			container.NewHBox(layout.NewSpacer(), closeBtn, saveBtn),
 		)
 		w.SetContent(container.NewPadded(form))
@@ -106,3 +277,26 @@ func ShowSettings(initialOutDir string, initialPostCapturePrompt bool) (newCfg c
 	res := <-done
 	return res.cfg, res.saved, res.err
 }
+
+// hotkeyOverrides returns the bindings that differ from the defaults, so that changes to the
+// defaults still reach users who never touched a given action. Region bindings in previous,
+// which have no entry here, are kept.
+func hotkeyOverrides(entries map[string]*widget.Entry, defaults map[string]string, previous map[string]string) map[string]string {
+	out := make(map[string]string)
+	for action, binding := range previous {
+		if _, ok := hotkeys.RegionName(action); ok {
+			out[action] = binding
+		}
+	}
+	for action, e := range entries {
+		text := strings.TrimSpace(e.Text)
+		if text == defaults[action] {
+			continue
+		}
+		out[action] = text
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -5,6 +5,6 @@ package ui
 import "go-snip/internal/config"
 
 // ShowSettings is unavailable unless built with the `fyne` build tag.
-func ShowSettings(initialOutDir string, initialPostCapturePrompt bool) (newCfg config.Config, saved bool, err error) {
+func ShowSettings(initial config.Config) (newCfg config.Config, saved bool, err error) {
 	return config.Config{}, false, ErrSettingsUnavailable
 }
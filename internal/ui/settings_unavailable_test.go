@@ -5,12 +5,14 @@ package ui
 import (
 	"errors"
 	"testing"
+
+	"go-snip/internal/config"
 )
 
 func TestShowSettings_UnavailableWithoutFyne(t *testing.T) {
 	t.Parallel()
 
-	_, _, err := ShowSettings("C:\\test", false)
+	_, _, err := ShowSettings(config.Config{OutputDir: "C:\\test"})
 	if !errors.Is(err, ErrSettingsUnavailable) {
 		t.Fatalf("expected ErrSettingsUnavailable, got=%v", err)
 	}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"image"
-	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
@@ -28,10 +27,21 @@ func EnsureDir(path string) error {
 // FilenameForTime returns a deterministic PNG filename in local time:
 // YYYYMMDD_HHMMSS_mmm.png
 func FilenameForTime(t time.Time) string {
+	return timestampBase(t) + ".png"
+}
+
+// timestampBase returns the timestamp part of generated filenames: YYYYMMDD_HHMMSS_mmm.
+func timestampBase(t time.Time) string {
 	t = t.Local()
 	base := t.Format("20060102_150405")
 	ms := t.Nanosecond() / int(time.Millisecond)
-	return fmt.Sprintf("%s_%03d.png", base, ms)
+	return fmt.Sprintf("%s_%03d", base, ms)
+}
+
+// DateDir returns the date folder for t inside dir: dir/YYYY/MM/DD in local time.
+func DateDir(dir string, t time.Time) string {
+	t = t.Local()
+	return filepath.Join(dir, t.Format("2006"), t.Format("01"), t.Format("02"))
 }
 
 // SanitizeFilenameComponent returns a string safe to use as a filename component on Windows.
@@ -90,7 +100,7 @@ func isWindowsReservedBaseName(upper string) bool {
 // BaseNameForTimeAndName returns a deterministic base filename (no extension) in local time:
 // YYYYMMDD_HHMMSS_mmm or YYYYMMDD_HHMMSS_mmm - <name>
 func BaseNameForTimeAndName(t time.Time, rawName string) string {
-	ts := strings.TrimSuffix(FilenameForTime(t), ".png")
+	ts := timestampBase(t)
 	name := SanitizeFilenameComponent(rawName)
 	if name == "" {
 		return ts
@@ -98,24 +108,29 @@ func BaseNameForTimeAndName(t time.Time, rawName string) string {
 	return ts + " - " + name
 }
 
-// UniquePathWithBase returns a destination path inside dir for the provided base (no extension).
+// UniquePathWithBase returns a destination .png path inside dir for the provided base (no extension).
 // If the base filename already exists, it appends a counter suffix:
 // ... - 001.png, ... - 002.png, ...
 //
 // The exists function is injected for testability.
 func UniquePathWithBase(dir string, base string, exists func(path string) bool) string {
+	return UniquePathWithBaseExt(dir, base, ".png", exists)
+}
+
+// UniquePathWithBaseExt is UniquePathWithBase for any extension (with leading dot, e.g. ".jpg").
+func UniquePathWithBaseExt(dir string, base string, ext string, exists func(path string) bool) string {
 	base = strings.TrimSpace(base)
 	if base == "" {
 		// Fall back to something sane (callers generally pass a timestamp base).
 		base = "screenshot"
 	}
 
-	candidate := filepath.Join(dir, base+".png")
+	candidate := filepath.Join(dir, base+ext)
 	if !exists(candidate) {
 		return candidate
 	}
 	for i := 1; ; i++ {
-		name := fmt.Sprintf("%s - %03d.png", base, i)
+		name := fmt.Sprintf("%s - %03d%s", base, i, ext)
 		candidate = filepath.Join(dir, name)
 		if !exists(candidate) {
 			return candidate
@@ -123,22 +138,26 @@ func UniquePathWithBase(dir string, base string, exists func(path string) bool)
 	}
 }
 
-// UniquePath returns a destination path inside dir for the provided time.
+// UniquePath returns a destination .png path inside dir for the provided time.
 // If the base filename already exists, it appends a counter suffix:
 // ..._001.png, ..._002.png, ...
 //
 // The exists function is injected for testability.
 func UniquePath(dir string, t time.Time, exists func(path string) bool) string {
-	filename := FilenameForTime(t)
-	base := strings.TrimSuffix(filename, ".png")
+	return UniquePathExt(dir, t, ".png", exists)
+}
 
-	candidate := filepath.Join(dir, filename)
+// UniquePathExt is UniquePath for any extension (with leading dot, e.g. ".jpg").
+func UniquePathExt(dir string, t time.Time, ext string, exists func(path string) bool) string {
+	base := timestampBase(t)
+
+	candidate := filepath.Join(dir, base+ext)
 	if !exists(candidate) {
 		return candidate
 	}
 
 	for i := 1; ; i++ {
-		name := fmt.Sprintf("%s_%03d.png", base, i)
+		name := fmt.Sprintf("%s_%03d%s", base, i, ext)
 		candidate = filepath.Join(dir, name)
 		if !exists(candidate) {
 			return candidate
@@ -153,20 +172,5 @@ func pathExists(path string) bool {
 
 // SavePNG writes img as a PNG to destPath, creating the parent directory if needed.
 func SavePNG(img image.Image, destPath string) error {
-	if strings.TrimSpace(destPath) == "" {
-		return errors.New("destPath is empty")
-	}
-
-	if err := EnsureDir(filepath.Dir(destPath)); err != nil {
-		return err
-	}
-
-	f, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-
-	encodeErr := png.Encode(f, img)
-	closeErr := f.Close()
-	return errors.Join(encodeErr, closeErr)
+	return SaveImage(img, destPath, SaveOptions{Format: FormatPNG})
 }
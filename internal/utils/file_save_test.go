@@ -50,6 +50,15 @@ func TestFilenameForTime_Format(t *testing.T) {
 	}
 }
 
+func TestDateDir(t *testing.T) {
+	t.Parallel()
+
+	tt := time.Date(2026, 3, 7, 23, 0, 0, 0, time.Local)
+	if got, want := DateDir("out", tt), filepath.Join("out", "2026", "03", "07"); got != want {
+		t.Fatalf("DateDir() = %q, want %q", got, want)
+	}
+}
+
 func TestUniquePath_NoCollision(t *testing.T) {
 	t.Parallel()
 
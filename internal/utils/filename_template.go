@@ -0,0 +1,501 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidTemplate is wrapped by ParseFilenameTemplate errors.
+var ErrInvalidTemplate = errors.New("utils: invalid filename template")
+
+// Placeholders understood by ParseFilenameTemplate.
+const (
+	fieldDate      = "date"      // {date} 2006-01-02, or {date:<Go time layout>}
+	fieldTime      = "time"      // {time} 150405, or {time:<Go time layout>}
+	fieldTimestamp = "timestamp" // YYYYMMDD_HHMMSS_mmm, as in the default names
+	fieldMode      = "mode"      // full, area, window, region, last, scroll, record
+	fieldDisplay   = "display"   // display index, or "all"
+	fieldWidth     = "w"
+	fieldHeight    = "h"
+	fieldHost      = "host"
+	fieldName      = "name"    // the name entered in the post-capture prompt
+	fieldCounter   = "counter" // sequence number, 001, 002, ... ({counter:N} for N digits)
+)
+
+const defaultCounterWidth = 3
+
+// FilenameTemplate is a parsed filename template such as
+// "{date:2006-01-02}/{mode}_{display}_{w}x{h}_{name}{counter}". Each "/" starts a
+// subdirectory; the extension is added by the caller. The zero value is the empty template.
+type FilenameTemplate struct {
+	raw        string
+	components [][]templateSegment
+}
+
+// templateSegment is literal text or, if field is set, a placeholder.
+type templateSegment struct {
+	literal string
+	field   string
+	arg     string
+}
+
+// FilenameFields are the values substituted into a FilenameTemplate.
+type FilenameFields struct {
+	Time time.Time
+	Mode string
+	// Display is the display index, or negative for captures of all displays.
+	Display       int
+	Width, Height int
+	Host          string
+	Name          string
+}
+
+// ParseFilenameTemplate parses s; an empty s gives the zero template. It rejects unknown or
+// unclosed placeholders, arguments on placeholders that take none, and empty path components
+// (absolute paths, "//").
+func ParseFilenameTemplate(s string) (FilenameTemplate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return FilenameTemplate{}, nil
+	}
+
+	t := FilenameTemplate{raw: s}
+	var comp []templateSegment
+	var lit strings.Builder
+	flushLiteral := func() {
+		if lit.Len() > 0 {
+			comp = append(comp, templateSegment{literal: lit.String()})
+			lit.Reset()
+		}
+	}
+	endComponent := func() error {
+		flushLiteral()
+		if len(comp) == 0 {
+			return fmt.Errorf("%w %q: empty path component", ErrInvalidTemplate, s)
+		}
+		t.components = append(t.components, comp)
+		comp = nil
+		return nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '/':
+			if err := endComponent(); err != nil {
+				return FilenameTemplate{}, err
+			}
+		case '}':
+			return FilenameTemplate{}, fmt.Errorf("%w %q: unmatched }", ErrInvalidTemplate, s)
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return FilenameTemplate{}, fmt.Errorf("%w %q: unclosed {", ErrInvalidTemplate, s)
+			}
+			seg, err := parsePlaceholder(s[i+1 : i+end])
+			if err != nil {
+				return FilenameTemplate{}, fmt.Errorf("%w %q: %v", ErrInvalidTemplate, s, err)
+			}
+			flushLiteral()
+			comp = append(comp, seg)
+			i += end
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	if err := endComponent(); err != nil {
+		return FilenameTemplate{}, err
+	}
+	return t, nil
+}
+
+func parsePlaceholder(body string) (templateSegment, error) {
+	if strings.ContainsRune(body, '{') {
+		return templateSegment{}, errors.New("nested {")
+	}
+	field, arg, hasArg := strings.Cut(body, ":")
+	seg := templateSegment{field: strings.ToLower(strings.TrimSpace(field)), arg: arg}
+	switch seg.field {
+	case fieldDate, fieldTime:
+		if hasArg && strings.TrimSpace(arg) == "" {
+			return templateSegment{}, fmt.Errorf("{%s:} needs a time layout", seg.field)
+		}
+		return seg, nil
+	case fieldCounter:
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 || n > 9 {
+				return templateSegment{}, fmt.Errorf("{counter:%s}: width must be 1..9", arg)
+			}
+		}
+		return seg, nil
+	case fieldTimestamp, fieldMode, fieldDisplay, fieldWidth, fieldHeight, fieldHost, fieldName:
+		if hasArg {
+			return templateSegment{}, fmt.Errorf("{%s} takes no argument", seg.field)
+		}
+		return seg, nil
+	}
+	return templateSegment{}, fmt.Errorf("unknown placeholder {%s}", body)
+}
+
+// IsZero reports whether t is the empty template.
+func (t FilenameTemplate) IsZero() bool {
+	return len(t.components) == 0
+}
+
+// String returns the template as written.
+func (t FilenameTemplate) String() string {
+	return t.raw
+}
+
+// HasCounter reports whether t contains {counter}.
+func (t FilenameTemplate) HasCounter() bool {
+	for _, comp := range t.components {
+		for _, seg := range comp {
+			if seg.field == fieldCounter {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Expand returns the relative path (without extension) for f, with counter as the {counter}
+// value. Every component goes through SanitizeFilenameComponent and loses the separators
+// ("_", "-", spaces, dots) left dangling at its ends by empty placeholders. Directory components
+// that end up empty are dropped; an empty file name becomes "screenshot".
+func (t FilenameTemplate) Expand(f FilenameFields, counter int) string {
+	var parts []string
+	for i, comp := range t.components {
+		var b strings.Builder
+		for _, seg := range comp {
+			if seg.field == "" {
+				b.WriteString(seg.literal)
+				continue
+			}
+			b.WriteString(seg.value(f, counter))
+		}
+		part := strings.Trim(SanitizeFilenameComponent(b.String()), "_- .")
+		if part == "" {
+			if i < len(t.components)-1 {
+				continue
+			}
+			part = "screenshot"
+		}
+		parts = append(parts, part)
+	}
+	return filepath.Join(parts...)
+}
+
+func (s templateSegment) value(f FilenameFields, counter int) string {
+	switch s.field {
+	case fieldDate:
+		return f.Time.Local().Format(orDefault(s.arg, "2006-01-02"))
+	case fieldTime:
+		return f.Time.Local().Format(orDefault(s.arg, "150405"))
+	case fieldTimestamp:
+		return timestampBase(f.Time)
+	case fieldMode:
+		return f.Mode
+	case fieldDisplay:
+		if f.Display < 0 {
+			return "all"
+		}
+		return strconv.Itoa(f.Display)
+	case fieldWidth:
+		return strconv.Itoa(f.Width)
+	case fieldHeight:
+		return strconv.Itoa(f.Height)
+	case fieldHost:
+		return f.Host
+	case fieldName:
+		return f.Name
+	case fieldCounter:
+		width := defaultCounterWidth
+		if s.arg != "" {
+			width, _ = strconv.Atoi(s.arg)
+		}
+		return fmt.Sprintf("%0*d", width, counter)
+	}
+	return ""
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// UniquePath returns a destination path inside dir for f with extension ext (leading dot). With
+// {counter} in the template it uses the lowest counter from 1 whose path is free; otherwise a
+// taken path gets a " - 001", " - 002", ... suffix like UniquePathWithBaseExt.
+//
+// The exists function is injected for testability.
+func (t FilenameTemplate) UniquePath(dir string, f FilenameFields, ext string, exists func(path string) bool) string {
+	if !t.HasCounter() {
+		return UniquePathWithBaseExt(dir, t.Expand(f, 0), ext, exists)
+	}
+	for n := 1; ; n++ {
+		candidate := filepath.Join(dir, t.Expand(f, n)+ext)
+		if !exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+// HasTime reports whether t contains {date}, {time} or {timestamp}.
+func (t FilenameTemplate) HasTime() bool {
+	for _, comp := range t.components {
+		for _, seg := range comp {
+			switch seg.field {
+			case fieldDate, fieldTime, fieldTimestamp:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Pattern returns a regular expression matching the slash-separated relative paths, with any
+// extension and " - 001" style suffix, that t produces, taking into account the separators
+// Expand trims when {name} or {host} is empty. Digits in time values match the exact widths
+// their layout produces, month and weekday names any run of letters, and {name} and {host}
+// anything.
+//
+// Pattern returns nil when those names can't be told apart from other files: for the zero
+// template, without a time placeholder, when the file name has no placeholder besides {name}
+// and {host} ("{date}/{name}"), or when {name} or {host} can end up right next to a time
+// placeholder with no literal in between ("{time}{name}").
+func (t FilenameTemplate) Pattern() *regexp.Regexp {
+	if t.IsZero() || !t.HasTime() {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	last := len(t.components) - 1
+	for i, comp := range t.components {
+		alts, canBeEmpty, ok := componentPatterns(comp)
+		if !ok {
+			return nil
+		}
+		re := "(?:" + strings.Join(alts, "|") + ")"
+		if i < last {
+			if canBeEmpty {
+				fmt.Fprintf(&b, "(?:%s/)?", re)
+			} else {
+				fmt.Fprintf(&b, "%s/", re)
+			}
+			continue
+		}
+		if !slices.ContainsFunc(comp, func(seg templateSegment) bool { return seg.field != "" && !seg.freeText() }) {
+			return nil
+		}
+		if canBeEmpty {
+			re = "(?:" + strings.Join(append(alts, "screenshot"), "|") + ")"
+		}
+		b.WriteString(re)
+	}
+	if !t.HasCounter() {
+		b.WriteString(`(?: - \d{3,})?`)
+	}
+	b.WriteString(`\.[0-9A-Za-z]+$`)
+	return regexp.MustCompile(b.String())
+}
+
+// maxFreeTextFields bounds the {name} and {host} placeholders per path component in Pattern,
+// which tries every combination of them being empty.
+const maxFreeTextFields = 6
+
+// componentPatterns returns the patterns of what comp expands to for every combination of its
+// {name} and {host} placeholders being empty or not, and whether it can expand to nothing.
+// It reports !ok if a free-text placeholder can end up next to a time placeholder.
+func componentPatterns(comp []templateSegment) (alts []string, canBeEmpty, ok bool) {
+	var free []int
+	for i, seg := range comp {
+		if seg.freeText() {
+			free = append(free, i)
+		}
+	}
+	if len(free) > maxFreeTextFields {
+		return nil, false, false
+	}
+	for mask := 0; mask < 1<<len(free); mask++ {
+		var segs []templateSegment
+		for i, seg := range comp {
+			if j := slices.Index(free, i); j >= 0 && mask&(1<<j) == 0 {
+				continue // this one is empty
+			}
+			if n := len(segs); n > 0 && seg.field == "" && segs[n-1].field == "" {
+				segs[n-1].literal += seg.literal
+				continue
+			}
+			segs = append(segs, seg)
+		}
+		re, ok := expansionPattern(segs)
+		if !ok {
+			return nil, false, false
+		}
+		if re == "" {
+			canBeEmpty = true
+		} else if !slices.Contains(alts, re) {
+			alts = append(alts, re)
+		}
+	}
+	return alts, canBeEmpty, true
+}
+
+// expansionPattern returns the pattern of segs, adjacent literals merged, after Expand trims the
+// separators at both ends. Placeholders other than {name} and {host} never expand to nothing.
+func expansionPattern(segs []templateSegment) (string, bool) {
+	for i := range segs {
+		segs[i].literal = sanitizeLiteral(segs[i].literal)
+	}
+	if len(segs) > 0 && segs[0].field == "" {
+		segs[0].literal = strings.TrimLeft(segs[0].literal, "_- .")
+	}
+	if n := len(segs); n > 0 && segs[n-1].field == "" {
+		segs[n-1].literal = strings.TrimRight(segs[n-1].literal, "_- .")
+	}
+
+	var b strings.Builder
+	for i, seg := range segs {
+		if i > 0 && (seg.freeText() && segs[i-1].isTime() || seg.isTime() && segs[i-1].freeText()) {
+			return "", false
+		}
+		b.WriteString(seg.pattern())
+	}
+	return b.String(), true
+}
+
+// freeText reports whether s is {name} or {host}, which may expand to anything, or nothing.
+func (s templateSegment) freeText() bool {
+	return s.field == fieldName || s.field == fieldHost
+}
+
+func (s templateSegment) isTime() bool {
+	return s.field == fieldDate || s.field == fieldTime || s.field == fieldTimestamp
+}
+
+// patternSamples are the times whose formatting gives the shape of time placeholders in
+// Pattern: one with the fewest digits in unpadded layout elements, one with the most.
+var patternSamples = [2]time.Time{
+	time.Date(2006, time.January, 2, 3, 4, 5, 0, time.Local),
+	time.Date(2006, time.December, 29, 23, 59, 59, 999999999, time.Local),
+}
+
+func (s templateSegment) pattern() string {
+	switch s.field {
+	case "":
+		return regexp.QuoteMeta(s.literal)
+	case fieldDate, fieldTime, fieldTimestamp:
+		return timePattern(s.value(FilenameFields{Time: patternSamples[0]}, 0), s.value(FilenameFields{Time: patternSamples[1]}, 0))
+	case fieldMode:
+		return `[a-z]*`
+	case fieldDisplay:
+		return `(?:\d+|all)`
+	case fieldWidth, fieldHeight:
+		return `\d+`
+	case fieldHost, fieldName:
+		return `[^/]+`
+	case fieldCounter:
+		width := defaultCounterWidth
+		if s.arg != "" {
+			width, _ = strconv.Atoi(s.arg)
+		}
+		return fmt.Sprintf(`\d{%d,}`, width)
+	}
+	return ""
+}
+
+// sanitizeLiteral applies the replacements of SanitizeFilenameComponent to literal text.
+func sanitizeLiteral(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 32:
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// shapeRun is a run of digits or letters, or a single other character, in a formatted time.
+type shapeRun struct {
+	kind     rune // 'd' for digits, 'l' for letters, 0 for the literal text
+	text     string
+	min, max int
+}
+
+func shapeRuns(s string) []shapeRun {
+	var runs []shapeRun
+	runes := []rune(sanitizeLiteral(s))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		var same func(rune) bool
+		var kind rune
+		switch {
+		case unicode.IsDigit(r):
+			same, kind = unicode.IsDigit, 'd'
+		case unicode.IsLetter(r):
+			same, kind = unicode.IsLetter, 'l'
+		default:
+			runs = append(runs, shapeRun{text: string(r)})
+			continue
+		}
+		n := 1
+		for i+1 < len(runes) && same(runes[i+1]) {
+			i++
+			n++
+		}
+		runs = append(runs, shapeRun{kind: kind, min: n, max: n})
+	}
+	return runs
+}
+
+// timePattern matches time values shaped like a and b, the same placeholder formatted at both
+// patternSamples. If their runs line up, digit runs match from the shorter to the longer width,
+// so unpadded elements like "1" (month) match any value; otherwise either shape matches.
+func timePattern(a, b string) string {
+	ra, rb := shapeRuns(a), shapeRuns(b)
+	if len(ra) == len(rb) {
+		merged := make([]shapeRun, len(ra))
+		for i := range ra {
+			if ra[i].kind != rb[i].kind || ra[i].text != rb[i].text {
+				merged = nil
+				break
+			}
+			merged[i] = shapeRun{kind: ra[i].kind, text: ra[i].text, min: min(ra[i].min, rb[i].min), max: max(ra[i].max, rb[i].max)}
+		}
+		if merged != nil {
+			return runsPattern(merged)
+		}
+	}
+	return "(?:" + runsPattern(ra) + "|" + runsPattern(rb) + ")"
+}
+
+func runsPattern(runs []shapeRun) string {
+	var b strings.Builder
+	for _, r := range runs {
+		switch {
+		case r.kind == 'l':
+			b.WriteString(`\pL+`)
+		case r.kind == 'd' && r.min == r.max:
+			fmt.Fprintf(&b, `\d{%d}`, r.min)
+		case r.kind == 'd':
+			fmt.Fprintf(&b, `\d{%d,%d}`, r.min, r.max)
+		default:
+			b.WriteString(regexp.QuoteMeta(r.text))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFields() FilenameFields {
+	return FilenameFields{
+		Time:    time.Date(2025, 1, 2, 3, 4, 5, 678*int(time.Millisecond), time.Local),
+		Mode:    "area",
+		Display: 1,
+		Width:   800,
+		Height:  600,
+		Host:    "devbox",
+		Name:    "login bug",
+	}
+}
+
+func TestFilenameTemplate_Expand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		template string
+		counter  int
+		want     string
+	}{
+		{template: "{date:2006-01-02}/{mode}_{display}_{w}x{h}_{name}{counter}", counter: 7, want: filepath.Join("2025-01-02", "area_1_800x600_login bug007")},
+		{template: "{timestamp}", want: "20250102_030405_678"},
+		{template: "{date}_{time}", want: "2025-01-02_030405"},
+		{template: "{host}/{date:2006}/{date:01}/shot {counter:2}", counter: 3, want: filepath.Join("devbox", "2025", "01", "shot 03")},
+		{template: "{DATE:Jan 2}", want: "Jan 2"},
+		// A layout with a slash stays in its component.
+		{template: "{date:2006/01}", want: "2025_01"},
+	}
+	for _, tc := range cases {
+		tmpl, err := ParseFilenameTemplate(tc.template)
+		if err != nil {
+			t.Fatalf("ParseFilenameTemplate(%q) error: %v", tc.template, err)
+		}
+		if got := tmpl.Expand(testFields(), tc.counter); got != tc.want {
+			t.Fatalf("Expand(%q) got=%q want=%q", tc.template, got, tc.want)
+		}
+	}
+}
+
+func TestFilenameTemplate_SanitizesComponents(t *testing.T) {
+	t.Parallel()
+
+	f := testFields()
+	f.Name = `../..\evil: name?`
+	f.Display = -1
+	tmpl, err := ParseFilenameTemplate("{mode}/{name}/{display}_{name}")
+	if err != nil {
+		t.Fatalf("ParseFilenameTemplate() error: %v", err)
+	}
+	want := filepath.Join("area", "evil_ name", "all_.._.._evil_ name")
+	if got := tmpl.Expand(f, 0); got != want {
+		t.Fatalf("Expand() got=%q want=%q", got, want)
+	}
+
+	// Empty placeholders drop their dangling separators, empty directories and, as a last
+	// resort, the whole file name.
+	f.Name = ""
+	tmpl, _ = ParseFilenameTemplate("{name}/{mode}_{w}x{h}_{name}")
+	if got, want := tmpl.Expand(f, 0), "area_800x600"; got != want {
+		t.Fatalf("Expand(empty name) got=%q want=%q", got, want)
+	}
+	tmpl, _ = ParseFilenameTemplate("{mode}/..")
+	if got, want := tmpl.Expand(f, 0), filepath.Join("area", "screenshot"); got != want {
+		t.Fatalf("Expand(..) got=%q want=%q", got, want)
+	}
+}
+
+func TestParseFilenameTemplate_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{
+		"{nope}",
+		"{mode",
+		"mode}",
+		"{mo{de}}",
+		"/abs/{mode}",
+		"a//b",
+		"{mode}/",
+		"{w:3}",
+		"{counter:0}",
+		"{counter:x}",
+		"{date:}",
+	} {
+		if _, err := ParseFilenameTemplate(s); !errors.Is(err, ErrInvalidTemplate) {
+			t.Fatalf("ParseFilenameTemplate(%q) error=%v want=%v", s, err, ErrInvalidTemplate)
+		}
+	}
+
+	tmpl, err := ParseFilenameTemplate("  ")
+	if err != nil || !tmpl.IsZero() {
+		t.Fatalf("ParseFilenameTemplate(blank) got=%v,%v want the zero template", tmpl, err)
+	}
+}
+
+func TestFilenameTemplate_UniquePath(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("some", "dir")
+	withCounter, _ := ParseFilenameTemplate("{mode}/{name} {counter}")
+	taken := map[string]bool{
+		filepath.Join(dir, "area", "login bug 001.png"): true,
+		filepath.Join(dir, "area", "login bug 002.png"): true,
+	}
+	exists := func(p string) bool { return taken[p] }
+	if got, want := withCounter.UniquePath(dir, testFields(), ".png", exists), filepath.Join(dir, "area", "login bug 003.png"); got != want {
+		t.Fatalf("UniquePath(counter) got=%q want=%q", got, want)
+	}
+
+	plain, _ := ParseFilenameTemplate("{mode}/{name}")
+	taken[filepath.Join(dir, "area", "login bug.png")] = true
+	if got, want := plain.UniquePath(dir, testFields(), ".png", exists), filepath.Join(dir, "area", "login bug - 001.png"); got != want {
+		t.Fatalf("UniquePath(plain) got=%q want=%q", got, want)
+	}
+}
+
+func TestFilenameTemplate_Pattern(t *testing.T) {
+	t.Parallel()
+
+	noName := testFields()
+	noName.Name, noName.Host = "", ""
+	cases := []struct {
+		template string
+		fields   FilenameFields
+		others   []string
+	}{
+		{template: "{date:2006-01-02}/{mode}_{display}_{w}x{h}_{name}{counter}", fields: testFields(), others: []string{"2025-01-02/notes.txt.png", "area_1_800x600_001.png", "2025-01-02/area_1_800x600_x.png"}},
+		{template: "{date:2006-01-02}/{mode}_{display}_{w}x{h}_{name}{counter}", fields: noName},
+		{template: "{host}/{date:Jan 2} {time:15:04}", fields: noName, others: []string{"Jan 2 1504.png", "devbox/Jan 2 15.png"}},
+		{template: "{timestamp} - {name}", fields: testFields(), others: []string{"20250102_030405.png", "holiday.jpg"}},
+		{template: "{timestamp} - {name}", fields: noName},
+		{template: "{date:1/2} {time:3PM}", fields: noName, others: []string{"1_2 3.png", "123_4 5PM.png"}},
+		{template: "{date:2006}/{mode}", fields: testFields(), others: []string{"19/area.png", "20190/area.png"}},
+	}
+	for _, tc := range cases {
+		tmpl, err := ParseFilenameTemplate(tc.template)
+		if err != nil {
+			t.Fatalf("ParseFilenameTemplate(%q) error: %v", tc.template, err)
+		}
+		re := tmpl.Pattern()
+		for _, name := range []string{tmpl.Expand(tc.fields, 1) + ".png", tmpl.Expand(tc.fields, 12) + " - 004.jpg"} {
+			name = filepath.ToSlash(name)
+			if !tmpl.HasCounter() || !strings.Contains(name, " - ") {
+				if !re.MatchString(name) {
+					t.Fatalf("Pattern(%q)=%s does not match %q", tc.template, re, name)
+				}
+			}
+		}
+		for _, name := range tc.others {
+			if re.MatchString(name) {
+				t.Fatalf("Pattern(%q)=%s matches %q", tc.template, re, name)
+			}
+		}
+	}
+	if (FilenameTemplate{}).Pattern() != nil {
+		t.Fatalf("Pattern() of the zero template is not nil")
+	}
+
+	// Names that can't be told apart from other files get no pattern.
+	for _, s := range []string{"{name}", "{time}{name}", "{host}{date}_{mode}", "{date}/{name}", "{date:2006}/{host} - {name}", "{name}-{host}{time}"} {
+		tmpl, err := ParseFilenameTemplate(s)
+		if err != nil {
+			t.Fatalf("ParseFilenameTemplate(%q) error: %v", s, err)
+		}
+		if re := tmpl.Pattern(); re != nil {
+			t.Fatalf("Pattern(%q)=%s, want nil", s, re)
+		}
+	}
+}
+
+func TestFilenameTemplate_HasTime(t *testing.T) {
+	t.Parallel()
+
+	for template, want := range map[string]bool{"{name}": false, "{mode}/{counter}": false, "{time:15}": true, "x/{TIMESTAMP}": true} {
+		tmpl, err := ParseFilenameTemplate(template)
+		if err != nil {
+			t.Fatalf("ParseFilenameTemplate(%q) error: %v", template, err)
+		}
+		if got := tmpl.HasTime(); got != want {
+			t.Fatalf("HasTime(%q) got=%v want=%v", template, got, want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// Supported image formats for SaveOptions.Format.
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatGIF  = "gif"
+	FormatBMP  = "bmp"
+	FormatTIFF = "tiff"
+)
+
+// Encoder defaults used when the corresponding SaveOptions field is zero.
+const (
+	DefaultJPEGQuality = 90
+	DefaultGIFColors   = 256
+)
+
+var ErrUnknownFormat = errors.New("utils: unknown image format")
+
+// SaveOptions selects the file format and per-format encoder options for SaveImage.
+// Zero values mean "use the default".
+type SaveOptions struct {
+	// Format is one of the Format* constants. If empty, SaveImage infers it from the
+	// destination extension, falling back to PNG.
+	Format string
+
+	// PNGCompression is "default", "none", "speed" or "best".
+	PNGCompression string
+
+	// JPEGQuality is 1..100 (default DefaultJPEGQuality).
+	JPEGQuality int
+
+	// GIFColors is the palette size, 2..256 (default DefaultGIFColors).
+	GIFColors int
+
+	// TIFFCompression is "none" or "deflate" (default "deflate").
+	TIFFCompression string
+
+	// Metadata is written into PNG text chunks; the other formats ignore it.
+	Metadata CaptureMetadata
+}
+
+// ParseFormat returns the canonical format name for s, accepting common aliases
+// (jpg, tif) and a leading dot. An empty string is PNG.
+func ParseFormat(s string) (string, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), ".") {
+	case "", "png":
+		return FormatPNG, nil
+	case "jpeg", "jpg":
+		return FormatJPEG, nil
+	case "gif":
+		return FormatGIF, nil
+	case "bmp":
+		return FormatBMP, nil
+	case "tiff", "tif":
+		return FormatTIFF, nil
+	}
+	return "", fmt.Errorf("%w %q", ErrUnknownFormat, s)
+}
+
+// FormatExtension returns the file extension (with leading dot) used for format.
+// Unknown formats get ".png".
+func FormatExtension(format string) string {
+	f, err := ParseFormat(format)
+	if err != nil {
+		return ".png"
+	}
+	switch f {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatTIFF:
+		return ".tiff"
+	}
+	return "." + f
+}
+
+// Extension returns the file extension for o.Format (see FormatExtension).
+func (o SaveOptions) Extension() string {
+	return FormatExtension(o.Format)
+}
+
+// Validate reports unknown formats and out-of-range encoder options.
+func (o SaveOptions) Validate() error {
+	var errs []error
+	if _, err := ParseFormat(o.Format); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := pngCompressionLevel(o.PNGCompression); err != nil {
+		errs = append(errs, err)
+	}
+	if o.JPEGQuality != 0 && (o.JPEGQuality < 1 || o.JPEGQuality > 100) {
+		errs = append(errs, fmt.Errorf("jpeg quality %d out of range 1..100", o.JPEGQuality))
+	}
+	if o.GIFColors != 0 && (o.GIFColors < 2 || o.GIFColors > 256) {
+		errs = append(errs, fmt.Errorf("gif colors %d out of range 2..256", o.GIFColors))
+	}
+	if _, err := tiffCompression(o.TIFFCompression); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// SaveImage encodes img to destPath according to opts, creating the parent directory if needed.
+// If opts.Format is empty, the format is inferred from destPath's extension (PNG if unknown).
+func SaveImage(img image.Image, destPath string, opts SaveOptions) error {
+	if strings.TrimSpace(destPath) == "" {
+		return errors.New("destPath is empty")
+	}
+	if opts.Format == "" {
+		if f, err := ParseFormat(filepath.Ext(destPath)); err == nil {
+			opts.Format = f
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	if err := EnsureDir(filepath.Dir(destPath)); err != nil {
+		return err
+	}
+
+	f, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+
+	encodeErr := EncodeImage(f, img, opts)
+	closeErr := f.Close()
+	return errors.Join(encodeErr, closeErr)
+}
+
+// EncodeImage writes img to w in the format selected by opts (PNG if opts.Format is empty).
+func EncodeImage(w io.Writer, img image.Image, opts SaveOptions) error {
+	format, err := ParseFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJPEG:
+		q := opts.JPEGQuality
+		if q == 0 {
+			q = DefaultJPEGQuality
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: q})
+	case FormatGIF:
+		n := opts.GIFColors
+		if n == 0 {
+			n = DefaultGIFColors
+		}
+		// Without a quantizer gif.Encode would take the first n colours of the Plan 9 palette.
+		return gif.Encode(w, Quantize(img, n), nil)
+	case FormatBMP:
+		return bmp.Encode(w, img)
+	case FormatTIFF:
+		c, err := tiffCompression(opts.TIFFCompression)
+		if err != nil {
+			return err
+		}
+		return tiff.Encode(w, img, &tiff.Options{Compression: c})
+	}
+
+	level, err := pngCompressionLevel(opts.PNGCompression)
+	if err != nil {
+		return err
+	}
+	enc := png.Encoder{CompressionLevel: level}
+	if opts.Metadata.IsZero() {
+		return enc.Encode(w, img)
+	}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		return err
+	}
+	data, err := InjectPNGMetadata(buf.Bytes(), opts.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func pngCompressionLevel(s string) (png.CompressionLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "default":
+		return png.DefaultCompression, nil
+	case "none":
+		return png.NoCompression, nil
+	case "speed":
+		return png.BestSpeed, nil
+	case "best":
+		return png.BestCompression, nil
+	}
+	return 0, fmt.Errorf("unknown png compression %q", s)
+}
+
+func tiffCompression(s string) (tiff.CompressionType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "deflate":
+		return tiff.Deflate, nil
+	case "none":
+		return tiff.Uncompressed, nil
+	}
+	return 0, fmt.Errorf("unknown tiff compression %q", s)
+}
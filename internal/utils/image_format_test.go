@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	_ "image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+)
+
+func TestParseFormat_Aliases(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{"": FormatPNG, "JPG": FormatJPEG, ".tif": FormatTIFF, "gif": FormatGIF, "bmp": FormatBMP}
+	for in, want := range cases {
+		got, err := ParseFormat(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseFormat(%q)=%q,%v want=%q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("webp"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("expected ErrUnknownFormat, got=%v", err)
+	}
+}
+
+func TestSaveOptions_Validate(t *testing.T) {
+	t.Parallel()
+
+	if err := (SaveOptions{Format: "jpeg", JPEGQuality: 75}).Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	bad := []SaveOptions{
+		{Format: "webp"},
+		{JPEGQuality: 101},
+		{GIFColors: 1},
+		{PNGCompression: "max"},
+		{TIFFCompression: "lzw"},
+	}
+	for _, o := range bad {
+		if err := o.Validate(); err == nil {
+			t.Fatalf("Validate(%+v): expected error", o)
+		}
+	}
+}
+
+func TestSaveImage_AllFormatsDecode(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(1, 1, color.RGBA{R: 200, G: 10, B: 10, A: 255})
+
+	dir := t.TempDir()
+	for _, format := range []string{FormatPNG, FormatJPEG, FormatGIF, FormatBMP, FormatTIFF} {
+		dest := filepath.Join(dir, "out"+FormatExtension(format))
+		if err := SaveImage(img, dest, SaveOptions{Format: format, JPEGQuality: 50, GIFColors: 16}); err != nil {
+			t.Fatalf("SaveImage(%s) error: %v", format, err)
+		}
+
+		f, err := os.Open(dest)
+		if err != nil {
+			t.Fatalf("Open(%s) error: %v", format, err)
+		}
+		decoded, got, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("Decode(%s) error: %v", format, err)
+		}
+		if got != format {
+			t.Fatalf("decoded format=%q want=%q", got, format)
+		}
+		if decoded.Bounds().Dx() != 4 || decoded.Bounds().Dy() != 3 {
+			t.Fatalf("%s bounds=%v want 4x3", format, decoded.Bounds())
+		}
+	}
+}
+
+func TestEncodeImage_GIFKeepsColors(t *testing.T) {
+	t.Parallel()
+
+	// 16 saturated blocks: the first 16 Plan 9 colours are all near-black, so an encoder without
+	// a quantizer turns most of them dark.
+	colors := []color.RGBA{
+		{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255},
+		{0, 255, 255, 255}, {255, 0, 255, 255}, {255, 128, 0, 255}, {128, 0, 255, 255},
+		{0, 128, 64, 255}, {255, 192, 203, 255}, {128, 128, 128, 255}, {255, 255, 255, 255},
+		{64, 32, 0, 255}, {0, 64, 128, 255}, {200, 200, 100, 255}, {100, 200, 200, 255},
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			img.SetRGBA(x, y, colors[(y/8)*4+x/8])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeImage(&buf, img, SaveOptions{Format: FormatGIF, GIFColors: 16}); err != nil {
+		t.Fatalf("EncodeImage() error: %v", err)
+	}
+	decoded, err := gif.Decode(&buf)
+	if err != nil {
+		t.Fatalf("gif.Decode() error: %v", err)
+	}
+	if n := len(decoded.(*image.Paletted).Palette); n > 16 {
+		t.Fatalf("palette has %d colors, want at most 16", n)
+	}
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			got, want := color.RGBAModel.Convert(decoded.At(x, y)).(color.RGBA), img.RGBAAt(x, y)
+			if d := max(absDiff(got.R, want.R), absDiff(got.G, want.G), absDiff(got.B, want.B)); d > 8 {
+				t.Fatalf("pixel (%d,%d) got=%v want=%v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func TestSaveImage_InfersFormatFromExtension(t *testing.T) {
+	t.Parallel()
+
+	dest := filepath.Join(t.TempDir(), "shot.JPG")
+	if err := SaveImage(image.NewRGBA(image.Rect(0, 0, 2, 2)), dest, SaveOptions{}); err != nil {
+		t.Fatalf("SaveImage() error: %v", err)
+	}
+	f, err := os.Open(dest)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer f.Close()
+	if _, format, err := image.DecodeConfig(f); err != nil || format != FormatJPEG {
+		t.Fatalf("format=%q err=%v want jpeg", format, err)
+	}
+}
+
+func TestUniquePathExt_CountersKeepExtension(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("some", "dir")
+	tt := time.Date(2025, 1, 2, 3, 4, 5, 111*int(time.Millisecond), time.Local)
+	existsSet := map[string]bool{
+		filepath.Join(dir, "20250102_030405_111.jpg"):     true,
+		filepath.Join(dir, "20250102_030405_111_001.jpg"): true,
+		// A PNG with the same base doesn't collide with a JPEG.
+		filepath.Join(dir, "20250102_030405_111_002.png"): true,
+	}
+
+	got := UniquePathExt(dir, tt, ".jpg", func(p string) bool { return existsSet[p] })
+	if want := filepath.Join(dir, "20250102_030405_111_002.jpg"); got != want {
+		t.Fatalf("UniquePathExt() = %q, want %q", got, want)
+	}
+
+	got = UniquePathWithBaseExt(dir, "x - name", ".tiff", func(p string) bool { return p == filepath.Join(dir, "x - name.tiff") })
+	if want := filepath.Join(dir, "x - name - 001.tiff"); got != want {
+		t.Fatalf("UniquePathWithBaseExt() = %q, want %q", got, want)
+	}
+}
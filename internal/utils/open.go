@@ -0,0 +1,12 @@
+package utils
+
+// OpenPath opens path (a file or folder) with the desktop's default application. It returns
+// once the opener has started, without waiting for it.
+func OpenPath(path string) error {
+	cmd := openCommand(path)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package utils
+
+import "os/exec"
+
+func openCommand(path string) *exec.Cmd {
+	return exec.Command("xdg-open", path)
+}
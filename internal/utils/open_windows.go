@@ -0,0 +1,7 @@
+package utils
+
+import "os/exec"
+
+func openCommand(path string) *exec.Cmd {
+	return exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+}
@@ -0,0 +1,392 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PNG text keywords written by go-snip. Where the PNG specification defines a keyword for the
+// field it is used, so other viewers show it too.
+const (
+	pngKeyTime          = "Creation Time"
+	pngKeySoftware      = "Software"
+	pngKeyName          = "Title"
+	pngKeyNotes         = "Comment"
+	pngKeyHost          = "go-snip:host"
+	pngKeyDisplay       = "go-snip:display"
+	pngKeyDisplayBounds = "go-snip:display-bounds"
+	pngKeySelection     = "go-snip:selection"
+)
+
+// pngTimeLayout is RFC 3339 with milliseconds, the precision of capture file names.
+const pngTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// maxPNGTextChunk bounds the text chunks ReadPNGMetadata accepts, compressed or not, so a
+// corrupt length can't make it allocate gigabytes.
+const maxPNGTextChunk = 8 << 20
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var ErrNotPNG = errors.New("utils: not a PNG file")
+
+// CaptureMetadata is the context go-snip stores in the PNG text chunks of a capture.
+// Zero fields are not written.
+type CaptureMetadata struct {
+	Time time.Time
+	// Software is the program and version that made the capture, e.g. "go-snip v1.2.0".
+	Software string
+	// Display is the captured display index, -1 for all displays. It is only meaningful (and
+	// only written) along with DisplayBounds.
+	Display       int
+	DisplayBounds image.Rectangle
+	// Selection is the captured rectangle in virtual-desktop coordinates.
+	Selection image.Rectangle
+	Host      string
+	// Name and Notes are the user's name for the capture and free-form notes.
+	Name  string
+	Notes string
+	// Other holds text chunks with keywords go-snip doesn't know (e.g. from other programs).
+	Other map[string]string
+}
+
+// IsZero reports whether m has nothing to write.
+func (m CaptureMetadata) IsZero() bool {
+	return len(m.text()) == 0
+}
+
+// text returns m as PNG keyword/text pairs, in a stable order.
+func (m CaptureMetadata) text() [][2]string {
+	var out [][2]string
+	add := func(key, value string) {
+		if value != "" {
+			out = append(out, [2]string{key, value})
+		}
+	}
+	if !m.Time.IsZero() {
+		add(pngKeyTime, m.Time.Format(pngTimeLayout))
+	}
+	add(pngKeySoftware, m.Software)
+	if !m.DisplayBounds.Empty() {
+		display := "all"
+		if m.Display >= 0 {
+			display = strconv.Itoa(m.Display)
+		}
+		add(pngKeyDisplay, display)
+		add(pngKeyDisplayBounds, formatPNGRect(m.DisplayBounds))
+	}
+	if !m.Selection.Empty() {
+		add(pngKeySelection, formatPNGRect(m.Selection))
+	}
+	add(pngKeyHost, m.Host)
+	add(pngKeyName, m.Name)
+	add(pngKeyNotes, m.Notes)
+
+	keys := make([]string, 0, len(m.Other))
+	for k := range m.Other {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, m.Other[k])
+	}
+	return out
+}
+
+// set stores one text chunk into m.
+func (m *CaptureMetadata) set(key, value string) {
+	var err error
+	switch key {
+	case pngKeyTime:
+		if m.Time, err = time.Parse(time.RFC3339, value); err == nil {
+			return
+		}
+	case pngKeySoftware:
+		m.Software = value
+		return
+	case pngKeyDisplay:
+		if value == "all" {
+			m.Display = -1
+			return
+		}
+		if m.Display, err = strconv.Atoi(value); err == nil {
+			return
+		}
+	case pngKeyDisplayBounds:
+		if m.DisplayBounds, err = parsePNGRect(value); err == nil {
+			return
+		}
+	case pngKeySelection:
+		if m.Selection, err = parsePNGRect(value); err == nil {
+			return
+		}
+	case pngKeyHost:
+		m.Host = value
+		return
+	case pngKeyName:
+		m.Name = value
+		return
+	case pngKeyNotes:
+		m.Notes = value
+		return
+	}
+	// Unknown keywords, and known ones that don't parse, are kept verbatim.
+	if m.Other == nil {
+		m.Other = make(map[string]string)
+	}
+	m.Other[key] = value
+}
+
+func formatPNGRect(r image.Rectangle) string {
+	return fmt.Sprintf("%d,%d,%d,%d", r.Min.X, r.Min.Y, r.Max.X, r.Max.Y)
+}
+
+func parsePNGRect(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("rect %q: want x0,y0,x1,y1", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("rect %q: %w", s, err)
+		}
+		v[i] = n
+	}
+	return image.Rect(v[0], v[1], v[2], v[3]), nil
+}
+
+// InjectPNGMetadata returns the PNG in data with m added as text chunks right after the IHDR
+// chunk. image/png can't write text chunks, so go-snip encodes first and injects them here.
+// Values that are plain ASCII go into tEXt chunks, anything else into UTF-8 iTXt chunks.
+func InjectPNGMetadata(data []byte, m CaptureMetadata) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrNotPNG
+	}
+	// The IHDR chunk always comes first: length, type, 13 bytes of data and the CRC.
+	ihdrEnd := len(pngSignature) + 4 + 4 + 13 + 4
+	if len(data) < ihdrEnd || string(data[len(pngSignature)+4:len(pngSignature)+8]) != "IHDR" {
+		return nil, fmt.Errorf("%w: missing IHDR chunk", ErrNotPNG)
+	}
+
+	var chunks bytes.Buffer
+	for _, kv := range m.text() {
+		typ, body, err := pngTextChunk(kv[0], kv[1])
+		if err != nil {
+			return nil, err
+		}
+		writePNGChunk(&chunks, typ, body)
+	}
+
+	out := make([]byte, 0, len(data)+chunks.Len())
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, chunks.Bytes()...)
+	return append(out, data[ihdrEnd:]...), nil
+}
+
+// pngTextChunk returns the type and body of the text chunk for key and value.
+func pngTextChunk(key, value string) (string, []byte, error) {
+	if err := checkPNGKeyword(key); err != nil {
+		return "", nil, err
+	}
+	value = strings.ToValidUTF8(strings.ReplaceAll(value, "\x00", ""), "\uFFFD")
+
+	var b bytes.Buffer
+	b.WriteString(key)
+	b.WriteByte(0)
+	if isASCII(value) {
+		b.WriteString(value)
+		return "tEXt", b.Bytes(), nil
+	}
+	// Uncompressed, with empty language tag and translated keyword.
+	b.Write([]byte{0, 0, 0, 0})
+	b.WriteString(value)
+	return "iTXt", b.Bytes(), nil
+}
+
+// checkPNGKeyword enforces the PNG keyword rules, limited to ASCII: 1-79 printable characters
+// without leading, trailing or consecutive spaces.
+func checkPNGKeyword(key string) error {
+	if len(key) == 0 || len(key) > 79 {
+		return fmt.Errorf("png text keyword %q: want 1 to 79 characters", key)
+	}
+	if strings.HasPrefix(key, " ") || strings.HasSuffix(key, " ") || strings.Contains(key, "  ") {
+		return fmt.Errorf("png text keyword %q: bad spacing", key)
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c < 32 || c > 126 {
+			return fmt.Errorf("png text keyword %q: non-printable character", key)
+		}
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func writePNGChunk(w *bytes.Buffer, typ string, body []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(body)))
+	w.Write(n[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(body)
+	w.WriteString(typ)
+	w.Write(body)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	w.Write(n[:])
+}
+
+// ReadPNGMetadataFile is ReadPNGMetadata for the file at path.
+func ReadPNGMetadataFile(path string) (CaptureMetadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return CaptureMetadata{}, err
+	}
+	defer f.Close()
+	m, err := ReadPNGMetadata(bufio.NewReader(f))
+	if err != nil {
+		return CaptureMetadata{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// ReadPNGMetadata extracts the tEXt, zTXt and iTXt chunks of the PNG in r. Image data is
+// skipped, not decoded.
+func ReadPNGMetadata(r io.Reader) (CaptureMetadata, error) {
+	var m CaptureMetadata
+	sig := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, sig); err != nil || !bytes.Equal(sig, pngSignature) {
+		return m, ErrNotPNG
+	}
+
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return m, fmt.Errorf("read png chunk: %w", err)
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		typ := string(header[4:])
+		if typ == "IEND" {
+			return m, nil
+		}
+		if typ != "tEXt" && typ != "zTXt" && typ != "iTXt" {
+			if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+				return m, fmt.Errorf("read png %s chunk: %w", typ, err)
+			}
+			continue
+		}
+
+		if length > maxPNGTextChunk {
+			return m, fmt.Errorf("png %s chunk of %d bytes is too large", typ, length)
+		}
+		body := make([]byte, length+4)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return m, fmt.Errorf("read png %s chunk: %w", typ, err)
+		}
+		body, sum := body[:length], binary.BigEndian.Uint32(body[length:])
+		crc := crc32.NewIEEE()
+		crc.Write(header[4:])
+		crc.Write(body)
+		if crc.Sum32() != sum {
+			return m, fmt.Errorf("png %s chunk: checksum mismatch", typ)
+		}
+		key, value, err := parsePNGText(typ, body)
+		if err != nil {
+			return m, fmt.Errorf("png %s chunk: %w", typ, err)
+		}
+		m.set(key, value)
+	}
+}
+
+// parsePNGText decodes the body of a tEXt, zTXt or iTXt chunk.
+func parsePNGText(typ string, body []byte) (key, value string, err error) {
+	k, rest, ok := bytes.Cut(body, []byte{0})
+	if !ok {
+		return "", "", errors.New("missing keyword separator")
+	}
+	key = string(k)
+
+	switch typ {
+	case "tEXt":
+		return key, latin1(rest), nil
+	case "zTXt":
+		if len(rest) < 1 || rest[0] != 0 {
+			return "", "", errors.New("unknown compression method")
+		}
+		text, err := inflate(rest[1:])
+		if err != nil {
+			return "", "", err
+		}
+		return key, latin1(text), nil
+	}
+
+	// iTXt: compression flag and method, then language tag and translated keyword.
+	if len(rest) < 2 {
+		return "", "", errors.New("truncated")
+	}
+	compressed, method := rest[0], rest[1]
+	rest = rest[2:]
+	for range 2 {
+		var ok bool
+		if _, rest, ok = bytes.Cut(rest, []byte{0}); !ok {
+			return "", "", errors.New("truncated")
+		}
+	}
+	if compressed != 0 {
+		if method != 0 {
+			return "", "", errors.New("unknown compression method")
+		}
+		if rest, err = inflate(rest); err != nil {
+			return "", "", err
+		}
+	}
+	return key, string(rest), nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxPNGTextChunk+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxPNGTextChunk {
+		return nil, errors.New("compressed text is too large")
+	}
+	return out, nil
+}
+
+// latin1 converts ISO 8859-1 text, the encoding of tEXt and zTXt chunks, to UTF-8.
+func latin1(b []byte) string {
+	if isASCII(string(b)) {
+		return string(b)
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"image"
+	"image/png"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveImage_PNGMetadataRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	want := CaptureMetadata{
+		Time:          time.Date(2025, 1, 2, 3, 4, 5, 678000000, time.FixedZone("CET", 3600)),
+		Software:      "go-snip v1.2.0",
+		Display:       -1,
+		DisplayBounds: image.Rect(-1920, 0, 1920, 1080),
+		Selection:     image.Rect(-100, 20, 300, 220),
+		Host:          "build-box",
+		Name:          "login bug",
+		Notes:         "Anmeldung schlägt fehl\nzweite Zeile",
+	}
+	dest := filepath.Join(t.TempDir(), "a.png")
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	if err := SaveImage(img, dest, SaveOptions{Metadata: want}); err != nil {
+		t.Fatalf("SaveImage() error: %v", err)
+	}
+
+	got, err := ReadPNGMetadataFile(dest)
+	if err != nil {
+		t.Fatalf("ReadPNGMetadataFile() error: %v", err)
+	}
+	if !got.Time.Equal(want.Time) {
+		t.Fatalf("Time got=%v want=%v", got.Time, want.Time)
+	}
+	got.Time = want.Time
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("metadata got=%+v want=%+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeImage(&buf, img, SaveOptions{Metadata: want}); err != nil {
+		t.Fatalf("EncodeImage() error: %v", err)
+	}
+	if _, err := png.Decode(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("png.Decode() with text chunks error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("tEXtgo-snip:selection\x00-100,20,300,220")) || !bytes.Contains(buf.Bytes(), []byte("iTXtComment\x00")) {
+		t.Fatalf("expected tEXt for ASCII and iTXt for UTF-8 values")
+	}
+}
+
+func TestReadPNGMetadata_CompressedAndForeignChunks(t *testing.T) {
+	t.Parallel()
+
+	var plain bytes.Buffer
+	if err := png.Encode(&plain, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	deflate := func(s string) []byte {
+		var b bytes.Buffer
+		zw := zlib.NewWriter(&b)
+		zw.Write([]byte(s))
+		zw.Close()
+		return b.Bytes()
+	}
+	var chunks bytes.Buffer
+	writePNGChunk(&chunks, "zTXt", append([]byte("Author\x00\x00"), deflate("Jos\xe9")...))
+	writePNGChunk(&chunks, "iTXt", append([]byte("Comment\x00\x01\x00de\x00Kommentar\x00"), deflate("grüße")...))
+	writePNGChunk(&chunks, "tEXt", []byte("go-snip:display\x002"))
+	data := plain.Bytes()
+	data = append(append(append([]byte(nil), data[:33]...), chunks.Bytes()...), data[33:]...)
+
+	got, err := ReadPNGMetadata(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadPNGMetadata() error: %v", err)
+	}
+	want := CaptureMetadata{Display: 2, Notes: "grüße", Other: map[string]string{"Author": "José"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("metadata got=%+v want=%+v", got, want)
+	}
+
+	data[33+8+2] ^= 0xff // in the keyword of the first text chunk
+	if _, err := ReadPNGMetadata(bytes.NewReader(data)); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("ReadPNGMetadata(corrupt) error=%v, want a checksum error", err)
+	}
+}
+
+func TestPNGMetadata_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ReadPNGMetadata(strings.NewReader("GIF89a")); !errors.Is(err, ErrNotPNG) {
+		t.Fatalf("ReadPNGMetadata(gif) error=%v want=%v", err, ErrNotPNG)
+	}
+	if _, err := InjectPNGMetadata([]byte("nope"), CaptureMetadata{Name: "x"}); !errors.Is(err, ErrNotPNG) {
+		t.Fatalf("InjectPNGMetadata(not png) error=%v want=%v", err, ErrNotPNG)
+	}
+
+	var plain bytes.Buffer
+	if err := png.Encode(&plain, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", " lead", "double  space", "tab\there", strings.Repeat("k", 80)} {
+		if _, err := InjectPNGMetadata(plain.Bytes(), CaptureMetadata{Other: map[string]string{key: "v"}}); err == nil {
+			t.Fatalf("InjectPNGMetadata(keyword %q) error=nil, want an error", key)
+		}
+	}
+	if !(CaptureMetadata{Display: 3}).IsZero() {
+		t.Fatalf("IsZero() with only Display=false, want true (Display needs DisplayBounds)")
+	}
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// Quantize maps img onto a median-cut palette of at most n colours, with bounds starting at
+// (0,0). Colours are looked up through a 15-bit cache (5 bits per channel), which keeps large
+// images fast at the cost of colour precision: colours that share a cache entry map to the same
+// palette colour. There is no dithering. Transparent pixels are flattened onto black.
+func Quantize(img image.Image, n int) *image.Paletted {
+	b := img.Bounds()
+	pal := medianCut(sampleColors(img, 1<<16), n)
+	dst := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), pal)
+
+	var cache [1 << 15]uint16 // palette index + 1; 0 means not yet looked up
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := opaque(img.At(x, y))
+			key := int(c.R>>3)<<10 | int(c.G>>3)<<5 | int(c.B>>3)
+			if cache[key] == 0 {
+				cache[key] = uint16(pal.Index(c)) + 1
+			}
+			dst.Pix[(y-b.Min.Y)*dst.Stride+(x-b.Min.X)] = uint8(cache[key] - 1)
+		}
+	}
+	return dst
+}
+
+// opaque returns c as RGBA composited over black.
+func opaque(c color.Color) color.RGBA {
+	r, g, b, _ := c.RGBA() // premultiplied, so this is already "over black"
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}
+}
+
+// sampleColors returns up to about limit pixels of img, taken on an even grid.
+func sampleColors(img image.Image, limit int) []color.RGBA {
+	b := img.Bounds()
+	step := 1
+	for (b.Dx()/step)*(b.Dy()/step) > limit {
+		step++
+	}
+	out := make([]color.RGBA, 0, (b.Dx()/step+1)*(b.Dy()/step+1))
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			out = append(out, opaque(img.At(x, y)))
+		}
+	}
+	return out
+}
+
+// medianCut splits the colour box with the widest channel range at its median until there are
+// n boxes (or no box can be split), and returns each box's average colour.
+func medianCut(colors []color.RGBA, n int) color.Palette {
+	if len(colors) == 0 {
+		return color.Palette{color.RGBA{A: 0xff}}
+	}
+	boxes := [][]color.RGBA{colors}
+	for len(boxes) < n {
+		best, bestRange, bestChannel := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if ch, r := widestChannel(box); r > bestRange {
+				best, bestRange, bestChannel = i, r, ch
+			}
+		}
+		if best < 0 {
+			break // every box holds a single colour
+		}
+		box := boxes[best]
+		sort.Slice(box, func(i, j int) bool { return channel(box[i], bestChannel) < channel(box[j], bestChannel) })
+		mid := splitIndex(box, bestChannel)
+		boxes[best] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	pal := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var r, g, b int
+		for _, c := range box {
+			r, g, b = r+int(c.R), g+int(c.G), b+int(c.B)
+		}
+		k := len(box)
+		pal = append(pal, color.RGBA{R: uint8(r / k), G: uint8(g / k), B: uint8(b / k), A: 0xff})
+	}
+	return pal
+}
+
+// splitIndex returns the boundary between two distinct values of channel ch nearest the middle
+// of the sorted box, so equal colours never end up in different boxes.
+func splitIndex(box []color.RGBA, ch int) int {
+	mid := len(box) / 2
+	v := channel(box[mid], ch)
+	lo := mid
+	for lo > 0 && channel(box[lo-1], ch) == v {
+		lo--
+	}
+	hi := mid
+	for hi < len(box) && channel(box[hi], ch) == v {
+		hi++
+	}
+	if lo == 0 || (hi < len(box) && hi-mid < mid-lo) {
+		return hi
+	}
+	return lo
+}
+
+func widestChannel(box []color.RGBA) (ch, width int) {
+	for c := 0; c < 3; c++ {
+		lo, hi := 255, 0
+		for _, col := range box {
+			v := int(channel(col, c))
+			lo, hi = min(lo, v), max(hi, v)
+		}
+		if hi-lo > width {
+			ch, width = c, hi-lo
+		}
+	}
+	return ch, width
+}
+
+func channel(c color.RGBA, ch int) uint8 {
+	switch ch {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	}
+	return c.B
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestQuantize_KeepsFewColorsExact(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(10, 10, 74, 42))
+	for y := 10; y < 42; y++ {
+		for x := 10; x < 74; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8((x / 8) * 30), G: uint8((y / 8) * 50), B: 7, A: 255})
+		}
+	}
+	got := Quantize(img, 256)
+	if got.Bounds() != image.Rect(0, 0, 64, 32) {
+		t.Fatalf("bounds got=%v", got.Bounds())
+	}
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			want := img.RGBAAt(x+10, y+10)
+			if c := color.RGBAModel.Convert(got.At(x, y)); c != want {
+				t.Fatalf("pixel (%d,%d) got=%v want=%v", x, y, c, want)
+			}
+		}
+	}
+}
+
+func TestMedianCut_LimitsPaletteSize(t *testing.T) {
+	t.Parallel()
+
+	var colors []color.RGBA
+	for r := 0; r < 256; r += 8 {
+		for g := 0; g < 256; g += 8 {
+			colors = append(colors, color.RGBA{R: uint8(r), G: uint8(g), B: 100, A: 255})
+		}
+	}
+	if pal := medianCut(colors, 16); len(pal) != 16 {
+		t.Fatalf("medianCut() colors got=%d want=16", len(pal))
+	}
+	if pal := medianCut(nil, 16); len(pal) != 1 {
+		t.Fatalf("medianCut(nil) colors got=%d want=1", len(pal))
+	}
+}